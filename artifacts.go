@@ -0,0 +1,264 @@
+package nervo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const artifactIndexFile = "index.json"
+
+// Artifact is a firmware image kept on the server, identified by the sha256 of its content
+type Artifact struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// Board the firmware is built for, e.g. "uno"
+	Board       string         `json:"board"`
+	Notes       string         `json:"notes"`
+	Format      FirmwareFormat `json:"format"`
+	BaseAddress uint32         `json:"base_address"`
+	Size        int            `json:"size"`
+	// Tags are unique among the artifacts of the same name, e.g. "stable"
+	Tags       []string  `json:"tags"`
+	UploadedAt time.Time `json:"uploaded_at"`
+	UploadedBy string    `json:"uploaded_by"`
+}
+
+// ArtifactStore keeps firmware images with their metadata, so they don't have to be uploaded for every flash.
+// Artifacts are referenced by their id or by "<name>:<tag or version>", e.g. "leg-firmware:stable"
+type ArtifactStore struct {
+	// directory holds the index and a file per artifact. Without a directory, artifacts are only kept in memory
+	directory string
+	mutex     *sync.Mutex
+	artifacts map[string]*Artifact
+	contents  map[string][]byte
+}
+
+// NewArtifactStore loads the artifacts kept in directory. If directory is empty, artifacts are lost when the server stops
+func NewArtifactStore(directory string) (*ArtifactStore, error) {
+	s := &ArtifactStore{
+		directory: directory,
+		mutex:     &sync.Mutex{},
+		artifacts: map[string]*Artifact{},
+		contents:  map[string][]byte{},
+	}
+	if directory == "" {
+		return s, nil
+	}
+
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return nil, err
+	}
+	content, err := ioutil.ReadFile(path.Join(directory, artifactIndexFile))
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	artifacts := []*Artifact{}
+	if err := json.Unmarshal(content, &artifacts); err != nil {
+		return nil, fmt.Errorf("parsing the artifact index in %s: %v", directory, err)
+	}
+	for _, artifact := range artifacts {
+		s.artifacts[artifact.ID] = artifact
+	}
+	return s, nil
+}
+
+// add keeps content with the metadata of artifact. Content that is already kept fails with ArtifactExistsError
+func (s *ArtifactStore) add(artifact Artifact, content []byte) (Artifact, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	sum := sha256.Sum256(content)
+	id := hex.EncodeToString(sum[:])
+	if existing, ok := s.artifacts[id]; ok {
+		return Artifact{}, &ArtifactExistsError{ID: id, Name: existing.Name, Version: existing.Version}
+	}
+
+	artifact.ID = id
+	artifact.Size = len(content)
+	artifact.UploadedAt = time.Now()
+	tags := artifact.Tags
+	artifact.Tags = nil
+	if s.directory != "" {
+		if err := writeFileAtomically(path.Join(s.directory, id+".bin"), content); err != nil {
+			return Artifact{}, err
+		}
+	} else {
+		s.contents[id] = content
+	}
+	s.artifacts[id] = &artifact
+	for _, tag := range tags {
+		s.moveTag(&artifact, tag)
+	}
+	return artifact, s.saveIndex()
+}
+
+// list returns the artifacts named name or all of them if name is empty, the newest first
+func (s *ArtifactStore) list(name string) []Artifact {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	artifacts := []Artifact{}
+	for _, artifact := range s.artifacts {
+		if name == "" || artifact.Name == name {
+			artifacts = append(artifacts, *artifact)
+		}
+	}
+	sort.Slice(artifacts, func(i, j int) bool {
+		return artifacts[i].UploadedAt.After(artifacts[j].UploadedAt)
+	})
+	return artifacts
+}
+
+// get returns the artifact reference points to and its content
+func (s *ArtifactStore) get(reference string) (Artifact, []byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	artifact, err := s.resolve(reference)
+	if err != nil {
+		return Artifact{}, nil, err
+	}
+	if s.directory == "" {
+		return *artifact, s.contents[artifact.ID], nil
+	}
+	content, err := ioutil.ReadFile(path.Join(s.directory, artifact.ID+".bin"))
+	if err != nil {
+		return Artifact{}, nil, err
+	}
+	return *artifact, content, nil
+}
+
+// tag adds tag to the artifact reference points to, and removes it from other artifacts of the same name
+func (s *ArtifactStore) tag(reference, tag string) (Artifact, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	artifact, err := s.resolve(reference)
+	if err != nil {
+		return Artifact{}, err
+	}
+	s.moveTag(artifact, tag)
+	return *artifact, s.saveIndex()
+}
+
+// untag removes tag from the artifact reference points to
+func (s *ArtifactStore) untag(reference, tag string) (Artifact, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	artifact, err := s.resolve(reference)
+	if err != nil {
+		return Artifact{}, err
+	}
+	artifact.Tags = withoutString(artifact.Tags, tag)
+	return *artifact, s.saveIndex()
+}
+
+// remove deletes the artifact reference points to, including its content
+func (s *ArtifactStore) remove(reference string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	artifact, err := s.resolve(reference)
+	if err != nil {
+		return err
+	}
+	delete(s.artifacts, artifact.ID)
+	delete(s.contents, artifact.ID)
+	if s.directory != "" {
+		if err := os.Remove(path.Join(s.directory, artifact.ID+".bin")); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return s.saveIndex()
+}
+
+// resolve finds the artifact by id, then by name and tag and then by name and version, the newest if several match
+func (s *ArtifactStore) resolve(reference string) (*Artifact, error) {
+	if artifact, ok := s.artifacts[reference]; ok {
+		return artifact, nil
+	}
+
+	i := strings.LastIndex(reference, ":")
+	if i < 0 {
+		return nil, &ArtifactNotFoundError{Reference: reference}
+	}
+	name, tagOrVersion := reference[:i], reference[i+1:]
+	var byVersion *Artifact
+	for _, artifact := range s.artifacts {
+		if artifact.Name != name {
+			continue
+		}
+		for _, tag := range artifact.Tags {
+			if tag == tagOrVersion {
+				return artifact, nil
+			}
+		}
+		if artifact.Version == tagOrVersion && (byVersion == nil || artifact.UploadedAt.After(byVersion.UploadedAt)) {
+			byVersion = artifact
+		}
+	}
+	if byVersion != nil {
+		return byVersion, nil
+	}
+	return nil, &ArtifactNotFoundError{Reference: reference}
+}
+
+func (s *ArtifactStore) moveTag(to *Artifact, tag string) {
+	for _, artifact := range s.artifacts {
+		if artifact.Name == to.Name {
+			artifact.Tags = withoutString(artifact.Tags, tag)
+		}
+	}
+	to.Tags = append(to.Tags, tag)
+	sort.Strings(to.Tags)
+}
+
+func (s *ArtifactStore) saveIndex() error {
+	if s.directory == "" {
+		return nil
+	}
+
+	artifacts := []*Artifact{}
+	for _, artifact := range s.artifacts {
+		artifacts = append(artifacts, artifact)
+	}
+	sort.Slice(artifacts, func(i, j int) bool { return artifacts[i].UploadedAt.Before(artifacts[j].UploadedAt) })
+	content, err := json.MarshalIndent(artifacts, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomically(path.Join(s.directory, artifactIndexFile), content)
+}
+
+// writeFileAtomically writes to a temporary file first, so a crash never leaves a half written file behind
+func writeFileAtomically(filePath string, content []byte) error {
+	tmpPath := filePath + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, content, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, filePath)
+}
+
+func withoutString(values []string, value string) []string {
+	without := []string{}
+	for _, v := range values {
+		if v != value {
+			without = append(without, v)
+		}
+	}
+	return without
+}
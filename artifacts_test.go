@@ -0,0 +1,69 @@
+package nervo
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ArtifactStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nervo_artifacts")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	store, err := NewArtifactStore(dir)
+	require.NoError(t, err)
+	v1, err := store.add(Artifact{Name: "leg-firmware", Version: "1.0", Tags: []string{"stable"}}, []byte("v1"))
+	require.NoError(t, err)
+	v2, err := store.add(Artifact{Name: "leg-firmware", Version: "2.0"}, []byte("v2"))
+	require.NoError(t, err)
+	other, err := store.add(Artifact{Name: "arm-firmware", Version: "1.0", Tags: []string{"stable"}}, []byte("arm"))
+	require.NoError(t, err)
+
+	_, err = store.add(Artifact{Name: "renamed", Tags: []string{"beta"}}, []byte("v1"))
+	assert.Equal(t, &ArtifactExistsError{ID: v1.ID, Name: "leg-firmware", Version: "1.0"}, err, "identical content is kept once")
+
+	_, err = store.tag("leg-firmware:2.0", "stable")
+	require.NoError(t, err)
+
+	// reloading from the directory keeps everything
+	store, err = NewArtifactStore(dir)
+	require.NoError(t, err)
+
+	tests := []struct {
+		testMessage string
+		reference   string
+		expectedID  string
+	}{
+		{testMessage: "by id", reference: v1.ID, expectedID: v1.ID},
+		{testMessage: "by moved tag", reference: "leg-firmware:stable", expectedID: v2.ID},
+		{testMessage: "tags are per name", reference: "arm-firmware:stable", expectedID: other.ID},
+		{testMessage: "by version", reference: "leg-firmware:1.0", expectedID: v1.ID},
+		{testMessage: "unknown tag", reference: "leg-firmware:beta"},
+		{testMessage: "name only", reference: "leg-firmware"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.testMessage, func(t *testing.T) {
+			artifact, _, err := store.get(tt.reference)
+			if tt.expectedID == "" {
+				assert.IsType(t, &ArtifactNotFoundError{}, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedID, artifact.ID)
+		})
+	}
+
+	_, content, err := store.get("leg-firmware:stable")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v2"), content)
+
+	require.NoError(t, store.remove("leg-firmware:stable"))
+	assert.Len(t, store.list("leg-firmware"), 1)
+	assert.Len(t, store.list(""), 2)
+	_, err = os.Stat(dir + "/" + v2.ID + ".bin")
+	assert.True(t, os.IsNotExist(err))
+}
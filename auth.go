@@ -0,0 +1,211 @@
+package nervo
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Role of an api token. Every role includes the permissions of the roles before it
+type Role int
+
+// Roles from least to most privileged
+const (
+	RoleViewer Role = iota + 1
+	RoleOperator
+	RoleAdmin
+)
+
+var roleNames = map[Role]string{
+	RoleViewer:   "viewer",
+	RoleOperator: "operator",
+	RoleAdmin:    "admin",
+}
+
+func (r Role) String() string {
+	if name, ok := roleNames[r]; ok {
+		return name
+	}
+	return fmt.Sprintf("Role(%d)", int(r))
+}
+
+// ParseRole parses "viewer", "operator" or "admin"
+func ParseRole(name string) (Role, error) {
+	for role, roleName := range roleNames {
+		if strings.EqualFold(roleName, name) {
+			return role, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown role %q", name)
+}
+
+// requiredRoles maps the full grpc method names to the role needed to call them. Methods missing here need RoleAdmin
+var requiredRoles = map[string]Role{
+	"/proto.NervoService/ListControllers":                  RoleViewer,
+	"/proto.NervoService/ReadControllerOutput":             RoleViewer,
+	"/proto.NervoService/ReadControllerOutputContinuously": RoleViewer,
+	"/proto.NervoService/ExplainDiscovery":                 RoleViewer,
+	"/proto.NervoService/GetServerInfo":                    RoleViewer,
+	"/proto.NervoService/ReadMultiplexedOutput":            RoleViewer,
+	"/proto.NervoService/ListArtifacts":                    RoleViewer,
+	"/proto.NervoService/GetFlashHistory":                  RoleViewer,
+	"/proto.NervoService/WriteToController":                RoleOperator,
+	"/proto.NervoService/WriteToControllerContinuously":    RoleOperator,
+	"/proto.NervoService/WriteToControllers":               RoleOperator,
+	"/proto.NervoService/Session":                          RoleOperator,
+	"/proto.NervoService/SetControllerName":                RoleAdmin,
+	"/proto.NervoService/SetControllerLabels":              RoleAdmin,
+	"/proto.NervoService/FlashController":                  RoleAdmin,
+	"/proto.NervoService/UploadFirmware":                   RoleAdmin,
+	"/proto.NervoService/FlashFirmware":                    RoleAdmin,
+	"/proto.NervoService/UploadArtifact":                   RoleAdmin,
+	"/proto.NervoService/TagArtifact":                      RoleAdmin,
+	"/proto.NervoService/DeleteArtifact":                   RoleAdmin,
+	"/proto.NervoService/RollbackController":               RoleAdmin,
+	"/proto.NervoService/FlashControllers":                 RoleAdmin,
+	"/proto.NervoService/ResetUsb":                         RoleAdmin,
+}
+
+func requiredRole(fullMethod string) Role {
+	if role, ok := requiredRoles[fullMethod]; ok {
+		return role
+	}
+	return RoleAdmin
+}
+
+// APIToken as it is configured in the tokens file
+type APIToken struct {
+	Token string `json:"token"`
+	Name  string `json:"name"`
+	Role  string `json:"role"`
+}
+
+type apiTokenGrant struct {
+	token []byte
+	name  string
+	role  Role
+}
+
+// TokenAuthenticator checks the api token every request carries as "authorization: Bearer <token>"
+type TokenAuthenticator struct {
+	grants []apiTokenGrant
+}
+
+type authenticatedClientKey struct{}
+
+type authenticatedClient struct {
+	name string
+	role Role
+}
+
+// NewTokenAuthenticator validates the given tokens
+func NewTokenAuthenticator(tokens []APIToken) (*TokenAuthenticator, error) {
+	a := &TokenAuthenticator{}
+	for i, token := range tokens {
+		if len(token.Token) < 16 {
+			return nil, fmt.Errorf("token #%d (%s) is shorter than 16 characters", i+1, token.Name)
+		}
+		role, err := ParseRole(token.Role)
+		if err != nil {
+			return nil, fmt.Errorf("token #%d (%s): %v", i+1, token.Name, err)
+		}
+		a.grants = append(a.grants, apiTokenGrant{token: []byte(token.Token), name: token.Name, role: role})
+	}
+	return a, nil
+}
+
+// LoadTokenAuthenticator reads a json list of APITokens from path
+func LoadTokenAuthenticator(tokensPath string) (*TokenAuthenticator, error) {
+	content, err := ioutil.ReadFile(tokensPath)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := []APIToken{}
+	if err := json.Unmarshal(content, &tokens); err != nil {
+		return nil, fmt.Errorf("parsing %s: %v", tokensPath, err)
+	}
+	return NewTokenAuthenticator(tokens)
+}
+
+// authenticate finds the grant for an authorization header value in the form "Bearer <token>"
+func (a *TokenAuthenticator) authenticate(authorization string) (name string, role Role, err error) {
+	if !strings.HasPrefix(authorization, "Bearer ") {
+		return "", 0, status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+
+	token := []byte(strings.TrimPrefix(authorization, "Bearer "))
+	for _, grant := range a.grants {
+		if subtle.ConstantTimeCompare(grant.token, token) == 1 {
+			return grant.name, grant.role, nil
+		}
+	}
+	return "", 0, status.Error(codes.Unauthenticated, "invalid token")
+}
+
+// authorize checks the token in the incoming metadata against the role fullMethod requires
+func (a *TokenAuthenticator) authorize(ctx context.Context, fullMethod string) (context.Context, error) {
+	authorization := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get("authorization")) > 0 {
+		authorization = md.Get("authorization")[0]
+	}
+	return a.authorizeHeader(ctx, authorization, fullMethod)
+}
+
+// authorizeHeader checks the value of an authorization header against the role fullMethod requires
+func (a *TokenAuthenticator) authorizeHeader(ctx context.Context, authorization, fullMethod string) (context.Context, error) {
+	name, role, err := a.authenticate(authorization)
+	if err != nil {
+		return ctx, err
+	}
+
+	if required := requiredRole(fullMethod); role < required {
+		return ctx, status.Errorf(codes.PermissionDenied, "%s needs the %s role, %s is %s", fullMethod, required, name, role)
+	}
+	return context.WithValue(ctx, authenticatedClientKey{}, authenticatedClient{name: name, role: role}), nil
+}
+
+func (a *TokenAuthenticator) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := a.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *TokenAuthenticator) streamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authorize(stream.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedServerStream{ServerStream: stream, ctx: ctx})
+}
+
+type authenticatedServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func authenticatedName(ctx context.Context) (name string, ok bool) {
+	client, ok := ctx.Value(authenticatedClientKey{}).(authenticatedClient)
+	return client.name, ok
+}
+
+// mayCall tells whether the client authenticated in ctx has the role fullMethod requires.
+// Without authentication everybody may call everything
+func mayCall(ctx context.Context, fullMethod string) bool {
+	client, ok := ctx.Value(authenticatedClientKey{}).(authenticatedClient)
+	return !ok || client.role >= requiredRole(fullMethod)
+}
@@ -0,0 +1,83 @@
+package nervo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/codeuniversity/nervo/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func Test_GrpcServer_TokenAuthentication(t *testing.T) {
+	authenticator, err := NewTokenAuthenticator([]APIToken{
+		{Token: "viewer-token-0123456789", Name: "dashboard", Role: "viewer"},
+		{Token: "operator-token-0123456789", Name: "gait", Role: "operator"},
+		{Token: "admin-token-0123456789", Name: "alice", Role: "admin"},
+	})
+	require.NoError(t, err)
+
+	h := newConfiguredTestHarness(t, func(s *GrpcServer) {
+		s.Authenticator = authenticator
+	})
+	defer h.close()
+	h.attach("/dev/ttyACM0", "leg1")
+
+	withToken := func(token string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+	}
+	write := func(ctx context.Context) error {
+		_, err := h.client.WriteToController(ctx, &proto.WriteToControllerRequest{ControllerPortName: "/dev/ttyACM0"})
+		return err
+	}
+	list := func(ctx context.Context) error {
+		_, err := h.client.ListControllers(ctx, &proto.ControllerListRequest{})
+		return err
+	}
+	rename := func(ctx context.Context) error {
+		_, err := h.client.SetControllerName(ctx, &proto.ControllerInfo{PortName: "/dev/ttyACM0", Name: "leg1"})
+		return err
+	}
+	writeContinuously := func(ctx context.Context) error {
+		stream, err := h.client.WriteToControllerContinuously(ctx)
+		if err != nil {
+			return err
+		}
+		_, err = stream.CloseAndRecv()
+		return err
+	}
+
+	tests := []struct {
+		testMessage  string
+		ctx          context.Context
+		call         func(context.Context) error
+		expectedCode codes.Code
+	}{
+		{"without a token", context.Background(), list, codes.Unauthenticated},
+		{"with an unknown token", withToken("some-token-0123456789"), list, codes.Unauthenticated},
+		{"viewer listing", withToken("viewer-token-0123456789"), list, codes.OK},
+		{"viewer writing", withToken("viewer-token-0123456789"), write, codes.PermissionDenied},
+		{"viewer streaming writes", withToken("viewer-token-0123456789"), writeContinuously, codes.PermissionDenied},
+		{"operator writing", withToken("operator-token-0123456789"), write, codes.OK},
+		{"operator renaming", withToken("operator-token-0123456789"), rename, codes.PermissionDenied},
+		{"admin renaming", withToken("admin-token-0123456789"), rename, codes.OK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testMessage, func(t *testing.T) {
+			err := test.call(test.ctx)
+			assert.Equal(t, test.expectedCode, status.Code(err), "error: %v", err)
+		})
+	}
+}
+
+func Test_NewTokenAuthenticator(t *testing.T) {
+	_, err := NewTokenAuthenticator([]APIToken{{Token: "short", Name: "alice", Role: "admin"}})
+	assert.Error(t, err, "rejects short tokens")
+
+	_, err = NewTokenAuthenticator([]APIToken{{Token: "long-enough-0123456789", Name: "alice", Role: "root"}})
+	assert.Error(t, err, "rejects unknown roles")
+}
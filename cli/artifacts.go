@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/codeuniversity/nervo/proto"
+
+	"github.com/manifoldco/promptui"
+)
+
+// uploadArtifact keeps a firmware file on the server, so it can be flashed by id or tag later
+func uploadArtifact(client proto.NervoServiceClient) {
+	source := flashSource
+	if source == "" {
+		source = "."
+	}
+	s := promptui.Select{
+		Label: "What firmware do you want to upload?",
+		Items: findFirmwareFileNames(source),
+	}
+	_, fileName, err := s.Run()
+	if err != nil {
+		exitWithError(err)
+	}
+	content, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	metadata := &proto.Artifact{
+		Name:    askFor("Name of the firmware, e.g. leg-firmware", true),
+		Version: askFor("Version", false),
+		Board:   askFor("Board it is built for, e.g. uno", false),
+		Notes:   askFor("Notes", false),
+		Tags:    splitList(askFor("Comma separated tags, e.g. stable", false)),
+	}
+	if isRawImage(fileName) {
+		metadata.Format = proto.FirmwareFormat_BIN
+		metadata.BaseAddress = askForBaseAddress()
+	}
+
+	stream, err := client.UploadArtifact(context.Background())
+	if err != nil {
+		exitWithError(err)
+	}
+	if err := stream.Send(&proto.ArtifactChunk{Metadata: metadata}); err == nil {
+		for start := 0; start < len(content); start += firmwareChunkSize {
+			end := start + firmwareChunkSize
+			if end > len(content) {
+				end = len(content)
+			}
+			if err := stream.Send(&proto.ArtifactChunk{Data: content[start:end]}); err != nil {
+				// the server ended the stream, CloseAndRecv returns why
+				break
+			}
+		}
+	}
+	artifact, err := stream.CloseAndRecv()
+	if err != nil {
+		exitWithError(err)
+	}
+	printArtifact(artifact)
+}
+
+func listArtifacts(client proto.NervoServiceClient) {
+	response, err := client.ListArtifacts(context.Background(), &proto.ListArtifactsRequest{})
+	if err != nil {
+		exitWithError(err)
+	}
+	if len(response.Artifacts) == 0 {
+		fmt.Println("no artifacts uploaded yet")
+	}
+	for _, artifact := range response.Artifacts {
+		printArtifact(artifact)
+		fmt.Println()
+	}
+}
+
+func tagArtifact(client proto.NervoServiceClient) {
+	reference := askForArtifact(client)
+	tag := askFor("Tag", true)
+	s := promptui.Select{
+		Label: "Add or remove the tag?",
+		Items: []string{"add", "remove"},
+	}
+	_, choice, err := s.Run()
+	if err != nil {
+		exitWithError(err)
+	}
+
+	artifact, err := client.TagArtifact(context.Background(), &proto.TagArtifactRequest{
+		Reference: reference,
+		Tag:       tag,
+		Remove:    choice == "remove",
+	})
+	if err != nil {
+		exitWithError(err)
+	}
+	printArtifact(artifact)
+}
+
+func deleteArtifact(client proto.NervoServiceClient) {
+	reference := askForArtifact(client)
+	if _, err := client.DeleteArtifact(context.Background(), &proto.DeleteArtifactRequest{Reference: reference}); err != nil {
+		exitWithError(err)
+	}
+	fmt.Println("deleted", reference)
+}
+
+// flashArtifact flashes an artifact kept on the server, e.g. "leg-firmware:stable"
+func flashArtifact(client proto.NervoServiceClient, controllerPortName string) {
+	reference := flashSource
+	if reference == "" {
+		reference = askForArtifact(client)
+	}
+	flashWithUsbReset(client, &proto.FlashFirmwareRequest{ControllerPortName: controllerPortName, Artifact: reference})
+}
+
+// askForArtifact lets the user choose one of the artifacts on the server and returns its id
+func askForArtifact(client proto.NervoServiceClient) string {
+	response, err := client.ListArtifacts(context.Background(), &proto.ListArtifactsRequest{})
+	if err != nil {
+		exitWithError(err)
+	}
+	if len(response.Artifacts) == 0 {
+		exitWithError(fmt.Errorf("no artifacts uploaded yet"))
+	}
+
+	items := []string{}
+	for _, artifact := range response.Artifacts {
+		items = append(items, fmt.Sprintf("%s %s [%s] %s", artifact.Name, artifact.Version, strings.Join(artifact.Tags, ", "), artifact.Id[:12]))
+	}
+	s := promptui.Select{
+		Label: "Which artifact?",
+		Items: items,
+	}
+	i, _, err := s.Run()
+	if err != nil {
+		exitWithError(err)
+	}
+	return response.Artifacts[i].Id
+}
+
+func askFor(label string, required bool) string {
+	prompt := promptui.Prompt{Label: label}
+	if required {
+		prompt.Validate = func(input string) error {
+			if strings.TrimSpace(input) == "" {
+				return fmt.Errorf("%s is required", label)
+			}
+			return nil
+		}
+	}
+	input, err := prompt.Run()
+	if err != nil {
+		exitWithError(err)
+	}
+	return strings.TrimSpace(input)
+}
+
+func splitList(input string) []string {
+	values := []string{}
+	for _, value := range strings.Split(input, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func printArtifact(artifact *proto.Artifact) {
+	fmt.Println("id:      ", artifact.Id)
+	fmt.Println("name:    ", artifact.Name, artifact.Version)
+	fmt.Println("board:   ", artifact.Board)
+	fmt.Println("format:  ", strings.ToLower(artifact.Format.String()), artifact.Size, "bytes")
+	fmt.Println("tags:    ", strings.Join(artifact.Tags, ", "))
+	fmt.Println("uploaded:", time.Unix(0, artifact.UploadedAtUnixNano).Format(time.RFC3339), "by", artifact.UploadedBy)
+	if artifact.Notes != "" {
+		fmt.Println("notes:   ", artifact.Notes)
+	}
+}
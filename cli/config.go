@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// config of the cli. It is read from ~/.nervo-cli.json, flags take precedence
+type config struct {
+	Address       string `json:"address"`
+	TLS           bool   `json:"tls"`
+	TLSCAFile     string `json:"tls_ca"`
+	TLSCertFile   string `json:"tls_cert"`
+	TLSKeyFile    string `json:"tls_key"`
+	TLSServerName string `json:"tls_server_name"`
+	Token         string `json:"token"`
+}
+
+func loadConfig() (*config, error) {
+	c := &config{}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return c, nil
+	}
+
+	content, err := ioutil.ReadFile(path.Join(home, ".nervo-cli.json"))
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return c, json.Unmarshal(content, c)
+}
+
+func (c *config) registerFlags() {
+	flag.BoolVar(&c.TLS, "tls", c.TLS, "connect to the server over tls. Implied by the other tls flags")
+	flag.StringVar(&c.TLSCAFile, "tls_ca", c.TLSCAFile, "CA file to verify the server certificate with. If not given, the system CAs are used")
+	flag.StringVar(&c.TLSCertFile, "tls_cert", c.TLSCertFile, "client certificate file for mutual tls")
+	flag.StringVar(&c.TLSKeyFile, "tls_key", c.TLSKeyFile, "private key file belonging to tls_cert")
+	flag.StringVar(&c.TLSServerName, "tls_server_name", c.TLSServerName, "overrides the name the server certificate is verified against")
+	flag.StringVar(&c.Token, "token", c.Token, "api token sent with every request. Defaults to $NERVO_TOKEN")
+}
+
+func (c *config) dialOptions() ([]grpc.DialOption, error) {
+	transportOption, err := c.transportDialOption()
+	if err != nil {
+		return nil, err
+	}
+
+	options := []grpc.DialOption{transportOption}
+	token := c.Token
+	if token == "" {
+		token = os.Getenv("NERVO_TOKEN")
+	}
+	if token != "" {
+		options = append(options, grpc.WithPerRPCCredentials(tokenCredentials(token)))
+	}
+	return options, nil
+}
+
+func (c *config) transportDialOption() (grpc.DialOption, error) {
+	if !c.TLS && c.TLSCAFile == "" && c.TLSCertFile == "" && c.TLSServerName == "" {
+		return grpc.WithInsecure(), nil
+	}
+
+	tlsConfig := &tls.Config{ServerName: c.TLSServerName}
+	if c.TLSCAFile != "" {
+		pem, err := ioutil.ReadFile(c.TLSCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("no certificates found in " + c.TLSCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if c.TLSCertFile != "" || c.TLSKeyFile != "" {
+		certificate, err := tls.LoadX509KeyPair(c.TLSCertFile, c.TLSKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)), nil
+}
+
+// tokenCredentials sends the api token as bearer token with every request
+type tokenCredentials string
+
+func (t tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(t)}, nil
+}
+
+func (t tokenCredentials) RequireTransportSecurity() bool {
+	return false
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/codeuniversity/nervo/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/manifoldco/promptui"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+)
+
+// exitWithError prints err in a readable way, including the details the server attached, and exits
+func exitWithError(err error) {
+	if err == promptui.ErrInterrupt || err == promptui.ErrEOF {
+		os.Exit(1)
+	}
+
+	s, ok := status.FromError(err)
+	if !ok {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+
+	fmt.Fprintf(os.Stderr, "%s: %s\n", s.Code(), s.Message())
+	printStatusDetails(s)
+	os.Exit(1)
+}
+
+func printStatusDetails(s *status.Status) {
+	for _, detail := range s.Details() {
+		switch d := detail.(type) {
+		case *errdetails.DebugInfo:
+			if d.Detail != "" {
+				fmt.Fprintln(os.Stderr, d.Detail)
+			}
+		case *errdetails.RetryInfo:
+			if delay, err := ptypes.Duration(d.RetryDelay); err == nil {
+				fmt.Fprintln(os.Stderr, "retry in", delay)
+			}
+		case *errdetails.PreconditionFailure:
+			for _, violation := range d.Violations {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", violation.Subject, violation.Description)
+			}
+		case *errdetails.QuotaFailure:
+			for _, violation := range d.Violations {
+				fmt.Fprintln(os.Stderr, violation.Description)
+			}
+		case *proto.FlashReport:
+			if advice, ok := flashAdvice[d.Failure]; ok {
+				fmt.Fprintln(os.Stderr, strings.ToLower(strings.Replace(d.Failure.String(), "_", " ", -1))+":", advice)
+			}
+		}
+	}
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codeuniversity/nervo/proto"
+
+	"github.com/manifoldco/promptui"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// firmwareChunkSize stays well below the default grpc message limit of 4 MiB
+const firmwareChunkSize = 256 << 10
+
+// uploadFirmware sends content in chunks and returns the id to flash it with
+func uploadFirmware(client proto.NervoServiceClient, content []byte) (uploadID string, err error) {
+	stream, err := client.UploadFirmware(context.Background())
+	if err != nil {
+		return "", err
+	}
+	for start := 0; start < len(content); start += firmwareChunkSize {
+		end := start + firmwareChunkSize
+		if end > len(content) {
+			end = len(content)
+		}
+		if err := stream.Send(&proto.FirmwareChunk{Data: content[start:end]}); err != nil {
+			// the server ended the stream, CloseAndRecv returns why
+			break
+		}
+	}
+	response, err := stream.CloseAndRecv()
+	if err != nil {
+		return "", err
+	}
+	return response.UploadId, nil
+}
+
+// flashFirmwareRequest asks for the base address of raw .bin files, the server tells hex and elf files apart itself
+func flashFirmwareRequest(controllerPortName, uploadID, fileName string) *proto.FlashFirmwareRequest {
+	request := &proto.FlashFirmwareRequest{ControllerPortName: controllerPortName, UploadId: uploadID}
+	if !isRawImage(fileName) {
+		return request
+	}
+	request.Format = proto.FirmwareFormat_BIN
+	request.BaseAddress = askForBaseAddress()
+	return request
+}
+
+func isRawImage(fileName string) bool {
+	return strings.HasSuffix(strings.ToLower(fileName), ".bin")
+}
+
+func askForBaseAddress() uint32 {
+	prompt := promptui.Prompt{
+		Label:   "At which address should the raw image be written?",
+		Default: "0x0",
+		Validate: func(input string) error {
+			_, err := strconv.ParseUint(input, 0, 32)
+			return err
+		},
+	}
+	input, err := prompt.Run()
+	if err != nil {
+		exitWithError(err)
+	}
+	baseAddress, _ := strconv.ParseUint(input, 0, 32)
+	return uint32(baseAddress)
+}
+
+// flashUpload flashes an uploaded firmware and draws the progress as it goes. It returns the DONE message with the output of the flasher
+func flashUpload(client proto.NervoServiceClient, request *proto.FlashFirmwareRequest) (*proto.FlashProgress, error) {
+	stream, err := client.FlashFirmware(context.Background(), request)
+	if err != nil {
+		return nil, err
+	}
+	return printFlashProgress(stream)
+}
+
+// flashProgressStream is the client side of the rpcs that flash and stream the progress
+type flashProgressStream interface {
+	Recv() (*proto.FlashProgress, error)
+}
+
+// printFlashProgress draws the progress until the stream ends and returns the DONE message
+func printFlashProgress(stream flashProgressStream) (done *proto.FlashProgress, err error) {
+	lastPhase := proto.FlashProgress_UNKNOWN
+	for {
+		progress, err := stream.Recv()
+		if err == io.EOF {
+			return done, nil
+		}
+		if err != nil {
+			if lastPhase != proto.FlashProgress_UNKNOWN {
+				fmt.Println()
+			}
+			return nil, err
+		}
+
+		if progress.Phase == proto.FlashProgress_DONE {
+			done = progress
+		}
+		if lastPhase != proto.FlashProgress_UNKNOWN && progress.Phase != lastPhase {
+			fmt.Println()
+		}
+		if progress.Phase != proto.FlashProgress_DONE {
+			fmt.Printf("\r%-7s %s %3d%%", strings.ToLower(progress.Phase.String()), progressBar(progress.Percent), progress.Percent)
+		}
+		lastPhase = progress.Phase
+	}
+}
+
+// printFlashDone prints the output of the flasher and a summary of its report
+func printFlashDone(done *proto.FlashProgress) {
+	if done == nil {
+		return
+	}
+	fmt.Println(done.Output)
+	report := done.Report
+	if report == nil || (report.DeviceSignature == "" && report.BytesWritten == 0) {
+		return
+	}
+
+	summary := fmt.Sprintf("%d bytes written", report.BytesWritten)
+	if report.Verified {
+		summary += " and verified"
+	}
+	if report.DeviceSignature != "" {
+		summary += " to " + report.Device + " (" + report.DeviceSignature + ")"
+	}
+	summary += " in " + time.Duration(report.ElapsedNano).Round(time.Millisecond).String()
+	fuses := []string{}
+	for _, name := range []string{"lfuse", "hfuse", "efuse"} {
+		if value, ok := report.Fuses[name]; ok {
+			fuses = append(fuses, name+"="+value)
+		}
+	}
+	if len(fuses) > 0 {
+		summary += ", fuses " + strings.Join(fuses, " ")
+	}
+	fmt.Println(summary)
+}
+
+// flashAdvice tells what to do about the failures the server classified
+var flashAdvice = map[proto.FlashReport_Failure]string{
+	proto.FlashReport_NOT_IN_SYNC:        "the bootloader didn't answer: check the usb cable, press reset on the board right before flashing or burn the bootloader again",
+	proto.FlashReport_WRONG_SIGNATURE:    "the board isn't an atmega328p: check that you chose the right controller, the server flashes arduino unos only",
+	proto.FlashReport_PERMISSION_DENIED:  "the server may not open the port: add the user running it to the dialout group and restart it",
+	proto.FlashReport_TIMEOUT:            "avrdude didn't finish in time: the board may hang, unplug it or reset usb and try again",
+	proto.FlashReport_VERIFICATION_ERROR: "the flash read back differs from the image: check the power supply of the board and try again, the flash may be worn out",
+	proto.FlashReport_UNKNOWN:            "the failure isn't known, the output of avrdude above may tell more",
+}
+
+// resetUsbHelps is false for failures that are no usb problem
+func resetUsbHelps(failure proto.FlashReport_Failure) bool {
+	return failure != proto.FlashReport_PERMISSION_DENIED && failure != proto.FlashReport_WRONG_SIGNATURE
+}
+
+// flashReportOf returns the report the server attached to a failed flash, nil if there is none
+func flashReportOf(s *status.Status) *proto.FlashReport {
+	for _, detail := range s.Details() {
+		if report, ok := detail.(*proto.FlashReport); ok {
+			return report
+		}
+	}
+	return nil
+}
+
+func progressBar(percent int32) string {
+	const width = 40
+	done := int(percent) * width / 100
+	return "[" + strings.Repeat("#", done) + strings.Repeat(" ", width-done) + "]"
+}
+
+func printFlashHistory(client proto.NervoServiceClient, controllerPortName string) {
+	response, err := client.GetFlashHistory(context.Background(), &proto.FlashHistoryRequest{ControllerPortName: controllerPortName})
+	if err != nil {
+		exitWithError(err)
+	}
+	if len(response.Records) == 0 {
+		fmt.Println("no flashes recorded for", response.ControllerStableId)
+		return
+	}
+
+	fmt.Println("flashes of", response.ControllerStableId)
+	for _, record := range response.Records {
+		outcome := "ok"
+		if !record.Succeeded {
+			outcome = "failed: " + record.Error
+		}
+		fmt.Printf("%s %s by %s in %s, image %s: %s\n",
+			time.Unix(0, record.StartedAtUnixNano).Format(time.RFC3339),
+			record.Source,
+			record.Client,
+			time.Duration(record.DurationNano).Round(time.Millisecond),
+			record.ImageId[:12],
+			outcome,
+		)
+	}
+}
+
+// rollbackController re-flashes the previous known-good image from the flash history
+func rollbackController(client proto.NervoServiceClient, controllerPortName string) {
+	stream, err := client.RollbackController(context.Background(), &proto.RollbackControllerRequest{ControllerPortName: controllerPortName})
+	if err != nil {
+		exitWithError(err)
+	}
+	done, err := printFlashProgress(stream)
+	if err != nil {
+		exitWithError(err)
+	}
+	printFlashDone(done)
+}
+
+// flashMultiple flashes every controller matching a selector with the same firmware file or artifact and prints a result per controller
+func flashMultiple(client proto.NervoServiceClient) {
+	prompt := promptui.Prompt{
+		Label: "Names and labels as key=value, comma separated (empty for all)",
+	}
+	input, err := prompt.Run()
+	if err != nil {
+		exitWithError(err)
+	}
+	names, labels := parseSelectorInput(input)
+	request := &proto.FlashControllersRequest{
+		Selector: &proto.ControllerSelector{Names: names, Labels: labels, All: len(names) == 0 && len(labels) == 0},
+	}
+
+	s := promptui.Select{
+		Label: "What do you want to flash?",
+		Items: []string{"a firmware file", "an artifact"},
+	}
+	i, _, err := s.Run()
+	if err != nil {
+		exitWithError(err)
+	}
+	if i == 0 {
+		source := flashSource
+		if source == "" {
+			source = "."
+		}
+		s := promptui.Select{
+			Label: "What firmware do you want to flash?",
+			Items: findFirmwareFileNames(source),
+		}
+		_, fileName, err := s.Run()
+		if err != nil {
+			exitWithError(err)
+		}
+		content, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			exitWithError(err)
+		}
+		if request.UploadId, err = uploadFirmware(client, content); err != nil {
+			exitWithError(err)
+		}
+		if isRawImage(fileName) {
+			request.Format = proto.FirmwareFormat_BIN
+			request.BaseAddress = askForBaseAddress()
+		}
+	} else {
+		request.Artifact = askForArtifact(client)
+	}
+
+	parallelism := promptui.Prompt{
+		Label:   "How many controllers should be flashed at once?",
+		Default: "1",
+		Validate: func(input string) error {
+			_, err := strconv.ParseInt(input, 10, 32)
+			return err
+		},
+	}
+	input, err = parallelism.Run()
+	if err != nil {
+		exitWithError(err)
+	}
+	n, _ := strconv.ParseInt(input, 10, 32)
+	request.Parallelism = int32(n)
+	onFailure := promptui.Select{
+		Label: "What should happen after a flash failed?",
+		Items: []string{"stop", "continue"},
+	}
+	_, choice, err := onFailure.Run()
+	if err != nil {
+		exitWithError(err)
+	}
+	request.ContinueOnFailure = choice == "continue"
+
+	response, err := client.FlashControllers(context.Background(), request)
+	if err != nil {
+		exitWithError(err)
+	}
+	for _, result := range response.Results {
+		outcome := "ok"
+		switch {
+		case result.Skipped:
+			outcome = "skipped"
+		case codes.Code(result.Code) != codes.OK:
+			outcome = codes.Code(result.Code).String() + ": " + result.Error
+			if result.Report != nil && flashAdvice[result.Report.Failure] != "" {
+				outcome += "\n    " + flashAdvice[result.Report.Failure]
+			}
+		}
+		fmt.Printf("%-15s %-10s %8s  %s\n",
+			result.ControllerPortName,
+			result.ControllerName,
+			time.Duration(result.DurationNano).Round(time.Millisecond),
+			outcome,
+		)
+	}
+}
@@ -2,49 +2,131 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/codeuniversity/nervo/proto"
 
 	"github.com/manifoldco/promptui"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var flashSource string
 
+var echo = flag.Bool("echo", false, "in the terminal, print what is written to the controller as well")
+
+var (
+	verbs   = flag.String("verbs", "", "when reading continuously, only print lines starting with one of these comma separated verbs, e.g. feedback")
+	include = flag.String("include", "", "when reading continuously, only print lines matching this regular expression")
+	exclude = flag.String("exclude", "", "when reading continuously, don't print lines matching this regular expression")
+	sample  = flag.Uint("sample", 0, "when reading continuously, only print every nth line")
+)
+
+// outputFilter is applied by the server, so the filtered lines don't go over the network
+func outputFilter() *proto.OutputFilter {
+	filter := &proto.OutputFilter{SampleEveryNth: uint32(*sample)}
+	for _, verb := range strings.Split(*verbs, ",") {
+		if verb = strings.TrimSpace(verb); verb != "" {
+			filter.Verbs = append(filter.Verbs, verb)
+		}
+	}
+	if *include != "" {
+		filter.Include = []string{*include}
+	}
+	if *exclude != "" {
+		filter.Exclude = []string{*exclude}
+	}
+	return filter
+}
+
 func main() {
-	if len(os.Args) < 2 || os.Args[1] == "help" {
-		fmt.Println("Usage: nervo <server address with port> [path containing .hex files]")
+	conf, err := loadConfig()
+	if err != nil {
+		exitWithError(err)
+	}
+	conf.registerFlags()
+	flag.Usage = func() {
+		fmt.Println("Usage: nervo [flags] <server address with port> [path containing .hex files]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() >= 1 {
+		conf.Address = flag.Arg(0)
+	}
+	if conf.Address == "" || conf.Address == "help" {
+		flag.Usage()
 		os.Exit(1)
 	}
 
-	conn, err := grpc.Dial(os.Args[1], grpc.WithInsecure())
+	dialOptions, err := conf.dialOptions()
 	if err != nil {
-		panic(err)
+		exitWithError(err)
 	}
-	if len(os.Args) == 3 {
-		flashSource = os.Args[2]
+	conn, err := grpc.Dial(conf.Address, dialOptions...)
+	if err != nil {
+		exitWithError(err)
+	}
+	if flag.NArg() == 2 {
+		flashSource = flag.Arg(1)
 	}
 	cmd := chooseBetweenCommands()
 
 	c := proto.NewNervoServiceClient(conn)
 	if cmd == "reset" {
 		output, err := c.ResetUsb(context.Background(), &proto.ResetUsbRequest{})
-		fmt.Println(output)
 		if err != nil {
-			panic(err)
+			exitWithError(err)
 		}
+		fmt.Println(output.Output)
 
 		return
 	}
+	if cmd == "explain discovery" {
+		explainDiscovery(c)
+		return
+	}
+	if cmd == "server info" {
+		printServerInfo(c)
+		return
+	}
+	if cmd == "read from multiple" {
+		readMultiplexedOutput(c)
+		return
+	}
+	if cmd == "write to multiple" {
+		writeToMultiple(c)
+		return
+	}
+	switch cmd {
+	case "flash multiple":
+		flashMultiple(c)
+		return
+	case "upload artifact":
+		uploadArtifact(c)
+		return
+	case "list artifacts":
+		listArtifacts(c)
+		return
+	case "tag artifact":
+		tagArtifact(c)
+		return
+	case "delete artifact":
+		deleteArtifact(c)
+		return
+	}
 
 	response, err := c.ListControllers(context.Background(), &proto.ControllerListRequest{})
 	if err != nil {
-		panic(err)
+		exitWithError(err)
 	}
 
 	controller := askForControllerName(response)
@@ -64,9 +146,24 @@ func main() {
 	case "flash":
 		flashController(c, controller)
 		break
+	case "flash artifact":
+		flashArtifact(c, controller)
+		break
+	case "flash history":
+		printFlashHistory(c, controller)
+		break
+	case "rollback":
+		rollbackController(c, controller)
+		break
+	case "terminal":
+		runTerminal(c, controller, *echo)
+		break
 	case "set name":
 		setControllerName(c, controller)
 		break
+	case "set labels":
+		setControllerLabels(c, controller)
+		break
 	}
 
 }
@@ -77,7 +174,7 @@ func setControllerName(client proto.NervoServiceClient, controllerPortName strin
 	}
 	name, err := prompt.Run()
 	if err != nil {
-		panic(err)
+		exitWithError(err)
 	}
 
 	response, err := client.SetControllerName(context.Background(), &proto.ControllerInfo{
@@ -85,37 +182,203 @@ func setControllerName(client proto.NervoServiceClient, controllerPortName strin
 		Name:     name,
 	})
 	if err != nil {
-		panic(err)
+		exitWithError(err)
 	}
 	for _, info := range response.ControllerInfos {
 		fmt.Println(info.Name, info.PortName)
 	}
 }
 
+func setControllerLabels(client proto.NervoServiceClient, controllerPortName string) {
+	prompt := promptui.Prompt{
+		Label: "Labels as key=value, comma separated (empty removes all)",
+	}
+	input, err := prompt.Run()
+	if err != nil {
+		exitWithError(err)
+	}
+
+	_, labels := parseSelectorInput(input)
+	response, err := client.SetControllerLabels(context.Background(), &proto.SetControllerLabelsRequest{
+		ControllerPortName: controllerPortName,
+		Labels:             labels,
+	})
+	if err != nil {
+		exitWithError(err)
+	}
+	for _, info := range response.ControllerInfos {
+		fmt.Println(info.Name, info.PortName, info.Labels)
+	}
+}
+
+func readMultiplexedOutput(client proto.NervoServiceClient) {
+	prompt := promptui.Prompt{
+		Label: "Names and labels as key=value, comma separated (empty for all)",
+	}
+	input, err := prompt.Run()
+	if err != nil {
+		exitWithError(err)
+	}
+
+	names, labels := parseSelectorInput(input)
+	selector := &proto.ControllerSelector{Names: names, Labels: labels, All: len(names) == 0 && len(labels) == 0}
+	stream, err := client.ReadMultiplexedOutput(context.Background(), &proto.ReadMultiplexedOutputRequest{
+		Selector: selector,
+		Filter:   outputFilter(),
+	})
+	if err != nil {
+		exitWithError(err)
+	}
+	for {
+		line, err := stream.Recv()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			exitWithError(err)
+		}
+
+		name := line.ControllerName
+		if name == "" {
+			name = line.ControllerPortName
+		}
+		fmt.Printf("%-10s| %s", name, line.Line)
+	}
+}
+
+// parseSelectorInput splits "leg1, side=left" into names and labels
+func parseSelectorInput(input string) (names []string, labels map[string]string) {
+	labels = map[string]string{}
+	for _, part := range strings.Split(input, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if i := strings.Index(part, "="); i >= 0 {
+			labels[strings.TrimSpace(part[:i])] = strings.TrimSpace(part[i+1:])
+			continue
+		}
+		names = append(names, part)
+	}
+	return names, labels
+}
+
+// writeToMultiple writes lines like "leg1 step 1" to the controller named first, until the prompt is interrupted
+func writeToMultiple(client proto.NervoServiceClient) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := client.WriteToControllers(ctx)
+	if err != nil {
+		exitWithError(err)
+	}
+	acksDone := make(chan struct{})
+	go func() {
+		defer close(acksDone)
+		for {
+			ack, err := stream.Recv()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				exitWithError(err)
+			}
+			if codes.Code(ack.Code) != codes.OK {
+				fmt.Fprintf(os.Stderr, "message %d: %s: %s\n", ack.Id, codes.Code(ack.Code), ack.Error)
+			}
+		}
+	}()
+
+	var id uint64
+	for {
+		prompt := promptui.Prompt{
+			Label: "Controller (port, by-id link or name) and message",
+		}
+		input, err := prompt.Run()
+		if err == promptui.ErrInterrupt || err == promptui.ErrEOF {
+			// wait for the acknowledgements of the messages sent so far
+			stream.CloseSend()
+			<-acksDone
+			return
+		}
+		if err != nil {
+			exitWithError(err)
+		}
+
+		id++
+		request := writeRequestForInput(input)
+		request.Id = id
+		if err := stream.Send(request); err != nil {
+			exitWithError(err)
+		}
+	}
+}
+
+// writeRequestForInput splits "leg1 step 1" into the target and the message, a newline is appended to the message
+func writeRequestForInput(input string) *proto.WriteToControllersRequest {
+	parts := strings.SplitN(strings.TrimSpace(input), " ", 2)
+	request := &proto.WriteToControllersRequest{}
+	if len(parts) == 2 {
+		request.Message = []byte(parts[1] + "\n")
+	}
+
+	target := parts[0]
+	switch {
+	case strings.HasPrefix(target, "/dev/serial/by-id/"):
+		request.ControllerStableId = target
+	case strings.HasPrefix(target, "/") || strings.Contains(target, "://"):
+		request.ControllerPortName = target
+	default:
+		request.ControllerName = target
+	}
+	return request
+}
+
 func readFromController(client proto.NervoServiceClient, controllerName string) {
 	output, err := client.ReadControllerOutput(context.Background(), &proto.ReadControllerOutputRequest{
 		ControllerPortName: controllerName,
 	})
 	if err != nil {
-		panic(err)
+		exitWithError(err)
 	}
 	fmt.Println(output.Output)
 }
 
+// readContiniouslyFromController prints the output of the controller until the stream ends.
+// If the connection drops, it reconnects and resumes after the last line it printed
 func readContiniouslyFromController(client proto.NervoServiceClient, controllerName string) {
-	stream, err := client.ReadControllerOutputContinuously(context.Background(), &proto.ReadControllerOutputRequest{
-		ControllerPortName: controllerName,
-	})
-	if err != nil {
-		panic(err)
-	}
+	var nextSequence uint64
 	for {
-		response, err := stream.Recv()
+		stream, err := client.ReadControllerOutputContinuously(context.Background(), &proto.ReadControllerOutputRequest{
+			ControllerPortName: controllerName,
+			Resume:             true,
+			FromSequence:       nextSequence,
+			Filter:             outputFilter(),
+		})
 		if err != nil {
-			panic(err)
+			exitWithError(err)
 		}
+		for {
+			var response *proto.ReadControllerOutputResponse
+			response, err = stream.Recv()
+			if err != nil {
+				break
+			}
 
-		fmt.Printf(response.Output)
+			if response.Gap {
+				fmt.Printf("[... %d lines missed ...]\n", response.Missed)
+				continue
+			}
+			fmt.Printf(response.Output)
+			nextSequence = response.Sequence + 1
+		}
+		if err == io.EOF {
+			return
+		}
+		if status.Code(err) != codes.Unavailable {
+			exitWithError(err)
+		}
+		fmt.Fprintln(os.Stderr, "connection lost, resuming in a second:", status.Convert(err).Message())
+		time.Sleep(time.Second)
 	}
 }
 
@@ -125,7 +388,7 @@ func writeToController(client proto.NervoServiceClient, controllerPortName strin
 	}
 	message, err := prompt.Run()
 	if err != nil {
-		panic(err)
+		exitWithError(err)
 	}
 
 	_, err = client.WriteToController(context.Background(), &proto.WriteToControllerRequest{
@@ -133,7 +396,7 @@ func writeToController(client proto.NervoServiceClient, controllerPortName strin
 		Message:            []byte(message),
 	})
 	if err != nil {
-		panic(err)
+		exitWithError(err)
 	}
 }
 
@@ -141,7 +404,7 @@ func writeToControllerContinuously(client proto.NervoServiceClient, controllerPo
 
 	stream, err := client.WriteToControllerContinuously(context.Background())
 	if err != nil {
-		panic(err)
+		exitWithError(err)
 	}
 	for {
 		prompt := promptui.Prompt{
@@ -150,7 +413,7 @@ func writeToControllerContinuously(client proto.NervoServiceClient, controllerPo
 
 		message, err := prompt.Run()
 		if err != nil {
-			panic(err)
+			exitWithError(err)
 		}
 
 		err = stream.Send(&proto.WriteToControllerRequest{
@@ -158,7 +421,7 @@ func writeToControllerContinuously(client proto.NervoServiceClient, controllerPo
 			Message:            []byte(message),
 		})
 		if err != nil {
-			panic(err)
+			exitWithError(err)
 		}
 	}
 }
@@ -170,47 +433,121 @@ func flashController(client proto.NervoServiceClient, controllerName string) {
 	} else {
 		source = "."
 	}
-	hexFileNames := findHexFileNames(source)
+	firmwareFileNames := findFirmwareFileNames(source)
 	s := promptui.Select{
-		Label: "What Hex file do you want to flash?",
-		Items: hexFileNames,
+		Label: "What firmware do you want to flash?",
+		Items: firmwareFileNames,
 	}
-	_, hexFileName, err := s.Run()
+	_, firmwareFileName, err := s.Run()
 	if err != nil {
-		panic(err)
+		exitWithError(err)
 	}
-	content, err := ioutil.ReadFile(hexFileName)
+	content, err := ioutil.ReadFile(firmwareFileName)
 	if err != nil {
-		panic(string(content) + ":" + err.Error())
+		exitWithError(err)
 	}
 
-	response, err := client.FlashController(context.Background(), &proto.FlashControllerRequest{ControllerPortName: controllerName, HexFileContent: content})
-	fmt.Println(response.Output)
+	uploadID, err := uploadFirmware(client, content)
 	if err != nil {
-		fmt.Println("Encountered error when flashing: ", err)
-		fmt.Println("... resetting usb devices...")
-		output, err := client.ResetUsb(context.Background(), &proto.ResetUsbRequest{})
-		fmt.Println(output)
-		if err != nil {
-			fmt.Println("couldn't reset usb: ", err)
-		} else {
-			fmt.Println("usb successfully reset, retrying")
-			response, err := client.FlashController(context.Background(), &proto.FlashControllerRequest{ControllerPortName: controllerName, HexFileContent: content})
-			fmt.Println(response.Output)
-			if err != nil {
-				panic(err)
-			}
+		exitWithError(err)
+	}
+	flashWithUsbReset(client, flashFirmwareRequest(controllerName, uploadID, firmwareFileName))
+}
+
+// flashWithUsbReset resets the usb devices and retries once, if the controller didn't respond
+func flashWithUsbReset(client proto.NervoServiceClient, request *proto.FlashFirmwareRequest) {
+	done, err := flashUpload(client, request)
+	if err == nil {
+		printFlashDone(done)
+		return
+	}
+
+	switch status.Code(err) {
+	case codes.Aborted, codes.Unavailable:
+	default:
+		exitWithError(err)
+	}
+
+	flashStatus := status.Convert(err)
+	fmt.Println("Encountered error when flashing:", flashStatus.Message())
+	printStatusDetails(flashStatus)
+	if report := flashReportOf(flashStatus); report != nil && !resetUsbHelps(report.Failure) {
+		os.Exit(1)
+	}
+	fmt.Println("... resetting usb devices...")
+	output, err := client.ResetUsb(context.Background(), &proto.ResetUsbRequest{})
+	if err != nil {
+		fmt.Println("couldn't reset usb")
+		exitWithError(err)
+	}
+	fmt.Println(output.Output)
+
+	fmt.Println("usb successfully reset, retrying")
+	done, err = flashUpload(client, request)
+	if err != nil {
+		exitWithError(err)
+	}
+	printFlashDone(done)
+}
+
+func explainDiscovery(client proto.NervoServiceClient) {
+	response, err := client.ExplainDiscovery(context.Background(), &proto.ExplainDiscoveryRequest{})
+	if err != nil {
+		exitWithError(err)
+	}
+
+	for _, candidate := range response.Candidates {
+		verdict := "rejected"
+		if candidate.Accepted {
+			verdict = "accepted"
+		}
+		usbID := ""
+		if candidate.VendorId != "" {
+			usbID = candidate.VendorId + ":" + candidate.ProductId
+		}
+		fmt.Printf("%s %s %s %s\n", verdict, candidate.PortName, usbID, candidate.Reason)
+		if candidate.ByIdPath != "" && candidate.ByIdPath != candidate.PortName {
+			fmt.Println("    by-id:", candidate.ByIdPath)
 		}
 	}
 }
 
+// version of the cli, set at build time with -ldflags "-X main.version=<version>"
+var version = "dev"
+
+func printServerInfo(client proto.NervoServiceClient) {
+	info, err := client.GetServerInfo(context.Background(), &proto.GetServerInfoRequest{})
+	if err != nil {
+		exitWithError(err)
+	}
+
+	revision := info.VcsRevision
+	if info.VcsModified {
+		revision += " (modified)"
+	}
+	fmt.Println("version:  ", info.Version, info.GoVersion, revision)
+	fmt.Println("cli:      ", version)
+	fmt.Println("host:     ", info.Hostname, info.Os+"/"+info.Arch)
+	fmt.Println("uptime:   ", time.Duration(info.UptimeSeconds)*time.Second)
+	fmt.Println("discovery:", info.DiscoveryBackend)
+	fmt.Println("mhist:    ", map[bool]string{true: "connected", false: "not connected"}[info.MhistConnected])
+	fmt.Println("features: ", strings.Join(info.Features, ", "))
+	for _, tool := range info.FlasherTools {
+		if tool.Path == "" {
+			fmt.Println("flasher:  ", tool.Name, "not found")
+			continue
+		}
+		fmt.Println("flasher:  ", tool.Name, tool.Version, tool.Path)
+	}
+}
+
 func askForControllerName(response *proto.ControllerListResponse) string {
 	items := response.ControllerInfos
 
 	templates := &promptui.SelectTemplates{
 		Label:    "{{ . }}?",
-		Active:   "> {{ .Name | cyan }} {{ .PortName | red }}",
-		Inactive: "  {{ .Name | cyan }} {{ .PortName | red }}",
+		Active:   "> {{ .Name | cyan }} {{ .PortName | red }} {{ .State | faint }}",
+		Inactive: "  {{ .Name | cyan }} {{ .PortName | red }} {{ .State | faint }}",
 		Selected: "✔ {{ .Name | cyan }} {{ .PortName | red }}",
 	}
 
@@ -231,7 +568,7 @@ func askForControllerName(response *proto.ControllerListResponse) string {
 	}
 	i, _, err := s.Run()
 	if err != nil {
-		panic(err)
+		exitWithError(err)
 	}
 	return items[i].PortName
 }
@@ -239,12 +576,26 @@ func askForControllerName(response *proto.ControllerListResponse) string {
 func chooseBetweenCommands() string {
 	commands := []string{
 		"flash",
+		"flash artifact",
+		"flash history",
+		"rollback",
 		"read once",
 		"read continuously",
 		"write message",
 		"write messages continuously",
+		"terminal",
 		"set name",
+		"set labels",
+		"read from multiple",
+		"write to multiple",
+		"flash multiple",
+		"upload artifact",
+		"list artifacts",
+		"tag artifact",
+		"delete artifact",
 		"reset",
+		"explain discovery",
+		"server info",
 	}
 	s := promptui.Select{
 		Label: "What do you want to do?",
@@ -252,30 +603,33 @@ func chooseBetweenCommands() string {
 	}
 	_, choice, err := s.Run()
 	if err != nil {
-		panic(err)
+		exitWithError(err)
 	}
 	return choice
 }
 
-func findHexFileNames(sourcePath string) []string {
-	hexFiles := []string{}
+// firmwareExtensions are the files the server can flash, elf and bin files are converted to hex files there
+var firmwareExtensions = []string{".hex", ".elf", ".bin"}
+
+func findFirmwareFileNames(sourcePath string) []string {
+	firmwareFiles := []string{}
 
 	files, err := ioutil.ReadDir(sourcePath)
 	if err != nil {
-		panic(err)
+		exitWithError(err)
 	}
 	for _, file := range files {
 		if file.IsDir() {
-			subFiles := findHexFileNames(path.Join(sourcePath, file.Name()))
-			for _, subFile := range subFiles {
-				hexFiles = append(hexFiles, subFile)
-			}
+			firmwareFiles = append(firmwareFiles, findFirmwareFileNames(path.Join(sourcePath, file.Name()))...)
 			continue
 		}
-		if strings.Contains(file.Name(), ".hex") {
-			hexFiles = append(hexFiles, path.Join(sourcePath, file.Name()))
+		for _, extension := range firmwareExtensions {
+			if strings.HasSuffix(strings.ToLower(file.Name()), extension) {
+				firmwareFiles = append(firmwareFiles, path.Join(sourcePath, file.Name()))
+				break
+			}
 		}
 	}
 
-	return hexFiles
+	return firmwareFiles
 }
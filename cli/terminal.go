@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/codeuniversity/nervo/proto"
+)
+
+// runTerminal connects stdin and stdout to the controller until stdin ends (ctrl+d) or the controller is detached
+func runTerminal(client proto.NervoServiceClient, controllerPortName string, echo bool) {
+	stream, err := client.Session(context.Background())
+	if err != nil {
+		exitWithError(err)
+	}
+	if err := stream.Send(&proto.SessionRequest{ControllerPortName: controllerPortName, Echo: echo}); err != nil {
+		exitWithError(err)
+	}
+
+	go func() {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			if err := stream.Send(&proto.SessionRequest{Message: []byte(scanner.Text() + "\n")}); err != nil {
+				return
+			}
+		}
+		stream.CloseSend()
+	}()
+
+	for {
+		event, err := stream.Recv()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			exitWithError(err)
+		}
+
+		switch event.Type {
+		case proto.SessionEvent_OPENED:
+			fmt.Fprintln(os.Stderr, "connected to", event.ControllerPortName+", ctrl+d to quit")
+		case proto.SessionEvent_OUTPUT:
+			fmt.Print(string(event.Data))
+		case proto.SessionEvent_ECHO:
+			fmt.Printf("%s > %s", time.Unix(0, event.TimeUnixNano).Format("15:04:05.000"), event.Data)
+		case proto.SessionEvent_ERROR:
+			fmt.Fprintln(os.Stderr, "writing failed:", event.Reason)
+		case proto.SessionEvent_CLOSED:
+			fmt.Fprintln(os.Stderr, "session closed:", event.Reason)
+		}
+	}
+}
@@ -3,15 +3,9 @@ package nervo
 import (
 	"bufio"
 	"bytes"
-	"fmt"
-	"io/ioutil"
 	"log"
-	"os"
-	"os/exec"
 	"sync"
 	"time"
-
-	"github.com/tarm/serial"
 )
 
 const (
@@ -19,107 +13,251 @@ const (
 	retainedBufferLength = 2 << 10
 )
 
+// States of a controller as they are listed
+const (
+	controllerStateConnecting   = "connecting"
+	controllerStateConnected    = "connected"
+	controllerStateDisconnected = "disconnected"
+	controllerStateFlashing     = "flashing"
+	// controllerStateFinished is a replay that played all of its events
+	controllerStateFinished = "finished"
+)
+
 type closeContiniousWriterMessage struct {
 	doneChan chan struct{}
 }
 
 type controller struct {
-	SerialPortPath            string
+	SerialPortPath string
+	// StableID identifies the controller across replugging if possible, see stableIDForPort
+	StableID                  string
 	Name                      string
-	serialPort                *serial.Port
+	openTransport             TransportOpener
+	transport                 Transport
 	outputbuffer              *bytes.Buffer
 	outputMutex               *sync.Mutex
-	readNotifierChan          chan []byte
+	readNotifierChan          chan outputLine
 	readNotifierMutex         *sync.Mutex
+	history                   *outputHistory
+	observers                 *outputObservers
+	allObservers              *outputObservers
 	closeContiniousWriterChan chan closeContiniousWriterMessage
 	handleVerbMessage         func(verb, message string)
-	Error                     error
+	recordDirectory           string
+	recorder                  *sessionRecorder
+	// readGeneration is increased whenever reading starts or stops, so a reader that was stopped doesn't touch the controller anymore.
+	// It, Name, transport, recorder, flashing and Error are guarded by outputMutex
+	readGeneration uint64
+	flashing       bool
+	Error          error
 }
 
-func newController(serialPort string) *controller {
+// newController creates a controller for serialPort. allObservers get the lines of every controller
+func newController(serialPort, byIDDirectory string, openTransport TransportOpener, allObservers *outputObservers) *controller {
 	return &controller{
 		SerialPortPath:    serialPort,
+		StableID:          stableIDForPort(serialPort, byIDDirectory),
+		openTransport:     openTransport,
 		outputbuffer:      &bytes.Buffer{},
 		outputMutex:       &sync.Mutex{},
 		readNotifierMutex: &sync.Mutex{},
+		history:           newOutputHistory(outputHistoryLength),
+		observers:         newOutputObservers(100),
+		allObservers:      allObservers,
 	}
 }
 
-func (c *controller) flash(hexFileContent []byte) (output string, err error) {
-	c.closeSerial()
+// startFlashing stops reading, so the flasher can open the port. Called by the manager loop
+func (c *controller) startFlashing() {
+	c.outputMutex.Lock()
+	c.flashing = true
+	c.stopReadingLocked()
+	c.outputMutex.Unlock()
 	c.clearNotifier()
+}
+
+// runFlash runs outside of the manager loop, so controllers can be flashed in parallel. It only uses the port of the controller
+func (c *controller) runFlash(flasher Flasher, hexFileContent []byte, progress func(FlashProgress)) (output string, timedOut bool, err error) {
 	time.Sleep(time.Millisecond * 200)
-	c.Error = nil
-	err = withTimeOut(time.Second*10, func() {
-		hexFilePath, hexfileCleanup := writeHexFileToTemporaryPath(hexFileContent)
-		defer hexfileCleanup()
-
-		cmd := exec.Command(
-			"sh",
-			"-c",
-			fmt.Sprintf("avrdude -p m328p -c arduino -P %s -b 115200 -U flash:w:%s", c.SerialPortPath, hexFilePath),
-		)
-
-		out, execErr := cmd.CombinedOutput()
-		output = string(out)
-		err = execErr
+	timeoutErr := withTimeOut(flashTimeout(len(hexFileContent)), func() {
+		if progressFlasher, ok := flasher.(ProgressFlasher); ok && progress != nil {
+			output, err = progressFlasher.FlashWithProgress(c.SerialPortPath, hexFileContent, progress)
+			return
+		}
+		output, err = flasher.Flash(c.SerialPortPath, hexFileContent)
 	})
+	if timeoutErr != nil {
+		return "", true, timeoutErr
+	}
 	if err != nil {
-		return "", err
+		if _, unsupported := err.(*UnsupportedError); !unsupported {
+			err = &FlashError{PortName: c.SerialPortPath, Output: output, Cause: err}
+		}
 	}
-	go c.readFromSerial()
 	return
 }
 
-func (c *controller) readFromSerial() error {
+// finishFlashing reads again if restartReading. Otherwise err is kept as the reason the controller isn't read,
+// so the discovery reconnects it, e.g. after the flasher timed out. Called by the manager loop
+func (c *controller) finishFlashing(restartReading bool, err error) {
+	c.outputMutex.Lock()
+	c.flashing = false
+	c.Error = err
+	c.outputMutex.Unlock()
+	if restartReading {
+		c.startReading()
+	}
+}
+
+func (c *controller) state() string {
+	c.outputMutex.Lock()
+	defer c.outputMutex.Unlock()
+
+	switch {
+	case c.flashing:
+		return controllerStateFlashing
+	case c.transport != nil:
+		return controllerStateConnected
+	case c.Error == ErrReplayFinished:
+		return controllerStateFinished
+	case c.Error != nil:
+		return controllerStateDisconnected
+	default:
+		return controllerStateConnecting
+	}
+}
+
+// name is what the controller announced or was named
+func (c *controller) name() string {
+	c.outputMutex.Lock()
+	defer c.outputMutex.Unlock()
+	return c.Name
+}
+
+func (c *controller) setName(name string) {
+	c.outputMutex.Lock()
+	defer c.outputMutex.Unlock()
+	c.Name = name
+}
+
+// disconnectedBy returns why reading stopped. It is nil while the controller is read, connecting or flashed and after a replay finished
+func (c *controller) disconnectedBy() error {
+	c.outputMutex.Lock()
+	defer c.outputMutex.Unlock()
+
+	if c.transport != nil || c.flashing || c.Error == ErrReplayFinished {
+		return nil
+	}
+	return c.Error
+}
+
+// startReading opens the transport and reads from it in a new goroutine.
+// Readers started before belong to an earlier generation and leave the controller alone from now on
+func (c *controller) startReading() {
+	c.outputMutex.Lock()
+	c.readGeneration++
+	generation := c.readGeneration
+	c.Error = nil
+	c.outputMutex.Unlock()
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Println(r)
+			}
+		}()
+		c.readFromTransport(generation)
+	}()
+}
+
+func (c *controller) readFromTransport(generation uint64) {
 	handleReadErr := func(err error) {
-		c.Error = err
+		if !c.readFailed(generation, err) {
+			return
+		}
 		c.clearNotifier()
-		c.closeSerial()
 		log.Println(c.SerialPortPath, err)
 	}
 
-	conf := &serial.Config{Name: c.SerialPortPath, Baud: 9600}
-	s, err := serial.OpenPort(conf)
+	t, err := c.openTransport(c.SerialPortPath)
 	if err != nil {
-		c.Error = err
-		return err
+		c.readFailed(generation, err)
+		return
 	}
-	c.serialPort = s
-	r := bufio.NewReader(c.serialPort)
+	if !c.useTransport(generation, t) {
+		return
+	}
+	r := bufio.NewReader(t)
 
 	firstLine, err := r.ReadString('\n')
 	if err != nil {
 		handleReadErr(err)
-		return err
+		return
 	}
+	c.record(SessionEventRead, []byte(firstLine))
 	if name, ok := ParseAnnounceMessage(firstLine); ok {
-		c.Name = name
+		c.setName(name)
 	} else {
-		c.notifyOrAppendToCappedOutputBuffer([]byte(firstLine))
+		c.handleLine([]byte(firstLine))
 	}
 
 	for {
-		var l string
-		l, err = r.ReadString('\n')
+		l, err := r.ReadString('\n')
 		if err != nil {
 			handleReadErr(err)
-			break
+			return
 		}
-		c.notifyOrAppendToCappedOutputBuffer([]byte(l))
+		c.record(SessionEventRead, []byte(l))
+		c.handleLine([]byte(l))
 	}
-	return err
 }
 
-func (c *controller) notifyOrAppendToCappedOutputBuffer(b []byte) {
+// useTransport keeps the freshly opened transport t, unless reading stopped while it was opened
+func (c *controller) useTransport(generation uint64, t Transport) bool {
+	c.outputMutex.Lock()
+	defer c.outputMutex.Unlock()
+
+	if generation != c.readGeneration {
+		t.Close()
+		return false
+	}
+	c.transport = t
+	c.recorder = c.newRecorder()
+	return true
+}
+
+// readFailed keeps err and closes the transport. It is false if reading stopped already, the error is just the transport being closed then
+func (c *controller) readFailed(generation uint64, err error) bool {
+	c.outputMutex.Lock()
+	defer c.outputMutex.Unlock()
+
+	if generation != c.readGeneration {
+		return false
+	}
+	c.Error = err
+	c.stopReadingLocked()
+	return true
+}
+
+// handleLine numbers a line read from the controller and passes it on
+func (c *controller) handleLine(b []byte) {
+	line := c.history.append(c.SerialPortPath, c.name(), b)
+	c.observers.notify(line)
+	c.allObservers.notify(line)
+	c.notifyOrAppendToCappedOutputBuffer(line)
+}
+
+func (c *controller) notifyOrAppendToCappedOutputBuffer(line outputLine) {
 	c.readNotifierMutex.Lock()
 	defer c.readNotifierMutex.Unlock()
 
 	if c.readNotifierChan != nil {
-		c.readNotifierChan <- b
+		c.readNotifierChan <- line
 		return
 	}
 
+	b := line.data
+
 	if c.handleVerbMessage != nil {
 		message, ok := ParseFeedbackMessage(string(b))
 		if ok {
@@ -152,12 +290,12 @@ func (c *controller) useOutput(f func(outputBuffer *bytes.Buffer)) {
 	f(c.outputbuffer)
 }
 
-func (c *controller) notifyOnRead() chan []byte {
+func (c *controller) notifyOnRead() chan outputLine {
 	c.clearNotifier()
 
 	c.readNotifierMutex.Lock()
 	defer c.readNotifierMutex.Unlock()
-	notifierChan := make(chan []byte, 10)
+	notifierChan := make(chan outputLine, 10)
 	c.readNotifierChan = notifierChan
 
 	return notifierChan
@@ -172,21 +310,79 @@ func (c *controller) clearNotifier() {
 	}
 }
 
-func (c *controller) closeSerial() {
+// observe returns a channel receiving every line read from now on. Unlike notifyOnRead it doesn't take the output away from others
+func (c *controller) observe() chan outputLine {
+	return c.observers.add()
+}
+
+func (c *controller) stopObserving(observer chan outputLine) {
+	c.observers.remove(observer)
+}
+
+// closeObservers ends all observations, e.g. because the controller was detached
+func (c *controller) closeObservers() {
+	c.observers.removeAll()
+}
+
+// closeTransport stops reading, e.g. because the controller was detached
+func (c *controller) closeTransport() {
 	c.outputMutex.Lock()
 	defer c.outputMutex.Unlock()
-	if c.serialPort != nil {
-		c.serialPort.Close()
-		c.serialPort = nil
+	c.stopReadingLocked()
+}
+
+// stopReadingLocked ends the current reader generation and closes its transport. outputMutex must be held
+func (c *controller) stopReadingLocked() {
+	c.readGeneration++
+	if c.transport != nil {
+		c.transport.Close()
+		c.transport = nil
+	}
+	if c.recorder != nil {
+		c.recorder.close()
+		c.recorder = nil
+	}
+}
+
+// newRecorder starts a new session file for the freshly opened transport, it is nil if recording is disabled or failed
+func (c *controller) newRecorder() *sessionRecorder {
+	if c.recordDirectory == "" {
+		return nil
+	}
+
+	recorder, err := newSessionRecorder(c.recordDirectory, c.SerialPortPath)
+	if err != nil {
+		log.Println("can't record session of", c.SerialPortPath, err)
+		return nil
+	}
+	return recorder
+}
+
+func (c *controller) record(direction string, data []byte) {
+	c.outputMutex.Lock()
+	recorder := c.recorder
+	c.outputMutex.Unlock()
+
+	if recorder != nil {
+		recorder.record(direction, data)
 	}
 }
 
 func (c *controller) write(message []byte) error {
-	if message == nil || c.serialPort == nil {
+	if message == nil {
 		return nil
 	}
+	c.outputMutex.Lock()
+	transport, readErr := c.transport, c.Error
+	c.outputMutex.Unlock()
+	if transport == nil {
+		return &ControllerUnavailableError{PortName: c.SerialPortPath, Cause: readErr}
+	}
 
-	_, err := c.serialPort.Write(message)
+	_, err := transport.Write(message)
+	if err == nil {
+		c.record(SessionEventWrite, message)
+	}
 	return err
 }
 
@@ -201,33 +397,15 @@ func (c *controller) continiouslyWrite(writeChan chan []byte) (stopChan chan clo
 	stopChan = make(chan closeContiniousWriterMessage)
 	c.closeContiniousWriterChan = stopChan
 	go func() {
+		var err error
 		for message := range writeChan {
-			err := c.write(message)
+			err = c.write(message)
 			if err != nil {
-				doneChan <- err
-				return
+				break
 			}
 		}
 		c.closeContiniousWriterChan = nil
-		doneChan <- nil
+		doneChan <- err
 	}()
 	return stopChan, doneChan
 }
-
-func writeHexFileToTemporaryPath(hexFileContent []byte) (path string, cleanup func()) {
-	tmpfile, err := ioutil.TempFile("", "flashing_*.hex")
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	if _, err := tmpfile.Write(hexFileContent); err != nil {
-		log.Fatal(err)
-	}
-	if err := tmpfile.Close(); err != nil {
-		log.Fatal(err)
-	}
-	cleanupFunc := func() {
-		os.Remove(tmpfile.Name())
-	}
-	return tmpfile.Name(), cleanupFunc
-}
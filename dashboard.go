@@ -0,0 +1,231 @@
+package nervo
+
+// dashboardHTML is the single page web ui served at / by the HTTPServer. It only uses the json api, like any other client
+const dashboardHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>nervo</title>
+<style>
+  body { font-family: sans-serif; margin: 0; display: flex; height: 100vh; color: #222; }
+  aside { width: 22em; border-right: 1px solid #ddd; padding: 1em; overflow-y: auto; }
+  main { flex: 1; display: flex; flex-direction: column; padding: 1em; min-width: 0; }
+  h1 { font-size: 1.3em; margin-top: 0; }
+  ul { list-style: none; padding: 0; }
+  li { padding: .5em; cursor: pointer; border-radius: 4px; }
+  li:hover, li.selected { background: #eef; }
+  .port { color: #a33; font-size: .85em; }
+  .state { float: right; font-size: .8em; }
+  .state.connected { color: #393; }
+  .state.disconnected { color: #a33; }
+  .state.finished { color: #888; }
+  .state.flashing, .state.connecting { color: #c80; }
+  #output { flex: 1; overflow-y: auto; background: #111; color: #ddd; font-family: monospace; padding: .5em; white-space: pre-wrap; }
+  #output .time { color: #777; }
+  form { display: flex; gap: .5em; margin: .5em 0; }
+  form input[type=text], form input[type=password] { flex: 1; }
+  #status { white-space: pre-wrap; }
+  [hidden] { display: none !important; }
+</style>
+</head>
+<body>
+<aside>
+  <h1>nervo</h1>
+  <form id="token-form">
+    <input type="password" id="token" placeholder="api token, if required">
+    <button>Save</button>
+  </form>
+  <ul id="controllers"></ul>
+  <button id="reset-usb">Reset usb</button>
+</aside>
+<main>
+  <p id="hint">Choose a controller to tail its output.</p>
+  <div id="controller" hidden>
+    <form id="rename-form">
+      <input type="text" id="name" placeholder="name">
+      <button>Rename</button>
+    </form>
+    <form id="flash-form">
+      <input type="file" id="hex-file" accept=".hex,.elf,.bin">
+      <button>Flash</button>
+    </form>
+    <form id="write-form">
+      <input type="text" id="message" placeholder="message, a newline is appended">
+      <button>Send</button>
+    </form>
+  </div>
+  <pre id="status"></pre>
+  <div id="output"></div>
+</main>
+<script>
+"use strict";
+
+let selectedPort = null;
+let socket = null;
+
+const byId = (id) => document.getElementById(id);
+byId("token").value = localStorage.getItem("nervo-token") || "";
+
+function token() {
+  return localStorage.getItem("nervo-token") || "";
+}
+
+function showStatus(message) {
+  byId("status").textContent = message || "";
+}
+
+async function api(method, path, body, contentType) {
+  const headers = {};
+  if (token()) {
+    headers["Authorization"] = "Bearer " + token();
+  }
+  if (contentType) {
+    headers["Content-Type"] = contentType;
+  }
+  const response = await fetch(path, { method, headers, body });
+  const json = await response.json();
+  if (!response.ok) {
+    const error = json.error || {};
+    throw new Error(error.code + ": " + error.message + (error.output ? "\n" + error.output : ""));
+  }
+  return json;
+}
+
+function query(params) {
+  if (token()) {
+    params.access_token = token();
+  }
+  return new URLSearchParams(params).toString();
+}
+
+async function refreshControllers() {
+  let list;
+  try {
+    list = await api("GET", "/api/controllers");
+  } catch (e) {
+    showStatus(e.message);
+    return;
+  }
+
+  const items = list.controllers.map((controller) => {
+    const li = document.createElement("li");
+    li.className = controller.port_name === selectedPort ? "selected" : "";
+    li.innerHTML = '<span class="state"></span><div class="name"></div><div class="port"></div>';
+    li.querySelector(".state").textContent = controller.state;
+    li.querySelector(".state").classList.add(controller.state);
+    li.querySelector(".name").textContent = controller.name || "(unnamed)";
+    li.querySelector(".port").textContent = controller.port_name;
+    li.onclick = () => select(controller);
+    return li;
+  });
+  byId("controllers").replaceChildren(...items);
+}
+
+function appendOutput(time, line) {
+  const output = byId("output");
+  const atBottom = output.scrollTop + output.clientHeight >= output.scrollHeight - 5;
+  const div = document.createElement("div");
+  const span = document.createElement("span");
+  span.className = "time";
+  span.textContent = new Date(time).toLocaleTimeString() + " ";
+  div.append(span, line.replace(/\n$/, ""));
+  output.append(div);
+  while (output.childElementCount > 2000) {
+    output.firstChild.remove();
+  }
+  if (atBottom) {
+    output.scrollTop = output.scrollHeight;
+  }
+}
+
+function select(controller) {
+  selectedPort = controller.port_name;
+  byId("hint").hidden = true;
+  byId("controller").hidden = false;
+  byId("name").value = controller.name;
+  byId("output").replaceChildren();
+  showStatus("");
+  refreshControllers();
+
+  if (socket) {
+    socket.onclose = null;
+    socket.close();
+  }
+  const protocol = location.protocol === "https:" ? "wss:" : "ws:";
+  socket = new WebSocket(protocol + "//" + location.host + "/api/controllers/stream?" + query({ port_name: selectedPort }));
+  socket.onmessage = (message) => {
+    const event = JSON.parse(message.data);
+    if (event.type === "line") {
+      appendOutput(event.time, event.line);
+    } else if (event.type === "error") {
+      showStatus(event.error.code + ": " + event.error.message);
+    }
+  };
+  socket.onclose = () => showStatus("the stream of " + controller.port_name + " ended");
+}
+
+byId("token-form").onsubmit = (e) => {
+  e.preventDefault();
+  localStorage.setItem("nervo-token", byId("token").value);
+  refreshControllers();
+};
+
+byId("write-form").onsubmit = (e) => {
+  e.preventDefault();
+  if (!socket || socket.readyState !== WebSocket.OPEN) {
+    showStatus("not connected to a controller");
+    return;
+  }
+  socket.send(JSON.stringify({ message: byId("message").value + "\n" }));
+  byId("message").value = "";
+};
+
+byId("rename-form").onsubmit = async (e) => {
+  e.preventDefault();
+  try {
+    await api("POST", "/api/controllers/name", JSON.stringify({ port_name: selectedPort, name: byId("name").value }), "application/json");
+    showStatus("");
+    refreshControllers();
+  } catch (err) {
+    showStatus(err.message);
+  }
+};
+
+byId("flash-form").onsubmit = async (e) => {
+  e.preventDefault();
+  const file = byId("hex-file").files[0];
+  if (!file) {
+    showStatus("choose a hex, elf or bin file first");
+    return;
+  }
+  const form = new FormData();
+  form.append("hex_file", file);
+  showStatus("flashing " + file.name + "...");
+  try {
+    const response = await api("POST", "/api/controllers/flash?" + new URLSearchParams({ port_name: selectedPort }), form);
+    showStatus(response.output);
+  } catch (err) {
+    showStatus(err.message);
+  }
+  refreshControllers();
+};
+
+byId("reset-usb").onclick = async () => {
+  if (!confirm("Reset all usb devices of the server?")) {
+    return;
+  }
+  try {
+    const response = await api("POST", "/api/usb/reset");
+    showStatus(response.output);
+  } catch (err) {
+    showStatus(err.message);
+  }
+};
+
+refreshControllers();
+setInterval(refreshControllers, 2000);
+</script>
+</body>
+</html>
+`
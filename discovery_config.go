@@ -0,0 +1,294 @@
+package nervo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	defaultByIDDirectory = "/dev/serial/by-id"
+	sysClassTtyDirectory = "/sys/class/tty"
+)
+
+// DiscoveryConfig decides which ports are considered to be controllers
+type DiscoveryConfig struct {
+	// SourceDirectories are scanned (not recursively) for candidate ports
+	SourceDirectories []string `json:"source_directories"`
+	// Globs are matched against the file names in the source directories, e.g. "ttyUSB*"
+	Globs []string `json:"globs"`
+	// Regexps are matched against the file names in the source directories as well
+	Regexps []string `json:"regexps"`
+	// ResolveByID reports ports by their stable /dev/serial/by-id link instead of the kernel name if possible
+	ResolveByID bool `json:"resolve_by_id"`
+	// ByIDDirectory overrides where the by-id links are looked up
+	ByIDDirectory string `json:"by_id_directory"`
+	// AllowedUsbIDs restricts discovery to devices with these "VID:PID" pairs. PID may be "*"
+	AllowedUsbIDs []string `json:"allowed_usb_ids"`
+	// DeniedUsbIDs excludes devices with these "VID:PID" pairs. PID may be "*"
+	DeniedUsbIDs []string `json:"denied_usb_ids"`
+	// StaticPorts are always reported as attached, e.g. "tcp://10.0.0.5:3333" or "rfc2217://esp-leg.local:2217"
+	StaticPorts []string `json:"static_ports"`
+
+	compiledRegexps []*regexp.Regexp
+}
+
+// DiscoveryCandidate describes a port that was looked at during discovery and why it was accepted or rejected
+type DiscoveryCandidate struct {
+	PortName   string
+	DevicePath string
+	ByIDPath   string
+	VendorID   string
+	ProductID  string
+	Accepted   bool
+	Reason     string
+}
+
+// DefaultDiscoveryConfig matches the usual arduino style usb serial ports
+func DefaultDiscoveryConfig() *DiscoveryConfig {
+	return &DiscoveryConfig{
+		SourceDirectories: []string{"/dev"},
+		Globs:             []string{"tty.usb*", "ttyACM*", "ttyUSB*"},
+		ByIDDirectory:     defaultByIDDirectory,
+	}
+}
+
+// LoadDiscoveryConfig reads a json encoded DiscoveryConfig from path. Fields missing in the file keep their defaults
+func LoadDiscoveryConfig(configPath string) (*DiscoveryConfig, error) {
+	content, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	config := DefaultDiscoveryConfig()
+	if err := json.Unmarshal(content, config); err != nil {
+		return nil, fmt.Errorf("parsing %s: %v", configPath, err)
+	}
+
+	return config, config.compile()
+}
+
+// compile validates the config and compiles its regexps. Discovery only uses the compiled ones,
+// so it can run concurrently with explaining it
+func (c *DiscoveryConfig) compile() error {
+	c.compiledRegexps = nil
+	for _, expression := range c.Regexps {
+		r, err := regexp.Compile(expression)
+		if err != nil {
+			return fmt.Errorf("invalid discovery regexp %q: %v", expression, err)
+		}
+		c.compiledRegexps = append(c.compiledRegexps, r)
+	}
+
+	for _, glob := range c.Globs {
+		if _, err := path.Match(glob, ""); err != nil {
+			return fmt.Errorf("invalid discovery glob %q: %v", glob, err)
+		}
+	}
+
+	for _, usbID := range append(append([]string{}, c.AllowedUsbIDs...), c.DeniedUsbIDs...) {
+		if len(strings.Split(usbID, ":")) != 2 {
+			return fmt.Errorf("invalid usb id %q, expected VID:PID", usbID)
+		}
+	}
+	return nil
+}
+
+func (c *DiscoveryConfig) discover() (controllerPorts []string, err error) {
+	candidates, err := c.explain()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, candidate := range candidates {
+		if candidate.Accepted {
+			controllerPorts = append(controllerPorts, candidate.PortName)
+		}
+	}
+	return
+}
+
+// explain lists every tty-like file in the source directories with the reason it was accepted or rejected
+func (c *DiscoveryConfig) explain() ([]DiscoveryCandidate, error) {
+	byIDLinks := c.byIDLinks()
+	candidates := []DiscoveryCandidate{}
+	for _, staticPort := range c.StaticPorts {
+		candidates = append(candidates, DiscoveryCandidate{
+			PortName: staticPort,
+			Accepted: true,
+			Reason:   "configured as static port",
+		})
+	}
+
+	for _, source := range c.SourceDirectories {
+		files, err := ioutil.ReadDir(source)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			if file.IsDir() {
+				continue
+			}
+
+			name := file.Name()
+			matchedPattern, matches := c.matchingPattern(name)
+			if !matches && !isHardwareTty(name) {
+				continue
+			}
+
+			candidate := DiscoveryCandidate{
+				PortName:   path.Join(source, name),
+				DevicePath: path.Join(source, name),
+			}
+			candidate.VendorID, candidate.ProductID = usbIDsForTty(name)
+			if target, err := filepath.EvalSymlinks(candidate.DevicePath); err == nil {
+				candidate.ByIDPath = byIDLinks[target]
+			}
+
+			candidate.Accepted, candidate.Reason = c.judge(candidate, matchedPattern, matches)
+			if candidate.Accepted && c.ResolveByID && candidate.ByIDPath != "" {
+				candidate.PortName = candidate.ByIDPath
+			}
+			candidates = append(candidates, candidate)
+		}
+	}
+	return candidates, nil
+}
+
+func (c *DiscoveryConfig) judge(candidate DiscoveryCandidate, matchedPattern string, matches bool) (accepted bool, reason string) {
+	if !matches {
+		return false, "does not match any glob or regexp"
+	}
+
+	usbID := candidate.VendorID + ":" + candidate.ProductID
+	for _, denied := range c.DeniedUsbIDs {
+		if candidate.VendorID != "" && usbIDMatches(denied, candidate.VendorID, candidate.ProductID) {
+			return false, fmt.Sprintf("usb id %s is denied by %s", usbID, denied)
+		}
+	}
+
+	if len(c.AllowedUsbIDs) > 0 {
+		if candidate.VendorID == "" {
+			return false, "usb id is unknown and an allow list is configured"
+		}
+		for _, allowed := range c.AllowedUsbIDs {
+			if usbIDMatches(allowed, candidate.VendorID, candidate.ProductID) {
+				return true, fmt.Sprintf("matches %s and usb id %s is allowed by %s", matchedPattern, usbID, allowed)
+			}
+		}
+		return false, fmt.Sprintf("usb id %s is not in the allow list", usbID)
+	}
+
+	return true, "matches " + matchedPattern
+}
+
+func (c *DiscoveryConfig) matchingPattern(name string) (pattern string, ok bool) {
+	for _, glob := range c.Globs {
+		if matched, _ := path.Match(glob, name); matched {
+			return "glob " + glob, true
+		}
+	}
+	for _, r := range c.compiledRegexps {
+		if r.MatchString(name) {
+			return "regexp " + r.String(), true
+		}
+	}
+	return "", false
+}
+
+// byIDLinks maps resolved device paths to their link in the by-id directory
+func (c *DiscoveryConfig) byIDLinks() map[string]string {
+	links := map[string]string{}
+	byIDDirectory := c.ByIDDirectory
+	if byIDDirectory == "" {
+		byIDDirectory = defaultByIDDirectory
+	}
+
+	files, err := ioutil.ReadDir(byIDDirectory)
+	if err != nil {
+		return links
+	}
+	for _, file := range files {
+		link := path.Join(byIDDirectory, file.Name())
+		target, err := filepath.EvalSymlinks(link)
+		if err != nil {
+			continue
+		}
+		links[target] = link
+	}
+	return links
+}
+
+// stableIDForPort is the by-id link of the port if there is one, the port name otherwise.
+// Unlike kernel names like /dev/ttyACM0, by-id links stay the same when the controller is replugged
+func stableIDForPort(portName, byIDDirectory string) string {
+	if path.Dir(portName) == byIDDirectory {
+		return portName
+	}
+	target, err := filepath.EvalSymlinks(portName)
+	if err != nil {
+		return portName
+	}
+
+	files, err := ioutil.ReadDir(byIDDirectory)
+	if err != nil {
+		return portName
+	}
+	for _, file := range files {
+		link := path.Join(byIDDirectory, file.Name())
+		if linkTarget, err := filepath.EvalSymlinks(link); err == nil && linkTarget == target {
+			return link
+		}
+	}
+	return portName
+}
+
+// usbIDsForTty looks up vendor and product id of the usb device the tty belongs to in sysfs
+func usbIDsForTty(ttyName string) (vendorID, productID string) {
+	devicePath, err := filepath.EvalSymlinks(path.Join(sysClassTtyDirectory, ttyName, "device"))
+	if err != nil {
+		return "", ""
+	}
+
+	for dir := devicePath; dir != "/" && dir != "."; dir = path.Dir(dir) {
+		vendor, err := ioutil.ReadFile(path.Join(dir, "idVendor"))
+		if err != nil {
+			continue
+		}
+		product, err := ioutil.ReadFile(path.Join(dir, "idProduct"))
+		if err != nil {
+			return "", ""
+		}
+		return strings.TrimSpace(string(vendor)), strings.TrimSpace(string(product))
+	}
+	return "", ""
+}
+
+func usbIDMatches(pattern, vendorID, productID string) bool {
+	splitPattern := strings.SplitN(pattern, ":", 2)
+	if len(splitPattern) != 2 {
+		return false
+	}
+
+	return strings.EqualFold(splitPattern[0], vendorID) &&
+		(splitPattern[1] == "*" || strings.EqualFold(splitPattern[1], productID))
+}
+
+// isHardwareTty decides whether a non matching file is still worth explaining.
+// Virtual consoles like tty1 have no device in sysfs and would only clutter the explanation
+func isHardwareTty(name string) bool {
+	return fileExists(path.Join(sysClassTtyDirectory, name, "device")) ||
+		strings.HasPrefix(name, "tty.") ||
+		strings.HasPrefix(name, "cu.")
+}
+
+func fileExists(filePath string) bool {
+	_, err := os.Stat(filePath)
+	return err == nil
+}
@@ -0,0 +1,120 @@
+package nervo
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DiscoveryConfig_judge(t *testing.T) {
+	tests := []struct {
+		testMessage      string
+		allowed          []string
+		denied           []string
+		vendorID         string
+		productID        string
+		matches          bool
+		expectedAccepted bool
+	}{
+		{
+			testMessage:      "given a port not matching any pattern",
+			matches:          false,
+			expectedAccepted: false,
+		},
+		{
+			testMessage:      "given no allow or deny lists",
+			vendorID:         "2341",
+			productID:        "0043",
+			matches:          true,
+			expectedAccepted: true,
+		},
+		{
+			testMessage:      "given a denied usb id",
+			denied:           []string{"1bc7:*"},
+			vendorID:         "1bc7",
+			productID:        "1201",
+			matches:          true,
+			expectedAccepted: false,
+		},
+		{
+			testMessage:      "given an allowed usb id",
+			allowed:          []string{"1a86:7523", "2341:0043"},
+			vendorID:         "2341",
+			productID:        "0043",
+			matches:          true,
+			expectedAccepted: true,
+		},
+		{
+			testMessage:      "given an usb id missing from the allow list",
+			allowed:          []string{"1a86:7523"},
+			vendorID:         "0403",
+			productID:        "6001",
+			matches:          true,
+			expectedAccepted: false,
+		},
+		{
+			testMessage:      "given an unknown usb id and an allow list",
+			allowed:          []string{"1a86:7523"},
+			matches:          true,
+			expectedAccepted: false,
+		},
+		{
+			testMessage:      "given upper case hex digits",
+			allowed:          []string{"1A86:7523"},
+			vendorID:         "1a86",
+			productID:        "7523",
+			matches:          true,
+			expectedAccepted: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testMessage, func(t *testing.T) {
+			config := &DiscoveryConfig{AllowedUsbIDs: test.allowed, DeniedUsbIDs: test.denied}
+			candidate := DiscoveryCandidate{VendorID: test.vendorID, ProductID: test.productID}
+			accepted, reason := config.judge(candidate, "glob ttyUSB*", test.matches)
+			assert.Equal(t, test.expectedAccepted, accepted)
+			assert.NotEmpty(t, reason)
+		})
+	}
+}
+
+func Test_DiscoveryConfig_discover(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nervo_discovery")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	devDir := path.Join(dir, "dev")
+	byIDDir := path.Join(dir, "by-id")
+	require.NoError(t, os.Mkdir(devDir, 0755))
+	require.NoError(t, os.Mkdir(byIDDir, 0755))
+	for _, name := range []string{"ttyUSB0", "ttyACM0", "ttyS0", "null"} {
+		require.NoError(t, ioutil.WriteFile(path.Join(devDir, name), nil, 0644))
+	}
+	require.NoError(t, os.Symlink(path.Join(devDir, "ttyACM0"), path.Join(byIDDir, "usb-Arduino_Uno_123-if00")))
+
+	config := &DiscoveryConfig{
+		SourceDirectories: []string{devDir},
+		Globs:             []string{"ttyUSB*"},
+		Regexps:           []string{"^ttyACM[0-9]+$"},
+		ByIDDirectory:     byIDDir,
+		ResolveByID:       true,
+	}
+	require.NoError(t, config.compile())
+
+	ports, err := config.discover()
+	require.NoError(t, err)
+	assert.Equal(t, []string{path.Join(byIDDir, "usb-Arduino_Uno_123-if00"), path.Join(devDir, "ttyUSB0")}, ports)
+
+	t.Run("stable ids are the by-id links if there are any", func(t *testing.T) {
+		byIDLink := path.Join(byIDDir, "usb-Arduino_Uno_123-if00")
+		assert.Equal(t, byIDLink, stableIDForPort(path.Join(devDir, "ttyACM0"), byIDDir))
+		assert.Equal(t, byIDLink, stableIDForPort(byIDLink, byIDDir))
+		assert.Equal(t, path.Join(devDir, "ttyUSB0"), stableIDForPort(path.Join(devDir, "ttyUSB0"), byIDDir))
+		assert.Equal(t, "tcp://10.0.0.5:3333", stableIDForPort("tcp://10.0.0.5:3333", byIDDir))
+	})
+}
@@ -33,7 +33,9 @@ func resetUsb() (output string, err error) {
 
 	out, execErr := cmd.CombinedOutput()
 	output = string(out)
-	err = execErr
+	if execErr != nil {
+		err = &CommandError{Command: "resetting usb", Output: output, Cause: execErr}
+	}
 
 	return
 }
@@ -0,0 +1,114 @@
+package nervo
+
+import (
+	"fmt"
+)
+
+// ControllerNotFoundError means there is no controller attached at PortName
+type ControllerNotFoundError struct {
+	PortName string
+}
+
+func (e *ControllerNotFoundError) Error() string {
+	return "no controller found at " + e.PortName
+}
+
+// ArtifactNotFoundError means no artifact matches Reference, an id or "<name>:<tag or version>"
+type ArtifactNotFoundError struct {
+	Reference string
+}
+
+func (e *ArtifactNotFoundError) Error() string {
+	return "no artifact found for " + e.Reference
+}
+
+// ArtifactExistsError means the uploaded content is already kept as the artifact with ID
+type ArtifactExistsError struct {
+	ID      string
+	Name    string
+	Version string
+}
+
+func (e *ArtifactExistsError) Error() string {
+	return "the content was already uploaded as " + e.Name + " " + e.Version + " (" + e.ID + "), tag that artifact instead"
+}
+
+// ControllerUnavailableError means the controller is known, but its transport is not open right now,
+// e.g. because reading from it failed or it is being flashed
+type ControllerUnavailableError struct {
+	PortName string
+	Cause    error
+}
+
+func (e *ControllerUnavailableError) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("controller at %s is unavailable: %v", e.PortName, e.Cause)
+	}
+	return "controller at " + e.PortName + " is unavailable"
+}
+
+// UnsupportedError means the operation can't be done with the controller, no matter how often it is retried
+type UnsupportedError struct {
+	PortName string
+	Reason   string
+}
+
+func (e *UnsupportedError) Error() string {
+	return e.Reason
+}
+
+// FlashError means the flasher ran, but failed. Output holds what it printed
+type FlashError struct {
+	PortName string
+	Output   string
+	Cause    error
+}
+
+func (e *FlashError) Error() string {
+	return fmt.Sprintf("flashing %s failed: %v", e.PortName, e.Cause)
+}
+
+// HexFileError means a hex file is malformed or doesn't fit onto the controller. Line is 0 if it concerns the whole file
+type HexFileError struct {
+	Line   int
+	Reason string
+}
+
+func (e *HexFileError) Error() string {
+	if e.Line == 0 {
+		return "invalid hex file: " + e.Reason
+	}
+	return fmt.Sprintf("invalid hex file, line %d: %s", e.Line, e.Reason)
+}
+
+// FirmwareFormatError means a firmware image can't be converted into a hex file
+type FirmwareFormatError struct {
+	Format FirmwareFormat
+	Reason string
+}
+
+func (e *FirmwareFormatError) Error() string {
+	return fmt.Sprintf("invalid %s firmware: %s", e.Format, e.Reason)
+}
+
+// CommandError means a command run on the server, like resetting usb, failed. Output holds what it printed
+type CommandError struct {
+	Command string
+	Output  string
+	Cause   error
+}
+
+func (e *CommandError) Error() string {
+	return fmt.Sprintf("%s failed: %v", e.Command, e.Cause)
+}
+
+// ResourceExhaustedError means a request exceeded a limit of the server
+type ResourceExhaustedError struct {
+	Resource string
+	Limit    int
+	Actual   int
+}
+
+func (e *ResourceExhaustedError) Error() string {
+	return fmt.Sprintf("%s is %d bytes, the limit is %d bytes", e.Resource, e.Actual, e.Limit)
+}
@@ -1,30 +1,193 @@
 package nervo
 
 import (
-	"io/ioutil"
-	"path"
+	"log"
 	"strings"
+	"time"
 )
 
-var (
-	sourceDirectories = []string{"/dev"}
-	matchers          = []string{"tty.usb", "ttyACM"}
-)
+// Discoverer notifies the manager about the currently attached controllers
+type Discoverer interface {
+	// Run blocks and sends the ports of all attached controllers to portsChan whenever they might have changed
+	Run(portsChan chan<- []string)
+	// Name of the discovery backend
+	Name() string
+}
+
+type discoveryExplainer interface {
+	explain() ([]DiscoveryCandidate, error)
+}
+
+// NewDiscoverer returns a Discoverer that listens to kernel uevents if possible and falls back to polling otherwise.
+// It fails if the config is invalid, the config must not be changed afterwards
+func NewDiscoverer(config *DiscoveryConfig) (Discoverer, error) {
+	if err := config.compile(); err != nil {
+		return nil, err
+	}
+
+	conn, err := openUeventConn()
+	if err != nil {
+		log.Println("listening to uevents not possible, falling back to polling:", err)
+		return &PollingDiscoverer{config: config, interval: time.Second}, nil
+	}
+
+	return newUeventDiscoverer(config, conn), nil
+}
+
+// PollingDiscoverer rescans the source directories in a fixed interval
+type PollingDiscoverer struct {
+	config   *DiscoveryConfig
+	interval time.Duration
+}
+
+// NewPollingDiscoverer returns a PollingDiscoverer that rescans every interval.
+// It fails if the config is invalid, the config must not be changed afterwards
+func NewPollingDiscoverer(config *DiscoveryConfig, interval time.Duration) (*PollingDiscoverer, error) {
+	if err := config.compile(); err != nil {
+		return nil, err
+	}
+
+	return &PollingDiscoverer{config: config, interval: interval}, nil
+}
+
+// Run for the Discoverer interface
+func (d *PollingDiscoverer) Run(portsChan chan<- []string) {
+	t := time.NewTicker(d.interval)
+	for {
+		sendDiscoveredPorts(d.config, portsChan)
+		<-t.C
+	}
+}
+
+// Name for the Discoverer interface
+func (d *PollingDiscoverer) Name() string {
+	return "polling"
+}
+
+func (d *PollingDiscoverer) explain() ([]DiscoveryCandidate, error) {
+	return d.config.explain()
+}
 
-func discoverAttachedControllers() (controllerPorts []string, err error) {
-	for _, source := range sourceDirectories {
-		files, err := ioutil.ReadDir(source)
+// UeventDiscoverer rescans the source directories whenever the kernel reports a tty being added or removed
+type UeventDiscoverer struct {
+	config         *DiscoveryConfig
+	conn           ueventConn
+	resyncInterval time.Duration
+	debounce       time.Duration
+}
+
+// NewUeventDiscoverer subscribes to kernel uevents. It returns an error if that is not supported on this system
+// or the config is invalid, the config must not be changed afterwards
+func NewUeventDiscoverer(config *DiscoveryConfig) (*UeventDiscoverer, error) {
+	if err := config.compile(); err != nil {
+		return nil, err
+	}
+
+	conn, err := openUeventConn()
+	if err != nil {
+		return nil, err
+	}
+
+	return newUeventDiscoverer(config, conn), nil
+}
+
+func newUeventDiscoverer(config *DiscoveryConfig, conn ueventConn) *UeventDiscoverer {
+	return &UeventDiscoverer{
+		config:         config,
+		conn:           conn,
+		resyncInterval: time.Second * 30,
+		debounce:       time.Millisecond * 100,
+	}
+}
+
+// Run for the Discoverer interface. If reading uevents fails, it falls back to polling
+func (d *UeventDiscoverer) Run(portsChan chan<- []string) {
+	eventChan := make(chan struct{}, 1)
+	failedChan := make(chan error)
+	go func() {
+		failedChan <- d.listen(eventChan)
+	}()
+
+	sendDiscoveredPorts(d.config, portsChan)
+	t := time.NewTicker(d.resyncInterval)
+	for {
+		select {
+		case <-eventChan:
+			// udev might still be creating nodes for the same device, wait for the burst to settle
+			time.Sleep(d.debounce)
+			sendDiscoveredPorts(d.config, portsChan)
+		case <-t.C:
+			sendDiscoveredPorts(d.config, portsChan)
+		case err := <-failedChan:
+			t.Stop()
+			log.Println("reading uevents failed, falling back to polling:", err)
+			(&PollingDiscoverer{config: d.config, interval: time.Second}).Run(portsChan)
+			return
+		}
+	}
+}
+
+// Name for the Discoverer interface
+func (d *UeventDiscoverer) Name() string {
+	return "uevent"
+}
+
+func (d *UeventDiscoverer) explain() ([]DiscoveryCandidate, error) {
+	return d.config.explain()
+}
+
+func (d *UeventDiscoverer) listen(eventChan chan<- struct{}) error {
+	defer d.conn.Close()
+	for {
+		event, err := d.conn.ReadEvent()
 		if err != nil {
-			return nil, err
+			return err
+		}
+
+		if !isTtyHotplugEvent(event) {
+			continue
 		}
 
-		for _, file := range files {
-			for _, matcher := range matchers {
-				if strings.Contains(file.Name(), matcher) {
-					controllerPorts = append(controllerPorts, path.Join(source, file.Name()))
-				}
-			}
+		select {
+		case eventChan <- struct{}{}:
+		default:
 		}
 	}
-	return
+}
+
+type ueventConn interface {
+	ReadEvent() (map[string]string, error)
+	Close() error
+}
+
+// parseUevent parses the payload of a kernel uevent in the form "action@devpath\0KEY=value\0..."
+func parseUevent(payload []byte) map[string]string {
+	event := map[string]string{}
+	for _, field := range strings.Split(string(payload), "\x00") {
+		splitField := strings.SplitN(field, "=", 2)
+		if len(splitField) != 2 {
+			continue
+		}
+		event[splitField[0]] = splitField[1]
+	}
+	return event
+}
+
+func isTtyHotplugEvent(event map[string]string) bool {
+	if event["SUBSYSTEM"] != "tty" {
+		return false
+	}
+
+	action := event["ACTION"]
+	return action == "add" || action == "remove"
+}
+
+func sendDiscoveredPorts(config *DiscoveryConfig, portsChan chan<- []string) {
+	ports, err := config.discover()
+	if err != nil {
+		log.Println("discovering controllers failed:", err)
+		return
+	}
+
+	portsChan <- ports
 }
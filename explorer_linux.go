@@ -0,0 +1,51 @@
+package nervo
+
+import (
+	"syscall"
+)
+
+type netlinkUeventConn struct {
+	fd     int
+	buffer []byte
+}
+
+func openUeventConn() (ueventConn, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW, syscall.NETLINK_KOBJECT_UEVENT)
+	if err != nil {
+		return nil, err
+	}
+
+	err = syscall.Bind(fd, &syscall.SockaddrNetlink{
+		Family: syscall.AF_NETLINK,
+		Pid:    0,
+		Groups: 1,
+	})
+	if err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+
+	return &netlinkUeventConn{fd: fd, buffer: make([]byte, 8192)}, nil
+}
+
+func (c *netlinkUeventConn) ReadEvent() (map[string]string, error) {
+	for {
+		n, _, err := syscall.Recvfrom(c.fd, c.buffer, 0)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err == syscall.ENOBUFS {
+			// events were dropped, report a generic one so the discoverer rescans
+			return map[string]string{"ACTION": "add", "SUBSYSTEM": "tty"}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return parseUevent(c.buffer[:n]), nil
+	}
+}
+
+func (c *netlinkUeventConn) Close() error {
+	return syscall.Close(c.fd)
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+// +build !linux
+
+package nervo
+
+import (
+	"errors"
+)
+
+func openUeventConn() (ueventConn, error) {
+	return nil, errors.New("uevents are only supported on linux")
+}
@@ -0,0 +1,57 @@
+package nervo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_isTtyHotplugEvent(t *testing.T) {
+	tests := []struct {
+		testMessage string
+		payload     string
+		expected    bool
+	}{
+		{
+			testMessage: "given a tty being added",
+			payload:     "add@/devices/platform/soc/usb1/1-1/1-1:1.0/tty/ttyACM0\x00ACTION=add\x00DEVNAME=ttyACM0\x00SUBSYSTEM=tty\x00",
+			expected:    true,
+		},
+		{
+			testMessage: "given a tty being removed",
+			payload:     "remove@/devices/platform/soc/usb1/1-1/1-1:1.0/tty/ttyACM0\x00ACTION=remove\x00DEVNAME=ttyACM0\x00SUBSYSTEM=tty\x00",
+			expected:    true,
+		},
+		{
+			testMessage: "given a tty changing",
+			payload:     "change@/devices/virtual/tty/tty1\x00ACTION=change\x00SUBSYSTEM=tty\x00",
+			expected:    false,
+		},
+		{
+			testMessage: "given a usb device being added",
+			payload:     "add@/devices/platform/soc/usb1/1-1\x00ACTION=add\x00SUBSYSTEM=usb\x00",
+			expected:    false,
+		},
+		{
+			testMessage: "given garbage",
+			payload:     "libudev\x00\xfe\xed",
+			expected:    false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testMessage, func(t *testing.T) {
+			assert.Equal(t, test.expected, isTtyHotplugEvent(parseUevent([]byte(test.payload))))
+		})
+	}
+}
+
+func Test_NewDiscoverer_invalidConfig(t *testing.T) {
+	config := &DiscoveryConfig{Regexps: []string{"^ttyACM[0-9+$"}}
+
+	_, err := NewPollingDiscoverer(config, time.Second)
+	assert.Error(t, err)
+	_, err = NewDiscoverer(config)
+	assert.Error(t, err)
+}
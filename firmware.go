@@ -0,0 +1,137 @@
+package nervo
+
+import (
+	"bytes"
+	"debug/elf"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// FirmwareFormat is the file format of a firmware image
+type FirmwareFormat string
+
+// The firmware formats that can be flashed. All of them are converted to Intel HEX, which the flashers take
+const (
+	// FirmwareFormatAuto tells Intel HEX and ELF files apart by their content
+	FirmwareFormatAuto FirmwareFormat = ""
+	FirmwareFormatHex  FirmwareFormat = "hex"
+	FirmwareFormatELF  FirmwareFormat = "elf"
+	// FirmwareFormatBin is a raw image that is written from a base address on
+	FirmwareFormatBin FirmwareFormat = "bin"
+)
+
+// hexBytesPerRecord is what avr-objcopy writes per data record
+const hexBytesPerRecord = 16
+
+// firmwareSegment is a contiguous block of the image
+type firmwareSegment struct {
+	address uint32
+	data    []byte
+}
+
+// detectFirmwareFormat tells ELF files by their magic number, everything else is taken for Intel HEX
+func detectFirmwareFormat(content []byte) FirmwareFormat {
+	if bytes.HasPrefix(content, []byte(elf.ELFMAG)) {
+		return FirmwareFormatELF
+	}
+	return FirmwareFormatHex
+}
+
+// toIntelHex converts firmware in format to Intel HEX. baseAddress is only used for raw images
+func toIntelHex(content []byte, format FirmwareFormat, baseAddress uint32) ([]byte, error) {
+	if format == FirmwareFormatAuto {
+		format = detectFirmwareFormat(content)
+	}
+
+	switch format {
+	case FirmwareFormatHex:
+		return content, nil
+	case FirmwareFormatELF:
+		segments, err := loadableELFSegments(content)
+		if err != nil {
+			return nil, err
+		}
+		return encodeIntelHex(segments), nil
+	case FirmwareFormatBin:
+		if len(content) == 0 {
+			return nil, &FirmwareFormatError{Format: format, Reason: "the image is empty"}
+		}
+		return encodeIntelHex([]firmwareSegment{{address: baseAddress, data: content}}), nil
+	default:
+		return nil, &FirmwareFormatError{Format: format, Reason: "unknown firmware format"}
+	}
+}
+
+// elfFlashEnd is where the flash ends in the physical address space of avr-gcc. Above it are .eeprom at 0x810000,
+// the fuses at 0x820000 and the lock bits at 0x830000, which avrdude writes to other memories than the hex file
+const elfFlashEnd = 0x800000
+
+// loadableELFSegments returns what the loadable segments of an ELF file put into flash, at their physical addresses.
+// For AVR that includes the initial values of .data, which are copied from flash to ram on boot
+func loadableELFSegments(content []byte) ([]firmwareSegment, error) {
+	file, err := elf.NewFile(bytes.NewReader(content))
+	if err != nil {
+		return nil, &FirmwareFormatError{Format: FirmwareFormatELF, Reason: err.Error()}
+	}
+	defer file.Close()
+
+	segments := []firmwareSegment{}
+	for _, program := range file.Progs {
+		if program.Type != elf.PT_LOAD || program.Filesz == 0 || program.Paddr >= elfFlashEnd {
+			continue
+		}
+		data := make([]byte, program.Filesz)
+		if _, err := io.ReadFull(program.Open(), data); err != nil {
+			return nil, &FirmwareFormatError{
+				Format: FirmwareFormatELF,
+				Reason: fmt.Sprintf("reading the segment at 0x%X: %v", program.Paddr, err),
+			}
+		}
+		segments = append(segments, firmwareSegment{address: uint32(program.Paddr), data: data})
+	}
+	if len(segments) == 0 {
+		return nil, &FirmwareFormatError{Format: FirmwareFormatELF, Reason: "the file has no loadable segments"}
+	}
+
+	sort.Slice(segments, func(i, j int) bool { return segments[i].address < segments[j].address })
+	return segments, nil
+}
+
+// encodeIntelHex writes the segments as data records, with extended linear address records where the upper 16 bits change
+func encodeIntelHex(segments []firmwareSegment) []byte {
+	out := &bytes.Buffer{}
+	upperAddress := uint32(0)
+	for _, segment := range segments {
+		for offset := 0; offset < len(segment.data); {
+			address := segment.address + uint32(offset)
+			if address>>16 != upperAddress {
+				upperAddress = address >> 16
+				writeHexRecord(out, 0, hexRecordExtendedLinearAddress, []byte{byte(upperAddress >> 8), byte(upperAddress)})
+			}
+
+			// a record must not cross a 64 KiB boundary
+			length := hexBytesPerRecord
+			if remaining := len(segment.data) - offset; remaining < length {
+				length = remaining
+			}
+			if untilBoundary := int(0x10000 - address&0xFFFF); untilBoundary < length {
+				length = untilBoundary
+			}
+			writeHexRecord(out, uint16(address), hexRecordData, segment.data[offset:offset+length])
+			offset += length
+		}
+	}
+	writeHexRecord(out, 0, hexRecordEndOfFile, nil)
+	return out.Bytes()
+}
+
+func writeHexRecord(out *bytes.Buffer, address uint16, recordType byte, data []byte) {
+	record := append([]byte{byte(len(data)), byte(address >> 8), byte(address), recordType}, data...)
+	var sum byte
+	for _, b := range record {
+		sum += b
+	}
+	record = append(record, -sum)
+	fmt.Fprintf(out, ":%X\n", record)
+}
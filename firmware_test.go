@@ -0,0 +1,122 @@
+package nervo
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testELFSegment struct {
+	virtualAddress  uint32
+	physicalAddress uint32
+	data            []byte
+	memorySize      uint32
+}
+
+// testELF builds a 32 bit little endian avr ELF file with a program header for every segment and no sections
+func testELF(segments []testELFSegment) []byte {
+	const headerSize, programHeaderSize = 52, 32
+	out := &bytes.Buffer{}
+	ident := [elf.EI_NIDENT]byte{0x7f, 'E', 'L', 'F', byte(elf.ELFCLASS32), byte(elf.ELFDATA2LSB), byte(elf.EV_CURRENT)}
+	binary.Write(out, binary.LittleEndian, elf.Header32{
+		Ident:     ident,
+		Type:      uint16(elf.ET_EXEC),
+		Machine:   uint16(elf.EM_AVR),
+		Version:   uint32(elf.EV_CURRENT),
+		Phoff:     headerSize,
+		Ehsize:    headerSize,
+		Phentsize: programHeaderSize,
+		Phnum:     uint16(len(segments)),
+	})
+
+	offset := uint32(headerSize + programHeaderSize*len(segments))
+	for _, segment := range segments {
+		binary.Write(out, binary.LittleEndian, elf.Prog32{
+			Type:   uint32(elf.PT_LOAD),
+			Off:    offset,
+			Vaddr:  segment.virtualAddress,
+			Paddr:  segment.physicalAddress,
+			Filesz: uint32(len(segment.data)),
+			Memsz:  segment.memorySize,
+			Flags:  uint32(elf.PF_R),
+		})
+		offset += uint32(len(segment.data))
+	}
+	for _, segment := range segments {
+		out.Write(segment.data)
+	}
+	return out.Bytes()
+}
+
+func Test_toIntelHex(t *testing.T) {
+	text := bytes.Repeat([]byte{0x0C, 0x94}, 10)
+
+	tests := []struct {
+		testMessage string
+		content     []byte
+		format      FirmwareFormat
+		baseAddress uint32
+		hexFile     string
+	}{
+		{
+			testMessage: "hex files are taken as they are",
+			content:     []byte(":040000000C94340028\n:00000001FF\n"),
+			format:      FirmwareFormatAuto,
+			hexFile:     ":040000000C94340028\n:00000001FF\n",
+		},
+		{
+			testMessage: "a raw image at its base address",
+			content:     []byte{0x0C, 0x94, 0x34, 0x00},
+			format:      FirmwareFormatBin,
+			baseAddress: 0x7E00,
+			hexFile:     ":047E00000C943400AA\n:00000001FF\n",
+		},
+		{
+			testMessage: "a raw image across a 64 KiB boundary",
+			content:     []byte{0x01, 0x02, 0x03, 0x04},
+			format:      FirmwareFormatBin,
+			baseAddress: 0xFFFE,
+			hexFile:     ":02FFFE000102FE\n:020000040001F9\n:020000000304F7\n:00000001FF\n",
+		},
+		{
+			testMessage: "the loadable segments of an elf file at their physical addresses",
+			content: testELF([]testELFSegment{
+				{virtualAddress: 0x800100, physicalAddress: 0x14, data: []byte{0xAA, 0xBB}, memorySize: 2},
+				{virtualAddress: 0, physicalAddress: 0, data: text, memorySize: uint32(len(text))},
+				{virtualAddress: 0x800102, physicalAddress: 0x16, memorySize: 8},
+			}),
+			format:  FirmwareFormatAuto,
+			hexFile: ":100000000C940C940C940C940C940C940C940C94F0\n:040010000C940C94AC\n:02001400AABB85\n:00000001FF\n",
+		},
+		{
+			testMessage: "eeprom, fuse and lock bit segments are left out",
+			content: testELF([]testELFSegment{
+				{virtualAddress: 0, physicalAddress: 0, data: []byte{0x0C, 0x94}, memorySize: 2},
+				{virtualAddress: 0x810000, physicalAddress: 0x810000, data: []byte{0x01, 0x02}, memorySize: 2},
+				{virtualAddress: 0x820000, physicalAddress: 0x820000, data: []byte{0xFF, 0xD9, 0xFE}, memorySize: 3},
+				{virtualAddress: 0x830000, physicalAddress: 0x830000, data: []byte{0xCF}, memorySize: 1},
+			}),
+			format:  FirmwareFormatELF,
+			hexFile: ":020000000C945E\n:00000001FF\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testMessage, func(t *testing.T) {
+			hexFile, err := toIntelHex(test.content, test.format, test.baseAddress)
+			require.NoError(t, err)
+			assert.Equal(t, test.hexFile, string(hexFile))
+			_, err = parseIntelHex(hexFile, 0)
+			assert.NoError(t, err)
+		})
+	}
+
+	t.Run("an elf file without loadable segments", func(t *testing.T) {
+		_, err := toIntelHex(testELF(nil), FirmwareFormatELF, 0)
+		assert.Equal(t, &FirmwareFormatError{Format: FirmwareFormatELF, Reason: "the file has no loadable segments"}, err)
+	})
+}
@@ -0,0 +1,118 @@
+package nervo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/peer"
+)
+
+// maxFirmwareSize limits chunked uploads, it is well above the hex files of large ESP32 or SAMD images
+const maxFirmwareSize = 32 << 20
+
+// maxPendingFirmwarePerClient limits how many bytes of uploads a client can have waiting to be flashed
+const maxPendingFirmwarePerClient = 2 * maxFirmwareSize
+
+// firmwareUploadLifetime is how long an upload is kept to be flashed, possibly onto several controllers
+const firmwareUploadLifetime = time.Hour
+
+type firmwareUpload struct {
+	content    []byte
+	client     string
+	uploadedAt time.Time
+}
+
+// firmwareUploads keeps uploaded firmware by the sha256 of its content until it expires
+type firmwareUploads struct {
+	mutex               *sync.Mutex
+	uploads             map[string]firmwareUpload
+	maxPendingPerClient int
+}
+
+func newFirmwareUploads() *firmwareUploads {
+	return &firmwareUploads{
+		mutex:               &sync.Mutex{},
+		uploads:             map[string]firmwareUpload{},
+		maxPendingPerClient: maxPendingFirmwarePerClient,
+	}
+}
+
+// add keeps content of client and returns its id. It fails if that would exceed the pending uploads allowed for client
+func (u *firmwareUploads) add(client string, content []byte) (id string, err error) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	sum := sha256.Sum256(content)
+	id = hex.EncodeToString(sum[:])
+	if pending := u.pendingLocked(client, id) + len(content); pending > u.maxPendingPerClient {
+		return "", u.exhaustedError(client, pending)
+	}
+
+	u.uploads[id] = firmwareUpload{content: content, client: client, uploadedAt: time.Now()}
+	return id, nil
+}
+
+func (u *firmwareUploads) exhaustedError(client string, pending int) error {
+	return &ResourceExhaustedError{
+		Resource: "pending firmware uploads of " + client,
+		Limit:    u.maxPendingPerClient,
+		Actual:   pending,
+	}
+}
+
+// pending is how many bytes of uploads client has waiting to be flashed
+func (u *firmwareUploads) pending(client string) int {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	return u.pendingLocked(client, "")
+}
+
+// pendingLocked removes expired uploads and sums up the ones of client, except the upload with exceptID
+func (u *firmwareUploads) pendingLocked(client, exceptID string) int {
+	now := time.Now()
+	pending := 0
+	for id, upload := range u.uploads {
+		if now.Sub(upload.uploadedAt) > firmwareUploadLifetime {
+			delete(u.uploads, id)
+			continue
+		}
+		if upload.client == client && id != exceptID {
+			pending += len(upload.content)
+		}
+	}
+	return pending
+}
+
+func (u *firmwareUploads) get(id string) (content []byte, ok bool) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	upload, ok := u.uploads[id]
+	if !ok || time.Since(upload.uploadedAt) > firmwareUploadLifetime {
+		return nil, false
+	}
+	return upload.content, true
+}
+
+// uploadClient identifies the client of ctx for the upload limits. Unlike clientIdentity,
+// clients without a certificate or token are told apart by their host only, so more connections don't raise their limit
+func uploadClient(ctx context.Context) string {
+	if identity, ok := clientCertificateIdentity(ctx); ok {
+		return identity
+	}
+	if name, ok := authenticatedName(ctx); ok {
+		return name
+	}
+
+	if p, ok := peer.FromContext(ctx); ok {
+		if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+			return host
+		}
+		return p.Addr.String()
+	}
+	return "unknown"
+}
@@ -0,0 +1,192 @@
+package nervo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+	"time"
+)
+
+const flashHistoryFile = "history.json"
+
+// maxFlashRecordsPerController bounds the history, images only older records refer to are removed with them
+const maxFlashRecordsPerController = 100
+
+// FlashRecord is one flash of a controller
+type FlashRecord struct {
+	// ImageID is the sha256 of the flashed hex file
+	ImageID string `json:"image_id"`
+	// ArtifactID is set if an artifact was flashed
+	ArtifactID string `json:"artifact_id,omitempty"`
+	// Source describes what was flashed, e.g. "artifact leg-firmware 1.0"
+	Source    string        `json:"source"`
+	PortName  string        `json:"port_name"`
+	Client    string        `json:"client"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+	// Error is empty if the flash succeeded
+	Error string `json:"error,omitempty"`
+}
+
+func (r FlashRecord) succeeded() bool {
+	return r.Error == ""
+}
+
+// FlashHistory records every flash per stable controller id and keeps the images that were flashed successfully, so they can be flashed again
+type FlashHistory struct {
+	// directory holds the history and the images. Without a directory, the history is only kept in memory
+	directory string
+	mutex     *sync.Mutex
+	// records are the newest first
+	records map[string][]FlashRecord
+	images  map[string][]byte
+}
+
+// NewFlashHistory loads the history kept in directory. If directory is empty, the history is lost when the server stops
+func NewFlashHistory(directory string) (*FlashHistory, error) {
+	h := &FlashHistory{
+		directory: directory,
+		mutex:     &sync.Mutex{},
+		records:   map[string][]FlashRecord{},
+		images:    map[string][]byte{},
+	}
+	if directory == "" {
+		return h, nil
+	}
+
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return nil, err
+	}
+	content, err := ioutil.ReadFile(path.Join(directory, flashHistoryFile))
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &h.records); err != nil {
+		return nil, fmt.Errorf("parsing the flash history in %s: %v", directory, err)
+	}
+	return h, nil
+}
+
+// add records a flash of the controller with stableID. record.ImageID is set from hexFile
+func (h *FlashHistory) add(stableID string, record FlashRecord, hexFile []byte) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	sum := sha256.Sum256(hexFile)
+	record.ImageID = hex.EncodeToString(sum[:])
+	if record.succeeded() {
+		if err := h.keepImage(record.ImageID, hexFile); err != nil {
+			return err
+		}
+	}
+
+	records := append([]FlashRecord{record}, h.records[stableID]...)
+	if len(records) > maxFlashRecordsPerController {
+		records = records[:maxFlashRecordsPerController]
+	}
+	h.records[stableID] = records
+	if err := h.save(); err != nil {
+		return err
+	}
+	return h.removeUnusedImages()
+}
+
+// list returns the flashes of the controller with stableID, the newest first
+func (h *FlashHistory) list(stableID string) []FlashRecord {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	return append([]FlashRecord{}, h.records[stableID]...)
+}
+
+// rollbackImage returns the previous known-good image of the controller with stableID.
+// After a failed flash that is the last image flashed successfully, otherwise the last successful one that differs from the current image
+func (h *FlashHistory) rollbackImage(stableID string) (FlashRecord, []byte, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	records := h.records[stableID]
+	for i, record := range records {
+		if i == 0 || !record.succeeded() {
+			continue
+		}
+		if records[0].succeeded() && record.ImageID == records[0].ImageID {
+			continue
+		}
+		content, err := h.image(record.ImageID)
+		if err != nil {
+			return FlashRecord{}, nil, err
+		}
+		return record, content, nil
+	}
+	return FlashRecord{}, nil, &UnsupportedError{
+		PortName: stableID,
+		Reason:   "the flash history has no earlier known-good image to roll back to",
+	}
+}
+
+func (h *FlashHistory) keepImage(imageID string, hexFile []byte) error {
+	if h.directory == "" {
+		h.images[imageID] = hexFile
+		return nil
+	}
+	return writeFileAtomically(path.Join(h.directory, imageID+".hex"), hexFile)
+}
+
+func (h *FlashHistory) image(imageID string) ([]byte, error) {
+	if h.directory == "" {
+		return h.images[imageID], nil
+	}
+	return ioutil.ReadFile(path.Join(h.directory, imageID+".hex"))
+}
+
+func (h *FlashHistory) removeUnusedImages() error {
+	used := map[string]bool{}
+	for _, records := range h.records {
+		for _, record := range records {
+			used[record.ImageID] = true
+		}
+	}
+
+	if h.directory == "" {
+		for imageID := range h.images {
+			if !used[imageID] {
+				delete(h.images, imageID)
+			}
+		}
+		return nil
+	}
+	files, err := ioutil.ReadDir(h.directory)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if path.Ext(file.Name()) != ".hex" || used[file.Name()[:len(file.Name())-len(".hex")]] {
+			continue
+		}
+		if err := os.Remove(path.Join(h.directory, file.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *FlashHistory) save() error {
+	if h.directory == "" {
+		return nil
+	}
+
+	content, err := json.MarshalIndent(h.records, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomically(path.Join(h.directory, flashHistoryFile), content)
+}
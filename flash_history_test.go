@@ -0,0 +1,47 @@
+package nervo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FlashHistory_rollbackImage(t *testing.T) {
+	tests := []struct {
+		testMessage string
+		// flashes are the images flashed, the oldest first, with whether they failed
+		flashes       []string
+		failures      []bool
+		expectedImage string
+	}{
+		{testMessage: "nothing flashed yet"},
+		{testMessage: "only one image", flashes: []string{"a"}, failures: []bool{false}},
+		{testMessage: "the image before the current one", flashes: []string{"a", "b"}, failures: []bool{false, false}, expectedImage: "a"},
+		{testMessage: "reflashing the current image doesn't count", flashes: []string{"a", "b", "b"}, failures: []bool{false, false, false}, expectedImage: "a"},
+		{testMessage: "after a failed flash the last good image", flashes: []string{"a", "b", "c"}, failures: []bool{false, false, true}, expectedImage: "b"},
+		{testMessage: "failed flashes are no rollback target", flashes: []string{"a", "b", "c"}, failures: []bool{false, true, false}, expectedImage: "a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.testMessage, func(t *testing.T) {
+			history, err := NewFlashHistory("")
+			require.NoError(t, err)
+			for i, image := range tt.flashes {
+				record := FlashRecord{Source: image}
+				if tt.failures[i] {
+					record.Error = "flash failed"
+				}
+				require.NoError(t, history.add("controller", record, []byte(image)))
+			}
+
+			record, content, err := history.rollbackImage("controller")
+			if tt.expectedImage == "" {
+				assert.IsType(t, &UnsupportedError{}, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedImage, record.Source)
+			assert.Equal(t, []byte(tt.expectedImage), content)
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package nervo
+
+import (
+	"bytes"
+	"strings"
+	"time"
+)
+
+// flashTimeout grows with the size of the hex file, an atmega328p takes about a second per 8 KiB of hex file to write and verify
+func flashTimeout(hexFileSize int) time.Duration {
+	return time.Second*10 + time.Duration(hexFileSize/(8<<10))*time.Second
+}
+
+// FlashPhase is the step a flasher is at
+type FlashPhase string
+
+// The phases flashers report, in the order they happen
+const (
+	FlashPhaseErase  FlashPhase = "erase"
+	FlashPhaseWrite  FlashPhase = "write"
+	FlashPhaseVerify FlashPhase = "verify"
+)
+
+// FlashProgress is reported by a ProgressFlasher while it flashes
+type FlashProgress struct {
+	Phase FlashPhase
+	// Percent of the phase that is done
+	Percent int
+}
+
+// ProgressFlasher is implemented by flashers that can report their progress while flashing
+type ProgressFlasher interface {
+	Flasher
+	FlashWithProgress(portName string, hexFileContent []byte, progress func(FlashProgress)) (output string, err error)
+}
+
+// avrdudeProgressWriter parses what avrdude prints while flashing into progress.
+// avrdude announces each phase on its own line and then draws a bar of 50 '#', one for every 2 percent
+type avrdudeProgressWriter struct {
+	progress func(FlashProgress)
+	line     []byte
+	phase    FlashPhase
+	inBar    bool
+	percent  int
+}
+
+func newAvrdudeProgressWriter(progress func(FlashProgress)) *avrdudeProgressWriter {
+	return &avrdudeProgressWriter{progress: progress}
+}
+
+// Write for the io.Writer interface
+func (w *avrdudeProgressWriter) Write(p []byte) (int, error) {
+	for _, b := range p {
+		switch b {
+		case '\n', '\r':
+			w.endLine()
+		case '#':
+			if w.inBar && w.percent < 100 {
+				w.percent += 2
+				w.report()
+			}
+			w.line = append(w.line, b)
+		default:
+			w.line = append(w.line, b)
+			w.startBar()
+		}
+	}
+	return len(p), nil
+}
+
+// startBar recognizes the "Writing | " and "Reading | " prefixes of the bars
+func (w *avrdudeProgressWriter) startBar() {
+	if w.inBar || !bytes.HasSuffix(w.line, []byte(" | ")) {
+		return
+	}
+
+	switch strings.TrimSpace(string(w.line)) {
+	case "Writing |":
+		w.phase = FlashPhaseWrite
+	case "Reading |":
+		// avrdude reads the signature before writing as well, only reading back the written flash counts
+		if w.phase != FlashPhaseWrite && w.phase != FlashPhaseVerify {
+			return
+		}
+		w.phase = FlashPhaseVerify
+	default:
+		return
+	}
+	w.inBar = true
+	w.percent = 0
+	w.report()
+}
+
+func (w *avrdudeProgressWriter) endLine() {
+	line := strings.ToLower(string(w.line))
+	w.line = w.line[:0]
+	w.inBar = false
+
+	switch {
+	case strings.Contains(line, "erasing chip"):
+		w.phase = FlashPhaseErase
+		w.percent = 0
+		w.report()
+	case strings.Contains(line, "writing flash"):
+		w.phase = FlashPhaseWrite
+	case strings.Contains(line, "reading on-chip flash data"), strings.Contains(line, "verifying"):
+		w.phase = FlashPhaseVerify
+	}
+}
+
+func (w *avrdudeProgressWriter) report() {
+	w.progress(FlashProgress{Phase: w.phase, Percent: w.percent})
+}
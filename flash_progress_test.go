@@ -0,0 +1,58 @@
+package nervo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const avrdudeOutput = `
+avrdude: AVR device initialized and ready to accept instructions
+
+Reading | ################################################## | 100% 0.00s
+
+avrdude: Device signature = 0x1e950f (probably m328p)
+avrdude: erasing chip
+avrdude: reading input file "firmware.hex"
+avrdude: writing flash (924 bytes):
+
+Writing | ################################################## | 100% 0.18s
+
+avrdude: 924 bytes of flash written
+avrdude: verifying flash memory against firmware.hex:
+avrdude: reading on-chip flash data:
+
+Reading | ################################################## | 100% 0.15s
+
+avrdude: verifying ...
+avrdude: 924 bytes of flash verified
+
+avrdude done.  Thank you.
+`
+
+func Test_avrdudeProgressWriter(t *testing.T) {
+	reported := []FlashProgress{}
+	w := newAvrdudeProgressWriter(func(p FlashProgress) {
+		reported = append(reported, p)
+	})
+
+	// avrdude draws the bars one '#' at a time
+	for _, part := range strings.SplitAfter(avrdudeOutput, "#") {
+		_, err := w.Write([]byte(part))
+		assert.NoError(t, err)
+	}
+
+	last := map[FlashPhase]FlashProgress{}
+	phases := []FlashPhase{}
+	for _, p := range reported {
+		if len(phases) == 0 || phases[len(phases)-1] != p.Phase {
+			phases = append(phases, p.Phase)
+		}
+		last[p.Phase] = p
+	}
+	assert.Equal(t, []FlashPhase{FlashPhaseErase, FlashPhaseWrite, FlashPhaseVerify}, phases)
+	assert.Equal(t, 100, last[FlashPhaseWrite].Percent)
+	assert.Equal(t, 100, last[FlashPhaseVerify].Percent)
+	assert.Len(t, reported, 1+51+51)
+}
@@ -0,0 +1,132 @@
+package nervo
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FlashFailure classifies why a flash failed
+type FlashFailure string
+
+// The failures avrdude's output is classified into
+const (
+	FlashFailureNone FlashFailure = ""
+	// FlashFailureUnknown is a failure none of the others matched
+	FlashFailureUnknown FlashFailure = "unknown"
+	// FlashFailureNotInSync means the bootloader didn't answer, e.g. because the board wasn't reset or the baud rate is wrong
+	FlashFailureNotInSync         FlashFailure = "not_in_sync"
+	FlashFailureWrongSignature    FlashFailure = "wrong_signature"
+	FlashFailurePermissionDenied  FlashFailure = "permission_denied"
+	FlashFailureTimeout           FlashFailure = "timeout"
+	FlashFailureVerificationError FlashFailure = "verification_error"
+)
+
+// FlashReport is what avrdude printed about a flash. Fields avrdude didn't print stay empty
+type FlashReport struct {
+	// DeviceSignature as avrdude read it, e.g. "0x1e950f"
+	DeviceSignature string
+	// Device is the part avrdude guessed from the signature, e.g. "m328p"
+	Device        string
+	BytesWritten  int
+	BytesVerified int
+	// Verified is true if the flash was read back and matched
+	Verified bool
+	// Fuses maps fuse names like "lfuse" to their values as hex, e.g. "0xFF". Nil if avrdude didn't print them
+	Fuses map[string]string
+	// Elapsed is the time of all of avrdude's progress bars
+	Elapsed time.Duration
+	Failure FlashFailure
+}
+
+var (
+	avrdudeSignatureRegexp  = regexp.MustCompile(`Device signature = (0x[0-9a-fA-F]+)(?: \(probably (\S+)\))?`)
+	avrdudeWrittenRegexp    = regexp.MustCompile(`(\d+) bytes of flash written`)
+	avrdudeVerifiedRegexp   = regexp.MustCompile(`(\d+) bytes of flash verified`)
+	avrdudeFusesOKRegexp    = regexp.MustCompile(`Fuses OK \(([^)]*)\)`)
+	avrdudeFuseReadsRegexp  = regexp.MustCompile(`([lhe]fuse) reads as ([0-9a-fA-F]+)`)
+	avrdudeBarRegexp        = regexp.MustCompile(`(?m)^(?:Reading|Writing) \|[# ]*\|\s+\d+%\s+(\d+\.\d+)s`)
+	avrdudeFuseLetterToName = map[string]string{"E": "efuse", "H": "hfuse", "L": "lfuse"}
+	avrdudeFailureMatchers  = []struct {
+		failure  FlashFailure
+		contains []string
+	}{
+		{FlashFailurePermissionDenied, []string{"permission denied"}},
+		{FlashFailureWrongSignature, []string{"invalid device signature", "expected signature for"}},
+		{FlashFailureVerificationError, []string{"verification error", "content mismatch"}},
+		{FlashFailureNotInSync, []string{"not in sync", "programmer is not responding"}},
+		{FlashFailureTimeout, []string{"timeout"}},
+	}
+)
+
+// parseAvrdudeOutput extracts a FlashReport from what avrdude printed. The failure is only classified if failed
+func parseAvrdudeOutput(output string, failed bool) FlashReport {
+	report := FlashReport{}
+	if match := avrdudeSignatureRegexp.FindStringSubmatch(output); match != nil {
+		report.DeviceSignature = strings.ToLower(match[1])
+		report.Device = match[2]
+	}
+	if match := avrdudeWrittenRegexp.FindStringSubmatch(output); match != nil {
+		report.BytesWritten, _ = strconv.Atoi(match[1])
+	}
+	if match := avrdudeVerifiedRegexp.FindStringSubmatch(output); match != nil {
+		report.BytesVerified, _ = strconv.Atoi(match[1])
+	}
+
+	fuses := map[string]string{}
+	if match := avrdudeFusesOKRegexp.FindStringSubmatch(output); match != nil {
+		for _, fuse := range strings.Split(match[1], ",") {
+			parts := strings.SplitN(strings.TrimSpace(fuse), ":", 2)
+			if name, ok := avrdudeFuseLetterToName[parts[0]]; ok && len(parts) == 2 {
+				fuses[name] = "0x" + strings.ToUpper(parts[1])
+			}
+		}
+	}
+	for _, match := range avrdudeFuseReadsRegexp.FindAllStringSubmatch(output, -1) {
+		fuses[match[1]] = "0x" + strings.ToUpper(match[2])
+	}
+	if len(fuses) > 0 {
+		report.Fuses = fuses
+	}
+
+	for _, match := range avrdudeBarRegexp.FindAllStringSubmatch(output, -1) {
+		seconds, _ := strconv.ParseFloat(match[1], 64)
+		report.Elapsed += time.Duration(seconds * float64(time.Second))
+	}
+
+	// avrdude retries getting in sync, so a successful flash may print failures as well
+	if failed {
+		report.Failure = classifyAvrdudeFailure(output)
+	}
+	report.Verified = report.BytesVerified > 0 && report.Failure != FlashFailureVerificationError
+	return report
+}
+
+func classifyAvrdudeFailure(output string) FlashFailure {
+	lower := strings.ToLower(output)
+	for _, matcher := range avrdudeFailureMatchers {
+		for _, contains := range matcher.contains {
+			if strings.Contains(lower, contains) {
+				return matcher.failure
+			}
+		}
+	}
+	return FlashFailureUnknown
+}
+
+// flashReportFor parses the output of a flash that reached the flasher, it is nil for errors that happened before
+func flashReportFor(answer flashAnswer) *FlashReport {
+	switch e := answer.Error.(type) {
+	case nil:
+		report := parseAvrdudeOutput(answer.Output, false)
+		return &report
+	case *FlashError:
+		report := parseAvrdudeOutput(e.Output, true)
+		return &report
+	}
+	if answer.Error == ErrTimeoutReached {
+		return &FlashReport{Failure: FlashFailureTimeout}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package nervo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseAvrdudeOutput(t *testing.T) {
+	tests := []struct {
+		testMessage string
+		output      string
+		failed      bool
+		expected    FlashReport
+	}{
+		{
+			testMessage: "a successful flash",
+			output:      avrdudeOutput + "avrdude: safemode: Fuses OK (E:FD, H:DE, L:FF)\n",
+			expected: FlashReport{
+				DeviceSignature: "0x1e950f",
+				Device:          "m328p",
+				BytesWritten:    924,
+				BytesVerified:   924,
+				Verified:        true,
+				Fuses:           map[string]string{"efuse": "0xFD", "hfuse": "0xDE", "lfuse": "0xFF"},
+				Elapsed:         330 * time.Millisecond,
+			},
+		},
+		{
+			testMessage: "retries getting in sync are no failure if the flash succeeded",
+			output:      "avrdude: stk500_getsync() attempt 1 of 10: not in sync: resp=0x00\n" + avrdudeOutput,
+			expected: FlashReport{
+				DeviceSignature: "0x1e950f",
+				Device:          "m328p",
+				BytesWritten:    924,
+				BytesVerified:   924,
+				Verified:        true,
+				Elapsed:         330 * time.Millisecond,
+			},
+		},
+		{
+			testMessage: "not in sync",
+			output:      "avrdude: stk500_recv(): programmer is not responding\navrdude: stk500_getsync() attempt 10 of 10: not in sync: resp=0x00\n",
+			failed:      true,
+			expected:    FlashReport{Failure: FlashFailureNotInSync},
+		},
+		{
+			testMessage: "wrong signature",
+			output: "avrdude: Device signature = 0x1e9587 (probably m32u4)\n" +
+				"avrdude: Expected signature for ATmega328P is 1E 95 0F\n         Double check chip, or use -F to override this check.\n",
+			failed:   true,
+			expected: FlashReport{DeviceSignature: "0x1e9587", Device: "m32u4", Failure: FlashFailureWrongSignature},
+		},
+		{
+			testMessage: "permission denied",
+			output:      `avrdude: ser_open(): can't open device "/dev/ttyACM0": Permission denied` + "\n",
+			failed:      true,
+			expected:    FlashReport{Failure: FlashFailurePermissionDenied},
+		},
+		{
+			testMessage: "verification error",
+			output: "avrdude: 924 bytes of flash written\n" +
+				"avrdude: verification error, first mismatch at byte 0x0002\n         0x34 != 0x00\n" +
+				"avrdude: verification error; content mismatch\n",
+			failed:   true,
+			expected: FlashReport{BytesWritten: 924, Failure: FlashFailureVerificationError},
+		},
+		{
+			testMessage: "unclassified failures",
+			output:      "avrdude: something unexpected\n",
+			failed:      true,
+			expected:    FlashReport{Failure: FlashFailureUnknown},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.testMessage, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parseAvrdudeOutput(tt.output, tt.failed))
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package nervo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Flasher writes firmware onto the controller attached at portName
+type Flasher interface {
+	Flash(portName string, hexFileContent []byte) (output string, err error)
+}
+
+// FlasherTool is an external program a Flasher relies on
+type FlasherTool struct {
+	Name string
+	// Path is empty if the tool wasn't found
+	Path    string
+	Version string
+}
+
+// toolDetector is implemented by flashers that can tell which external programs they would use
+type toolDetector interface {
+	detectTools() []FlasherTool
+}
+
+// AvrdudeFlasher flashes arduino unos (atmega328p) with avrdude
+type AvrdudeFlasher struct{}
+
+// Flash for the Flasher interface
+func (f *AvrdudeFlasher) Flash(portName string, hexFileContent []byte) (output string, err error) {
+	return f.FlashWithProgress(portName, hexFileContent, func(FlashProgress) {})
+}
+
+// FlashWithProgress for the ProgressFlasher interface, the progress is parsed from what avrdude prints
+func (f *AvrdudeFlasher) FlashWithProgress(portName string, hexFileContent []byte, progress func(FlashProgress)) (output string, err error) {
+	avrdudePort, err := avrdudePortName(portName)
+	if err != nil {
+		return "", err
+	}
+
+	hexFilePath, err := writeHexFileToTemporaryPath(hexFileContent)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(hexFilePath)
+
+	cmd := exec.Command(
+		"sh",
+		"-c",
+		fmt.Sprintf("avrdude -p m328p -c arduino -P %s -b 115200 -U flash:w:%s", avrdudePort, hexFilePath),
+	)
+
+	out := &bytes.Buffer{}
+	w := io.MultiWriter(out, newAvrdudeProgressWriter(progress))
+	cmd.Stdout = w
+	cmd.Stderr = w
+	err = cmd.Run()
+	return out.String(), err
+}
+
+func (f *AvrdudeFlasher) flashSize(string) int {
+	return atmega328pFlashSize
+}
+
+var toolVersionRegexp = regexp.MustCompile(`(?i)version:?\s+v?([0-9][^\s,]*)`)
+
+func (f *AvrdudeFlasher) detectTools() []FlasherTool {
+	return []FlasherTool{detectTool("avrdude", "-?")}
+}
+
+// detectTool looks the tool up in the PATH and parses its version out of what it prints for versionArgs
+func detectTool(name string, versionArgs ...string) FlasherTool {
+	tool := FlasherTool{Name: name}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return tool
+	}
+	tool.Path = path
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+	defer cancel()
+	// avrdude exits with an error after printing its usage, so only the output counts
+	out, _ := exec.CommandContext(ctx, path, versionArgs...).CombinedOutput()
+	if match := toolVersionRegexp.FindSubmatch(out); match != nil {
+		tool.Version = string(match[1])
+	}
+	return tool
+}
+
+// avrdudePortName translates a port name into what avrdude's -P flag expects
+func avrdudePortName(portName string) (string, error) {
+	switch {
+	case strings.HasPrefix(portName, tcpPortPrefix):
+		return "net:" + strings.TrimPrefix(portName, tcpPortPrefix), nil
+	case strings.HasPrefix(portName, rfc2217PortPrefix):
+		return "", &UnsupportedError{PortName: portName, Reason: "flashing over rfc2217 is not supported by avrdude"}
+	case strings.HasPrefix(portName, replayPortPrefix):
+		return "", &UnsupportedError{PortName: portName, Reason: "a replayed session can't be flashed"}
+	default:
+		return portName, nil
+	}
+}
+
+func writeHexFileToTemporaryPath(hexFileContent []byte) (path string, err error) {
+	tmpfile, err := ioutil.TempFile("", "flashing_*.hex")
+	if err != nil {
+		return "", fmt.Errorf("creating a temporary hex file: %v", err)
+	}
+
+	_, err = tmpfile.Write(hexFileContent)
+	if closeErr := tmpfile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpfile.Name())
+		return "", fmt.Errorf("writing the temporary hex file: %v", err)
+	}
+	return tmpfile.Name(), nil
+}
@@ -0,0 +1,27 @@
+package nervo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_toolVersionRegexp(t *testing.T) {
+	tests := []struct {
+		testMessage     string
+		output          string
+		expectedVersion string
+	}{
+		{"avrdude 6", "avrdude: Version 6.3-20171130\n         Copyright (c) 2000-2005 Brian Dean", "6.3-20171130"},
+		{"avrdude 7", "Usage: avrdude [options]\n...\navrdude version 7.1, URL: <https://github.com/avrdudes/avrdude>", "7.1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testMessage, func(t *testing.T) {
+			match := toolVersionRegexp.FindStringSubmatch(test.output)
+			require.NotNil(t, match)
+			assert.Equal(t, test.expectedVersion, match[1])
+		})
+	}
+}
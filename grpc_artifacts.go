@@ -0,0 +1,152 @@
+package nervo
+
+import (
+	"context"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/codeuniversity/nervo/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var protoFirmwareFormats = map[FirmwareFormat]proto.FirmwareFormat{
+	FirmwareFormatAuto: proto.FirmwareFormat_AUTO,
+	FirmwareFormatHex:  proto.FirmwareFormat_HEX,
+	FirmwareFormatELF:  proto.FirmwareFormat_ELF,
+	FirmwareFormatBin:  proto.FirmwareFormat_BIN,
+}
+
+// UploadArtifact for the grpc NervoService. The content is checked the same way as when flashing, so a kept artifact can always be flashed
+func (s *GrpcServer) UploadArtifact(stream proto.NervoService_UploadArtifactServer) error {
+	var metadata *proto.Artifact
+	content := []byte{}
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if metadata == nil {
+			metadata = chunk.Metadata
+		}
+
+		if len(content)+len(chunk.Data) > maxFirmwareSize {
+			return toStatusError(&ResourceExhaustedError{
+				Resource: "artifact upload",
+				Limit:    maxFirmwareSize,
+				Actual:   len(content) + len(chunk.Data),
+			})
+		}
+		content = append(content, chunk.Data...)
+	}
+	if metadata == nil || metadata.Name == "" {
+		return status.Error(codes.InvalidArgument, "the first chunk needs the metadata with at least a name")
+	}
+	for _, tag := range metadata.Tags {
+		if err := validateArtifactTag(tag); err != nil {
+			return err
+		}
+	}
+	if len(content) == 0 {
+		return status.Error(codes.InvalidArgument, "the upload is empty")
+	}
+
+	format, err := firmwareFormatOf(metadata.Format)
+	if err != nil {
+		return err
+	}
+	if format == FirmwareFormatAuto {
+		format = detectFirmwareFormat(content)
+	}
+	hexFile, err := toIntelHex(content, format, metadata.BaseAddress)
+	if err != nil {
+		return toStatusError(err)
+	}
+	if _, err := parseIntelHex(hexFile, 0); err != nil {
+		return toStatusError(err)
+	}
+
+	identity := clientIdentity(stream.Context())
+	artifact, err := s.Artifacts.add(Artifact{
+		Name:        metadata.Name,
+		Version:     metadata.Version,
+		Board:       metadata.Board,
+		Notes:       metadata.Notes,
+		Format:      format,
+		BaseAddress: metadata.BaseAddress,
+		Tags:        metadata.Tags,
+		UploadedBy:  identity,
+	}, content)
+	if err != nil {
+		return toStatusError(err)
+	}
+	log.Println(identity, "uploaded artifact", artifact.Name, artifact.Version, artifact.ID)
+	return stream.SendAndClose(artifactToProto(artifact))
+}
+
+// ListArtifacts for the grpc NervoService
+func (s *GrpcServer) ListArtifacts(_ context.Context, request *proto.ListArtifactsRequest) (*proto.ListArtifactsResponse, error) {
+	response := &proto.ListArtifactsResponse{}
+	for _, artifact := range s.Artifacts.list(request.Name) {
+		response.Artifacts = append(response.Artifacts, artifactToProto(artifact))
+	}
+	return response, nil
+}
+
+// TagArtifact for the grpc NervoService. A tag moves away from the other artifacts of the same name
+func (s *GrpcServer) TagArtifact(ctx context.Context, request *proto.TagArtifactRequest) (*proto.Artifact, error) {
+	if err := validateArtifactTag(request.Tag); err != nil {
+		return nil, err
+	}
+
+	var artifact Artifact
+	var err error
+	if request.Remove {
+		artifact, err = s.Artifacts.untag(request.Reference, request.Tag)
+	} else {
+		artifact, err = s.Artifacts.tag(request.Reference, request.Tag)
+	}
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	log.Println(clientIdentity(ctx), "tagged artifact", artifact.ID, "remove:", request.Remove, request.Tag)
+	return artifactToProto(artifact), nil
+}
+
+// DeleteArtifact for the grpc NervoService
+func (s *GrpcServer) DeleteArtifact(ctx context.Context, request *proto.DeleteArtifactRequest) (*proto.DeleteArtifactResponse, error) {
+	if err := s.Artifacts.remove(request.Reference); err != nil {
+		return nil, toStatusError(err)
+	}
+	log.Println(clientIdentity(ctx), "deleted artifact", request.Reference)
+	return &proto.DeleteArtifactResponse{}, nil
+}
+
+// validateArtifactTag rejects tags that could not be told apart in a "<name>:<tag>" reference
+func validateArtifactTag(tag string) error {
+	if tag == "" || strings.Contains(tag, ":") {
+		return status.Errorf(codes.InvalidArgument, "%q is not a valid tag, it has to be non empty and without ':'", tag)
+	}
+	return nil
+}
+
+func artifactToProto(artifact Artifact) *proto.Artifact {
+	return &proto.Artifact{
+		Id:                 artifact.ID,
+		Name:               artifact.Name,
+		Version:            artifact.Version,
+		Board:              artifact.Board,
+		Notes:              artifact.Notes,
+		Format:             protoFirmwareFormats[artifact.Format],
+		BaseAddress:        artifact.BaseAddress,
+		Size:               uint64(artifact.Size),
+		Tags:               artifact.Tags,
+		UploadedAtUnixNano: artifact.UploadedAt.UnixNano(),
+		UploadedBy:         artifact.UploadedBy,
+	}
+}
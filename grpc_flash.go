@@ -0,0 +1,380 @@
+package nervo
+
+import (
+	"context"
+	"io"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/codeuniversity/nervo/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var protoFlashPhases = map[FlashPhase]proto.FlashProgress_Phase{
+	FlashPhaseErase:  proto.FlashProgress_ERASE,
+	FlashPhaseWrite:  proto.FlashProgress_WRITE,
+	FlashPhaseVerify: proto.FlashProgress_VERIFY,
+}
+
+var protoFlashFailures = map[FlashFailure]proto.FlashReport_Failure{
+	FlashFailureNone:              proto.FlashReport_NONE,
+	FlashFailureUnknown:           proto.FlashReport_UNKNOWN,
+	FlashFailureNotInSync:         proto.FlashReport_NOT_IN_SYNC,
+	FlashFailureWrongSignature:    proto.FlashReport_WRONG_SIGNATURE,
+	FlashFailurePermissionDenied:  proto.FlashReport_PERMISSION_DENIED,
+	FlashFailureTimeout:           proto.FlashReport_TIMEOUT,
+	FlashFailureVerificationError: proto.FlashReport_VERIFICATION_ERROR,
+}
+
+var firmwareFormats = map[proto.FirmwareFormat]FirmwareFormat{
+	proto.FirmwareFormat_AUTO: FirmwareFormatAuto,
+	proto.FirmwareFormat_HEX:  FirmwareFormatHex,
+	proto.FirmwareFormat_ELF:  FirmwareFormatELF,
+	proto.FirmwareFormat_BIN:  FirmwareFormatBin,
+}
+
+// firmwareFormatOf fails with InvalidArgument for formats this server doesn't know, e.g. from a newer client
+func firmwareFormatOf(format proto.FirmwareFormat) (FirmwareFormat, error) {
+	firmwareFormat, ok := firmwareFormats[format]
+	if !ok {
+		return "", status.Errorf(codes.InvalidArgument, "unknown firmware format %d", format)
+	}
+	return firmwareFormat, nil
+}
+
+// UploadFirmware for the grpc NervoService. The chunks are joined and kept, so images larger than a single grpc message can be flashed
+func (s *GrpcServer) UploadFirmware(stream proto.NervoService_UploadFirmwareServer) error {
+	client := uploadClient(stream.Context())
+	// checked again when the upload is kept, this only stops clients over their limit early
+	pending := s.uploads.pending(client)
+	content := []byte{}
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(content)+len(chunk.Data) > maxFirmwareSize {
+			return toStatusError(&ResourceExhaustedError{
+				Resource: "firmware upload",
+				Limit:    maxFirmwareSize,
+				Actual:   len(content) + len(chunk.Data),
+			})
+		}
+		if pending+len(content)+len(chunk.Data) > s.uploads.maxPendingPerClient {
+			return toStatusError(s.uploads.exhaustedError(client, pending+len(content)+len(chunk.Data)))
+		}
+		content = append(content, chunk.Data...)
+	}
+	if len(content) == 0 {
+		return status.Error(codes.InvalidArgument, "the upload is empty")
+	}
+	// the format is only known when flashing, so the content is validated then
+	id, err := s.uploads.add(client, content)
+	if err != nil {
+		return toStatusError(err)
+	}
+	log.Println(clientIdentity(stream.Context()), "uploaded firmware", id, "with", len(content), "bytes")
+	return stream.SendAndClose(&proto.UploadFirmwareResponse{UploadId: id, Size: uint64(len(content))})
+}
+
+// FlashFirmware for the grpc NervoService. Flashes an upload or artifact and streams the progress, DONE with the output of the flasher ends the stream
+func (s *GrpcServer) FlashFirmware(request *proto.FlashFirmwareRequest, stream proto.NervoService_FlashFirmwareServer) error {
+	hexFile, record, err := s.firmwareToFlash(request)
+	if err != nil {
+		return err
+	}
+	return s.flashStreamingProgress(stream, request.ControllerPortName, hexFile, record)
+}
+
+// flashProgressStream is the server side of the rpcs that flash and stream the progress
+type flashProgressStream interface {
+	Send(*proto.FlashProgress) error
+	Context() context.Context
+}
+
+// flashStreamingProgress flashes hexFile, streams the progress and ends with DONE and the output of the flasher
+func (s *GrpcServer) flashStreamingProgress(stream flashProgressStream, portName string, hexFile []byte, record FlashRecord) error {
+	log.Println(clientIdentity(stream.Context()), "flashes", portName, "with", record.Source)
+
+	// the flasher may still report progress after a timeout, so it must never block on a stream that is gone
+	progressChan := make(chan FlashProgress, 100)
+	progress := func(p FlashProgress) {
+		select {
+		case progressChan <- p:
+		default:
+		}
+	}
+	answerChan := make(chan flashAnswer, 1)
+	go func() {
+		answerChan <- s.flashAndRecord(stream.Context(), portName, hexFile, record, progress)
+	}()
+
+	// once sending failed the client is gone, but the flash goes on until it is done
+	var sendErr error
+	send := func(p FlashProgress) {
+		if sendErr == nil {
+			sendErr = stream.Send(&proto.FlashProgress{Phase: protoFlashPhases[p.Phase], Percent: int32(p.Percent)})
+		}
+	}
+	for {
+		select {
+		case p := <-progressChan:
+			send(p)
+		case answer := <-answerChan:
+			for len(progressChan) > 0 {
+				send(<-progressChan)
+			}
+			if answer.Error != nil {
+				return flashStatusError(answer)
+			}
+			if sendErr != nil {
+				return sendErr
+			}
+			return stream.Send(&proto.FlashProgress{
+				Phase:   proto.FlashProgress_DONE,
+				Percent: 100,
+				Output:  answer.Output,
+				Report:  flashReportToProto(flashReportFor(answer)),
+			})
+		}
+	}
+}
+
+// firmwareToFlash returns the artifact or upload of the request as Intel HEX and what it is for the flash history
+func (s *GrpcServer) firmwareToFlash(request *proto.FlashFirmwareRequest) ([]byte, FlashRecord, error) {
+	if request.Artifact != "" {
+		artifact, content, err := s.Artifacts.get(request.Artifact)
+		if err != nil {
+			return nil, FlashRecord{}, toStatusError(err)
+		}
+		hexFile, err := toIntelHex(content, artifact.Format, artifact.BaseAddress)
+		if err != nil {
+			return nil, FlashRecord{}, toStatusError(err)
+		}
+		return hexFile, FlashRecord{Source: "artifact " + artifact.Name + " " + artifact.Version, ArtifactID: artifact.ID}, nil
+	}
+
+	content, ok := s.uploads.get(request.UploadId)
+	if !ok {
+		return nil, FlashRecord{}, status.Errorf(codes.NotFound, "there is no upload %q, it may have expired", request.UploadId)
+	}
+	format, err := firmwareFormatOf(request.Format)
+	if err != nil {
+		return nil, FlashRecord{}, err
+	}
+	hexFile, err := toIntelHex(content, format, request.BaseAddress)
+	if err != nil {
+		return nil, FlashRecord{}, toStatusError(err)
+	}
+	return hexFile, FlashRecord{Source: "upload " + request.UploadId}, nil
+}
+
+// flashAndRecord flashes hexFile and adds the flash to the history of the controller. Flashes that never reached a controller aren't recorded
+func (s *GrpcServer) flashAndRecord(ctx context.Context, portName string, hexFile []byte, record FlashRecord, progress func(FlashProgress)) flashAnswer {
+	record.PortName = portName
+	record.Client = clientIdentity(ctx)
+	record.StartedAt = time.Now()
+	answer := s.Manager.flashControllerWithProgress(portName, hexFile, progress)
+	record.Duration = time.Since(record.StartedAt)
+	if answer.Error != nil {
+		record.Error = answer.Error.Error()
+	}
+
+	if answer.StableID != "" {
+		if err := s.FlashHistory.add(answer.StableID, record, hexFile); err != nil {
+			log.Println("couldn't record the flash of", portName, ":", err)
+		}
+	}
+	return answer
+}
+
+// GetFlashHistory for the grpc NervoService
+func (s *GrpcServer) GetFlashHistory(_ context.Context, request *proto.FlashHistoryRequest) (*proto.FlashHistoryResponse, error) {
+	stableID := request.ControllerStableId
+	if stableID == "" {
+		var err error
+		if stableID, err = s.stableIDOf(request.ControllerPortName); err != nil {
+			return nil, toStatusError(err)
+		}
+	}
+
+	response := &proto.FlashHistoryResponse{ControllerStableId: stableID}
+	for _, record := range s.FlashHistory.list(stableID) {
+		response.Records = append(response.Records, &proto.FlashRecord{
+			ImageId:            record.ImageID,
+			ArtifactId:         record.ArtifactID,
+			Source:             record.Source,
+			ControllerPortName: record.PortName,
+			Client:             record.Client,
+			StartedAtUnixNano:  record.StartedAt.UnixNano(),
+			DurationNano:       int64(record.Duration),
+			Succeeded:          record.succeeded(),
+			Error:              record.Error,
+		})
+	}
+	return response, nil
+}
+
+// RollbackController for the grpc NervoService. Flashes the previous known-good image from the flash history and streams the progress like FlashFirmware
+func (s *GrpcServer) RollbackController(request *proto.RollbackControllerRequest, stream proto.NervoService_RollbackControllerServer) error {
+	stableID, err := s.stableIDOf(request.ControllerPortName)
+	if err != nil {
+		return toStatusError(err)
+	}
+	previous, hexFile, err := s.FlashHistory.rollbackImage(stableID)
+	if err != nil {
+		return toStatusError(err)
+	}
+
+	record := FlashRecord{
+		Source:     "rollback to " + previous.Source + " of " + previous.StartedAt.Format(time.RFC3339),
+		ArtifactID: previous.ArtifactID,
+	}
+	return s.flashStreamingProgress(stream, request.ControllerPortName, hexFile, record)
+}
+
+// stableIDOf returns the stable id of the controller at portName
+func (s *GrpcServer) stableIDOf(portName string) (string, error) {
+	for _, info := range s.Manager.listControllers() {
+		if info.portName == portName {
+			return info.stableID, nil
+		}
+	}
+	return "", &ControllerNotFoundError{PortName: portName}
+}
+
+// maxFlashParallelism bounds how many controllers FlashControllers flashes at once
+const maxFlashParallelism = 16
+
+// FlashControllers for the grpc NervoService. Flashes every controller matching the selector with the same image and returns a result per controller
+func (s *GrpcServer) FlashControllers(ctx context.Context, request *proto.FlashControllersRequest) (*proto.FlashControllersResponse, error) {
+	selector, err := selectorFromProto(request.Selector)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	parallelism := int(request.Parallelism)
+	if parallelism <= 0 {
+		parallelism = 1
+	}
+	if parallelism > maxFlashParallelism {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d controllers can be flashed at once", maxFlashParallelism)
+	}
+	hexFile, record, err := s.firmwareToFlash(&proto.FlashFirmwareRequest{
+		UploadId:    request.UploadId,
+		Format:      request.Format,
+		BaseAddress: request.BaseAddress,
+		Artifact:    request.Artifact,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	targets := []controllerInfo{}
+	for _, info := range s.Manager.listControllers() {
+		if selector.matches(info.portName, info.name, info.labels) {
+			targets = append(targets, info)
+		}
+	}
+	if len(targets) == 0 {
+		return nil, status.Error(codes.NotFound, "the selector matches no controller")
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].portName < targets[j].portName })
+	log.Println(clientIdentity(ctx), "flashes", len(targets), "controllers", parallelism, "at a time with", record.Source)
+
+	results := make([]*proto.FlashControllerResult, len(targets))
+	failedMutex := &sync.Mutex{}
+	failed := false
+	jobs := make(chan int)
+	wg := &sync.WaitGroup{}
+	for worker := 0; worker < parallelism; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				failedMutex.Lock()
+				skip := (failed && !request.ContinueOnFailure) || ctx.Err() != nil
+				failedMutex.Unlock()
+				if skip {
+					results[i] = flashControllerResult(targets[i])
+					results[i].Skipped = true
+					continue
+				}
+
+				results[i] = s.flashTarget(ctx, targets[i], hexFile, record)
+				if results[i].Code != int32(codes.OK) {
+					failedMutex.Lock()
+					failed = true
+					failedMutex.Unlock()
+				}
+			}
+		}()
+	}
+	for i := range targets {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return &proto.FlashControllersResponse{Results: results}, nil
+}
+
+func (s *GrpcServer) flashTarget(ctx context.Context, target controllerInfo, hexFile []byte, record FlashRecord) *proto.FlashControllerResult {
+	result := flashControllerResult(target)
+	start := time.Now()
+	answer := s.flashAndRecord(ctx, target.portName, hexFile, record, nil)
+	result.DurationNano = int64(time.Since(start))
+	result.Output = answer.Output
+	result.Report = flashReportToProto(flashReportFor(answer))
+	if answer.Error != nil {
+		flashStatus := status.Convert(toStatusError(answer.Error))
+		result.Code = int32(flashStatus.Code())
+		result.Error = flashStatus.Message()
+	}
+	return result
+}
+
+func flashControllerResult(target controllerInfo) *proto.FlashControllerResult {
+	return &proto.FlashControllerResult{
+		ControllerPortName: target.portName,
+		ControllerName:     target.name,
+		ControllerStableId: target.stableID,
+	}
+}
+
+// flashStatusError attaches the report of a flash that failed in the flasher to its status
+func flashStatusError(answer flashAnswer) error {
+	err := toStatusError(answer.Error)
+	report := flashReportFor(answer)
+	if report == nil {
+		return err
+	}
+	withReport, detailErr := status.Convert(err).WithDetails(flashReportToProto(report))
+	if detailErr != nil {
+		return err
+	}
+	return withReport.Err()
+}
+
+func flashReportToProto(report *FlashReport) *proto.FlashReport {
+	if report == nil {
+		return nil
+	}
+	return &proto.FlashReport{
+		DeviceSignature: report.DeviceSignature,
+		Device:          report.Device,
+		BytesWritten:    uint64(report.BytesWritten),
+		BytesVerified:   uint64(report.BytesVerified),
+		Verified:        report.Verified,
+		Fuses:           report.Fuses,
+		ElapsedNano:     int64(report.Elapsed),
+		Failure:         protoFlashFailures[report.Failure],
+	}
+}
@@ -0,0 +1,320 @@
+package nervo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/codeuniversity/nervo/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func Test_GrpcServer_FlashFirmware(t *testing.T) {
+	h := newTestHarness(t)
+	defer h.close()
+
+	h.attach("/dev/ttyACM0", "leg1")
+	hexFile := []byte(":100000000C9434000C9446000C9446000C9446006A\n:00000001FF\n")
+
+	upload, err := h.client.UploadFirmware(context.Background())
+	require.NoError(t, err)
+	for i := 0; i < len(hexFile); i += 16 {
+		end := i + 16
+		if end > len(hexFile) {
+			end = len(hexFile)
+		}
+		require.NoError(t, upload.Send(&proto.FirmwareChunk{Data: hexFile[i:end]}))
+	}
+	uploaded, err := upload.CloseAndRecv()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(len(hexFile)), uploaded.Size)
+
+	stream, err := h.client.FlashFirmware(context.Background(), &proto.FlashFirmwareRequest{
+		ControllerPortName: "/dev/ttyACM0",
+		UploadId:           uploaded.UploadId,
+	})
+	require.NoError(t, err)
+	progress := []*proto.FlashProgress{}
+	for {
+		p, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		progress = append(progress, p)
+	}
+	assert.Equal(t, []*proto.FlashProgress{
+		{Phase: proto.FlashProgress_WRITE, Percent: 50},
+		{Phase: proto.FlashProgress_WRITE, Percent: 100},
+		{Phase: proto.FlashProgress_DONE, Percent: 100, Output: "fake flash done", Report: &proto.FlashReport{}},
+	}, progress)
+	assert.Equal(t, [][]byte{hexFile}, h.flasher.flashedImages("/dev/ttyACM0"))
+
+	t.Run("unknown uploads are not found", func(t *testing.T) {
+		stream, err := h.client.FlashFirmware(context.Background(), &proto.FlashFirmwareRequest{
+			ControllerPortName: "/dev/ttyACM0",
+			UploadId:           "unknown",
+		})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("uploads beyond the pending limit of a client are refused", func(t *testing.T) {
+		h.server.uploads.maxPendingPerClient = len(hexFile) + 16
+
+		upload, err := h.client.UploadFirmware(context.Background())
+		require.NoError(t, err)
+		require.NoError(t, upload.Send(&proto.FirmwareChunk{Data: []byte(":040000000C94340028\n:00000001FF\n")}))
+		_, err = upload.CloseAndRecv()
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	})
+}
+
+func Test_GrpcServer_FlashArtifact(t *testing.T) {
+	h := newTestHarness(t)
+	defer h.close()
+
+	h.attach("/dev/ttyACM0", "leg1")
+	hexFile := []byte(":100000000C9434000C9446000C9446000C9446006A\n:00000001FF\n")
+
+	upload, err := h.client.UploadArtifact(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, upload.Send(&proto.ArtifactChunk{Metadata: &proto.Artifact{Name: "leg-firmware", Version: "1.0", Board: "uno"}}))
+	require.NoError(t, upload.Send(&proto.ArtifactChunk{Data: hexFile}))
+	artifact, err := upload.CloseAndRecv()
+	require.NoError(t, err)
+	assert.Equal(t, proto.FirmwareFormat_HEX, artifact.Format)
+	assert.Equal(t, uint64(len(hexFile)), artifact.Size)
+
+	tagged, err := h.client.TagArtifact(context.Background(), &proto.TagArtifactRequest{Reference: artifact.Id, Tag: "stable"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"stable"}, tagged.Tags)
+
+	list, err := h.client.ListArtifacts(context.Background(), &proto.ListArtifactsRequest{Name: "leg-firmware"})
+	require.NoError(t, err)
+	require.Len(t, list.Artifacts, 1)
+	assert.Equal(t, artifact.Id, list.Artifacts[0].Id)
+
+	stream, err := h.client.FlashFirmware(context.Background(), &proto.FlashFirmwareRequest{
+		ControllerPortName: "/dev/ttyACM0",
+		Artifact:           "leg-firmware:stable",
+	})
+	require.NoError(t, err)
+	for {
+		_, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+	}
+	assert.Equal(t, [][]byte{hexFile}, h.flasher.flashedImages("/dev/ttyACM0"))
+
+	t.Run("tags with ':' are rejected", func(t *testing.T) {
+		_, err := h.client.TagArtifact(context.Background(), &proto.TagArtifactRequest{Reference: artifact.Id, Tag: "a:b"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("identical content is rejected with the existing id", func(t *testing.T) {
+		upload, err := h.client.UploadArtifact(context.Background())
+		require.NoError(t, err)
+		require.NoError(t, upload.Send(&proto.ArtifactChunk{Metadata: &proto.Artifact{Name: "leg-firmware", Version: "1.1", Tags: []string{"beta"}}, Data: hexFile}))
+		_, err = upload.CloseAndRecv()
+		assert.Equal(t, codes.AlreadyExists, status.Code(err))
+		assert.Contains(t, status.Convert(err).Message(), artifact.Id)
+	})
+
+	t.Run("invalid firmware is not kept", func(t *testing.T) {
+		upload, err := h.client.UploadArtifact(context.Background())
+		require.NoError(t, err)
+		require.NoError(t, upload.Send(&proto.ArtifactChunk{Metadata: &proto.Artifact{Name: "broken"}, Data: []byte(":00000001FE\n")}))
+		_, err = upload.CloseAndRecv()
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("deleted artifacts are not found", func(t *testing.T) {
+		_, err := h.client.DeleteArtifact(context.Background(), &proto.DeleteArtifactRequest{Reference: "leg-firmware:1.0"})
+		require.NoError(t, err)
+		stream, err := h.client.FlashFirmware(context.Background(), &proto.FlashFirmwareRequest{
+			ControllerPortName: "/dev/ttyACM0",
+			Artifact:           "leg-firmware:stable",
+		})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
+
+func Test_GrpcServer_RollbackController(t *testing.T) {
+	h := newTestHarness(t)
+	defer h.close()
+
+	h.attach("/dev/ttyACM0", "leg1")
+	good := []byte(":100000000C9434000C9446000C9446000C9446006A\n:00000001FF\n")
+	bad := []byte(":040000000C94340028\n:00000001FF\n")
+	for _, hexFile := range [][]byte{good, bad} {
+		_, err := h.client.FlashController(context.Background(), &proto.FlashControllerRequest{
+			ControllerPortName: "/dev/ttyACM0",
+			HexFileContent:     hexFile,
+		})
+		require.NoError(t, err)
+	}
+
+	history, err := h.client.GetFlashHistory(context.Background(), &proto.FlashHistoryRequest{ControllerPortName: "/dev/ttyACM0"})
+	require.NoError(t, err)
+	assert.Equal(t, "/dev/ttyACM0", history.ControllerStableId)
+	require.Len(t, history.Records, 2)
+	assert.True(t, history.Records[0].Succeeded)
+	assert.Equal(t, "hex file", history.Records[0].Source)
+
+	stream, err := h.client.RollbackController(context.Background(), &proto.RollbackControllerRequest{ControllerPortName: "/dev/ttyACM0"})
+	require.NoError(t, err)
+	for {
+		_, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+	}
+	assert.Equal(t, [][]byte{good, bad, good}, h.flasher.flashedImages("/dev/ttyACM0"))
+
+	history, err = h.client.GetFlashHistory(context.Background(), &proto.FlashHistoryRequest{ControllerStableId: "/dev/ttyACM0"})
+	require.NoError(t, err)
+	require.Len(t, history.Records, 3)
+	assert.Contains(t, history.Records[0].Source, "rollback to hex file")
+
+	t.Run("without an earlier image there is nothing to roll back to", func(t *testing.T) {
+		h.attach("/dev/ttyACM1", "leg2")
+		stream, err := h.client.RollbackController(context.Background(), &proto.RollbackControllerRequest{ControllerPortName: "/dev/ttyACM1"})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+}
+
+func uploadTestFirmware(t *testing.T, h *testHarness, content []byte) string {
+	upload, err := h.client.UploadFirmware(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, upload.Send(&proto.FirmwareChunk{Data: content}))
+	uploaded, err := upload.CloseAndRecv()
+	require.NoError(t, err)
+	return uploaded.UploadId
+}
+
+func Test_GrpcServer_FlashControllers(t *testing.T) {
+	h := newTestHarness(t)
+	defer h.close()
+
+	for _, name := range []string{"leg1", "leg2", "leg3", "leg4"} {
+		portName := "/dev/ttyACM" + name[3:]
+		h.attach(portName, name)
+		_, err := h.client.SetControllerLabels(context.Background(), &proto.SetControllerLabelsRequest{
+			ControllerPortName: portName,
+			Labels:             map[string]string{"part": "leg"},
+		})
+		require.NoError(t, err)
+	}
+	h.attach("/dev/ttyACM9", "arm1")
+	hexFile := []byte(":100000000C9434000C9446000C9446000C9446006A\n:00000001FF\n")
+	uploadID := uploadTestFirmware(t, h, hexFile)
+
+	h.flasher.mutex.Lock()
+	h.flasher.delay = 50 * time.Millisecond
+	h.flasher.portErrors = map[string]error{"/dev/ttyACM2": errors.New("programmer is not responding")}
+	h.flasher.mutex.Unlock()
+
+	codesAndSkips := func(response *proto.FlashControllersResponse) []string {
+		summary := []string{}
+		for _, result := range response.Results {
+			outcome := codes.Code(result.Code).String()
+			if result.Skipped {
+				outcome = "skipped"
+			}
+			summary = append(summary, result.ControllerName+" "+outcome)
+		}
+		return summary
+	}
+
+	tests := []struct {
+		testMessage       string
+		parallelism       int32
+		continueOnFailure bool
+		expectedResults   []string
+		expectedMaxActive int
+	}{
+		{
+			testMessage:       "stops after the first failure",
+			parallelism:       1,
+			expectedResults:   []string{"leg1 OK", "leg2 Aborted", "leg3 skipped", "leg4 skipped"},
+			expectedMaxActive: 1,
+		},
+		{
+			testMessage:       "continues after failures in parallel",
+			parallelism:       4,
+			continueOnFailure: true,
+			expectedResults:   []string{"leg1 OK", "leg2 Aborted", "leg3 OK", "leg4 OK"},
+			expectedMaxActive: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.testMessage, func(t *testing.T) {
+			h.flasher.mutex.Lock()
+			h.flasher.maxActive = 0
+			h.flasher.mutex.Unlock()
+
+			response, err := h.client.FlashControllers(context.Background(), &proto.FlashControllersRequest{
+				Selector:          &proto.ControllerSelector{Labels: map[string]string{"part": "leg"}},
+				UploadId:          uploadID,
+				Parallelism:       tt.parallelism,
+				ContinueOnFailure: tt.continueOnFailure,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedResults, codesAndSkips(response))
+			assert.Equal(t, "fake flash done", response.Results[0].Output)
+			assert.Contains(t, response.Results[1].Error, "programmer is not responding")
+
+			h.flasher.mutex.Lock()
+			defer h.flasher.mutex.Unlock()
+			assert.Equal(t, tt.expectedMaxActive, h.flasher.maxActive)
+		})
+	}
+
+	t.Run("a controller being flashed is busy", func(t *testing.T) {
+		done := make(chan error)
+		go func() {
+			_, err := h.client.FlashController(context.Background(), &proto.FlashControllerRequest{
+				ControllerPortName: "/dev/ttyACM9",
+				HexFileContent:     hexFile,
+			})
+			done <- err
+		}()
+		require.Eventually(t, func() bool {
+			for _, info := range h.manager.listControllers() {
+				if info.portName == "/dev/ttyACM9" {
+					return info.state == controllerStateFlashing
+				}
+			}
+			return false
+		}, testWaitTimeout, time.Millisecond)
+
+		_, err := h.client.FlashController(context.Background(), &proto.FlashControllerRequest{
+			ControllerPortName: "/dev/ttyACM9",
+			HexFileContent:     hexFile,
+		})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.NoError(t, <-done)
+	})
+
+	t.Run("selecting no controller is not found", func(t *testing.T) {
+		_, err := h.client.FlashControllers(context.Background(), &proto.FlashControllersRequest{
+			Selector: &proto.ControllerSelector{Names: []string{"tail"}},
+			UploadId: uploadID,
+		})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
@@ -0,0 +1,96 @@
+package nervo
+
+import (
+	"context"
+	"io"
+
+	"github.com/codeuniversity/nervo/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SetControllerLabels for the grpc NervoService
+func (s *GrpcServer) SetControllerLabels(_ context.Context, request *proto.SetControllerLabelsRequest) (*proto.ControllerListResponse, error) {
+	if err := s.Manager.setControllerLabels(request.ControllerPortName, request.Labels); err != nil {
+		return nil, toStatusError(err)
+	}
+	return s.controllerList(), nil
+}
+
+// ReadMultiplexedOutput for the grpc NervoService. Streams the lines of every controller matching the selector,
+// including the ones attached or renamed after the stream started
+func (s *GrpcServer) ReadMultiplexedOutput(request *proto.ReadMultiplexedOutputRequest, stream proto.NervoService_ReadMultiplexedOutputServer) error {
+	selector, err := selectorFromProto(request.Selector)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	filter, err := outputFilterFromProto(request.Filter)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	lines := s.Manager.observeAllControllers()
+	defer s.Manager.stopObservingAllControllers(lines)
+
+	for {
+		select {
+		case line := <-lines:
+			if !selector.matches(line.portName, line.name, s.Manager.labelsFor(line.name)) || !filter.passes(line.portName, line.data) {
+				continue
+			}
+			err := stream.Send(&proto.MultiplexedOutputLine{
+				ControllerPortName: line.portName,
+				ControllerName:     line.name,
+				Line:               string(line.data),
+				TimeUnixNano:       line.time.UnixNano(),
+			})
+			if err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+// WriteToControllers for the grpc NervoService. Every message names its own target and is acknowledged in order,
+// a failed write is reported in its acknowledgement and doesn't end the stream
+func (s *GrpcServer) WriteToControllers(stream proto.NervoService_WriteToControllersServer) error {
+	for {
+		request, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := stream.Send(s.writeToTarget(request)); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *GrpcServer) writeToTarget(request *proto.WriteToControllersRequest) *proto.WriteToControllersAck {
+	ack := &proto.WriteToControllersAck{Id: request.Id}
+	target := controllerTarget{
+		portName: request.ControllerPortName,
+		stableID: request.ControllerStableId,
+		name:     request.ControllerName,
+	}
+	if target.empty() {
+		ack.Code = int32(codes.InvalidArgument)
+		ack.Error = "the message names no controller, set its port name, stable id or name"
+		return ack
+	}
+
+	portName, err := s.Manager.writeToTarget(target, request.Message)
+	ack.ControllerPortName = portName
+	if err != nil {
+		writeStatus := status.Convert(toStatusError(err))
+		ack.Code = int32(writeStatus.Code())
+		ack.Error = writeStatus.Message()
+	}
+	return ack
+}
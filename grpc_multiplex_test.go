@@ -0,0 +1,152 @@
+package nervo
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/codeuniversity/nervo/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func Test_GrpcServer_ReadMultiplexedOutput(t *testing.T) {
+	h := newTestHarness(t)
+	defer h.close()
+
+	leg1 := h.attach("/dev/ttyACM0", "leg1")
+	leg2 := h.attach("/dev/ttyACM1", "leg2")
+	leg3 := h.attach("/dev/ttyACM2", "leg3")
+
+	response, err := h.client.SetControllerLabels(context.Background(), &proto.SetControllerLabelsRequest{
+		ControllerPortName: "/dev/ttyACM1",
+		Labels:             map[string]string{"side": "left"},
+	})
+	require.NoError(t, err)
+	for _, info := range response.ControllerInfos {
+		if info.PortName == "/dev/ttyACM1" {
+			assert.Equal(t, map[string]string{"side": "left"}, info.Labels)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := h.client.ReadMultiplexedOutput(ctx, &proto.ReadMultiplexedOutputRequest{
+		Selector: &proto.ControllerSelector{Names: []string{"leg1", "leg4"}, Labels: map[string]string{"side": "left"}},
+	})
+	require.NoError(t, err)
+	h.waitForObserversOfAllControllers(1)
+
+	receive := func() *proto.MultiplexedOutputLine {
+		line, err := stream.Recv()
+		require.NoError(t, err)
+		return line
+	}
+
+	leg3.send("not selected")
+	leg1.send("selected by name")
+	assert.Equal(t, &proto.MultiplexedOutputLine{
+		ControllerPortName: "/dev/ttyACM0",
+		ControllerName:     "leg1",
+		Line:               "selected by name\n",
+		TimeUnixNano:       0,
+	}, withoutTime(receive()))
+
+	leg2.send("selected by label")
+	assert.Equal(t, "leg2", receive().ControllerName)
+
+	t.Run("controllers attached later join", func(t *testing.T) {
+		leg4 := h.attach("/dev/ttyACM3", "leg4")
+		leg4.send("joined")
+
+		line := receive()
+		assert.Equal(t, "leg4", line.ControllerName)
+		assert.Equal(t, "joined\n", line.Line)
+	})
+}
+
+func Test_GrpcServer_ReadMultiplexedOutput_Filter(t *testing.T) {
+	h := newTestHarness(t)
+	defer h.close()
+
+	leg1 := h.attach("/dev/ttyACM0", "leg1")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := h.client.ReadMultiplexedOutput(ctx, &proto.ReadMultiplexedOutputRequest{
+		Selector: &proto.ControllerSelector{All: true},
+		Filter:   &proto.OutputFilter{Verbs: []string{"feedback"}, Exclude: []string{"debug"}},
+	})
+	require.NoError(t, err)
+	h.waitForObserversOfAllControllers(1)
+
+	leg1.send("sensor_data 12")
+	leg1.send("feedback debug on")
+	leg1.send("feedback done")
+	line, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "feedback done\n", line.Line)
+
+	t.Run("an invalid pattern is an invalid argument", func(t *testing.T) {
+		stream, err := h.client.ReadMultiplexedOutput(ctx, &proto.ReadMultiplexedOutputRequest{
+			Selector: &proto.ControllerSelector{All: true},
+			Filter:   &proto.OutputFilter{Include: []string{"("}},
+		})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func Test_GrpcServer_WriteToControllers(t *testing.T) {
+	h := newTestHarness(t)
+	defer h.close()
+
+	leg1 := h.attach("/dev/ttyACM0", "leg1")
+	leg2 := h.attach("/dev/ttyACM1", "leg2")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := h.client.WriteToControllers(ctx)
+	require.NoError(t, err)
+
+	requests := []*proto.WriteToControllersRequest{
+		{Id: 1, ControllerPortName: "/dev/ttyACM0", Message: []byte("step 1\n")},
+		{Id: 2, ControllerName: "leg2", Message: []byte("step 1\n")},
+		{Id: 3, ControllerStableId: "/dev/ttyACM1", Message: []byte("step 2\n")},
+		{Id: 4, ControllerName: "leg3", Message: []byte("step 1\n")},
+		{Id: 5, Message: []byte("step 1\n")},
+	}
+	for _, request := range requests {
+		require.NoError(t, stream.Send(request))
+	}
+
+	expected := []*proto.WriteToControllersAck{
+		{Id: 1, ControllerPortName: "/dev/ttyACM0"},
+		{Id: 2, ControllerPortName: "/dev/ttyACM1"},
+		{Id: 3, ControllerPortName: "/dev/ttyACM1"},
+		{Id: 4, Code: int32(codes.NotFound), Error: "no controller found at leg3"},
+		{Id: 5, Code: int32(codes.InvalidArgument), Error: "the message names no controller, set its port name, stable id or name"},
+	}
+	for _, expectedAck := range expected {
+		ack, err := stream.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, expectedAck, ack)
+	}
+	assert.Equal(t, "step 1\n", leg1.receive(t))
+	assert.Equal(t, "step 1\n", leg2.receive(t))
+	assert.Equal(t, "step 2\n", leg2.receive(t))
+
+	t.Run("ends when the client closes", func(t *testing.T) {
+		require.NoError(t, stream.CloseSend())
+		_, err := stream.Recv()
+		assert.Equal(t, io.EOF, err)
+	})
+}
+
+func withoutTime(line *proto.MultiplexedOutputLine) *proto.MultiplexedOutputLine {
+	line.TimeUnixNano = 0
+	return line
+}
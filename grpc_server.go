@@ -2,27 +2,51 @@ package nervo
 
 import (
 	"context"
-	"errors"
+	"crypto/tls"
 	"fmt"
+	"io"
 	"log"
 	"net"
 
 	"github.com/codeuniversity/nervo/proto"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
 )
 
+// maxHexFileSize is well above the hex file of the largest avr flash (256 KiB) and below the default grpc message limit
+const maxHexFileSize = 2 << 20
+
 // GrpcServer translates grpc requests into calls to the manager
 type GrpcServer struct {
 	Manager  *Manager
 	grpcPort int
+	// TLSConfig enables tls for all connections if set
+	TLSConfig *tls.Config
+	// Authenticator requires every request to carry an api token with a sufficient role if set
+	Authenticator *TokenAuthenticator
+	// Mhist is reported in the server info if set
+	Mhist *MhistConnector
+	// Artifacts keeps firmware images to flash by id or tag. NewGrpcServer creates one in memory
+	Artifacts *ArtifactStore
+	// FlashHistory records every flash to roll back to. NewGrpcServer creates one in memory
+	FlashHistory *FlashHistory
+	httpGateway  bool
+	uploads      *firmwareUploads
 }
 
 // NewGrpcServer creates a GrpcServer for the given manager
 func NewGrpcServer(m *Manager, grpcPort int) *GrpcServer {
+	artifacts, _ := NewArtifactStore("")
+	flashHistory, _ := NewFlashHistory("")
 	return &GrpcServer{
-		Manager:  m,
-		grpcPort: grpcPort,
+		Manager:      m,
+		grpcPort:     grpcPort,
+		Artifacts:    artifacts,
+		FlashHistory: flashHistory,
+		uploads:      newFirmwareUploads(),
 	}
 }
 
@@ -32,8 +56,7 @@ func (s *GrpcServer) Listen() {
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
-	grpcServer := grpc.NewServer()
-	proto.RegisterNervoServiceServer(grpcServer, s)
+	grpcServer := s.newGrpcServer()
 
 	if err := grpcServer.Serve(lis); err != nil {
 		log.Fatalf("failed to serve: %v", err)
@@ -41,43 +64,100 @@ func (s *GrpcServer) Listen() {
 
 }
 
+func (s *GrpcServer) newGrpcServer() *grpc.Server {
+	options := []grpc.ServerOption{}
+	if s.TLSConfig != nil {
+		options = append(options, grpc.Creds(credentials.NewTLS(s.TLSConfig)))
+	}
+	if s.Authenticator != nil {
+		options = append(options,
+			grpc.UnaryInterceptor(s.Authenticator.unaryInterceptor),
+			grpc.StreamInterceptor(s.Authenticator.streamInterceptor),
+		)
+	}
+
+	grpcServer := grpc.NewServer(options...)
+	proto.RegisterNervoServiceServer(grpcServer, s)
+	return grpcServer
+}
+
 // ListControllers for the grpc NervoService
 func (s *GrpcServer) ListControllers(_ context.Context, _ *proto.ControllerListRequest) (*proto.ControllerListResponse, error) {
-	controllerInfos := s.Manager.listControllers()
+	return s.controllerList(), nil
+}
+
+func (s *GrpcServer) controllerList() *proto.ControllerListResponse {
 	infos := []*proto.ControllerInfo{}
-	for _, info := range controllerInfos {
+	for _, info := range s.Manager.listControllers() {
 		infos = append(infos, &proto.ControllerInfo{
 			PortName: info.portName,
 			Name:     info.name,
+			State:    info.state,
+			Labels:   info.labels,
+			StableId: info.stableID,
 		})
 	}
 
-	return &proto.ControllerListResponse{ControllerInfos: infos}, nil
+	return &proto.ControllerListResponse{ControllerInfos: infos}
 }
 
 // ReadControllerOutput for the grpc NervoService
 func (s *GrpcServer) ReadControllerOutput(_ context.Context, request *proto.ReadControllerOutputRequest) (*proto.ReadControllerOutputResponse, error) {
-	output := s.Manager.readFromController(request.ControllerPortName)
+	output, err := s.Manager.readFromController(request.ControllerPortName)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
 
 	return &proto.ReadControllerOutputResponse{Output: output}, nil
 }
 
 // FlashController for the grpc NervoService
-func (s *GrpcServer) FlashController(_ context.Context, request *proto.FlashControllerRequest) (*proto.FlashControllerResponse, error) {
-	answer := s.Manager.flashController(request.ControllerPortName, request.HexFileContent)
-	return &proto.FlashControllerResponse{Output: answer.Output}, answer.Error
+func (s *GrpcServer) FlashController(ctx context.Context, request *proto.FlashControllerRequest) (*proto.FlashControllerResponse, error) {
+	log.Println(clientIdentity(ctx), "flashes", request.ControllerPortName)
+	if len(request.HexFileContent) > maxHexFileSize {
+		return nil, toStatusError(&ResourceExhaustedError{
+			Resource: "hex file",
+			Limit:    maxHexFileSize,
+			Actual:   len(request.HexFileContent),
+		})
+	}
+
+	format, err := firmwareFormatOf(request.Format)
+	if err != nil {
+		return nil, err
+	}
+	hexFile, err := toIntelHex(request.HexFileContent, format, request.BaseAddress)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	answer := s.flashAndRecord(ctx, request.ControllerPortName, hexFile, FlashRecord{Source: "hex file"}, nil)
+	if answer.Error != nil {
+		return nil, flashStatusError(answer)
+	}
+	return &proto.FlashControllerResponse{Output: answer.Output, Report: flashReportToProto(flashReportFor(answer))}, nil
 }
 
 // ReadControllerOutputContinuously for the grpc NervoService
 func (s *GrpcServer) ReadControllerOutputContinuously(request *proto.ReadControllerOutputRequest, stream proto.NervoService_ReadControllerOutputContinuouslyServer) error {
+	filter, err := outputFilterFromProto(request.Filter)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if request.Resume {
+		return s.resumeControllerOutput(request, filter, stream)
+	}
 
-	notifierChan := s.Manager.readContinuouslyFromController(request.ControllerPortName)
-
-	if notifierChan == nil {
-		return errors.New("no controller found for " + request.ControllerPortName)
+	notifierChan, err := s.Manager.readContinuouslyFromController(request.ControllerPortName)
+	if err != nil {
+		return toStatusError(err)
 	}
 
-	output := s.Manager.readFromController(request.ControllerPortName)
+	output, err := s.Manager.readFromController(request.ControllerPortName)
+	if err != nil {
+		s.Manager.stopReadingFromController(request.ControllerPortName)
+		return toStatusError(err)
+	}
+	output = filter.filterOutput(request.ControllerPortName, output)
 	if len(output) > 0 {
 		err := stream.Send(&proto.ReadControllerOutputResponse{Output: output})
 		if err != nil {
@@ -87,9 +167,9 @@ func (s *GrpcServer) ReadControllerOutputContinuously(request *proto.ReadControl
 		}
 	}
 
-	for newOutput := range notifierChan {
-		if len(newOutput) > 0 {
-			err := stream.Send(&proto.ReadControllerOutputResponse{Output: string(newOutput)})
+	for line := range notifierChan {
+		if len(line.data) > 0 && filter.passes(line.portName, line.data) {
+			err := stream.Send(outputLineResponse(line))
 			if err != nil {
 				fmt.Println(err)
 				s.Manager.stopReadingFromController(request.ControllerPortName)
@@ -100,28 +180,88 @@ func (s *GrpcServer) ReadControllerOutputContinuously(request *proto.ReadControl
 	return nil
 }
 
+// resumeControllerOutput streams the kept lines from the requested sequence on, followed by the new ones.
+// Unlike reading continuously without resuming, the output is only observed, not taken away from others
+func (s *GrpcServer) resumeControllerOutput(request *proto.ReadControllerOutputRequest, filter *outputFilter, stream proto.NervoService_ReadControllerOutputContinuouslyServer) error {
+	lines, history, missed, err := s.Manager.resumeController(request.ControllerPortName, request.FromSequence)
+	if err != nil {
+		return toStatusError(err)
+	}
+	defer s.Manager.stopObservingController(request.ControllerPortName, lines)
+
+	var lastSent uint64
+	send := func(line outputLine) error {
+		if line.sequence <= lastSent {
+			// already sent from the history
+			return nil
+		}
+		gap := uint64(0)
+		if lastSent == 0 {
+			gap = missed
+		} else if line.sequence > lastSent+1 {
+			// the observer dropped lines because the client was too slow
+			gap = line.sequence - lastSent - 1
+		}
+		if gap > 0 {
+			if err := stream.Send(&proto.ReadControllerOutputResponse{
+				Sequence: line.sequence - gap,
+				Gap:      true,
+				Missed:   gap,
+			}); err != nil {
+				return err
+			}
+		}
+		lastSent = line.sequence
+		if !filter.passes(line.portName, line.data) {
+			return nil
+		}
+		return stream.Send(outputLineResponse(line))
+	}
+
+	for _, line := range history {
+		if err := send(line); err != nil {
+			return err
+		}
+	}
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				return nil
+			}
+			if err := send(line); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+func outputLineResponse(line outputLine) *proto.ReadControllerOutputResponse {
+	return &proto.ReadControllerOutputResponse{
+		Output:       string(line.data),
+		Sequence:     line.sequence,
+		TimeUnixNano: line.time.UnixNano(),
+	}
+}
+
 // SetControllerName for the grpc NervoService
 func (s *GrpcServer) SetControllerName(_ context.Context, request *proto.ControllerInfo) (*proto.ControllerListResponse, error) {
-	s.Manager.setControllerName(request.PortName, request.Name)
-
-	controllerInfos := s.Manager.listControllers()
-	infos := []*proto.ControllerInfo{}
-	for _, info := range controllerInfos {
-		infos = append(infos, &proto.ControllerInfo{
-			PortName: info.portName,
-			Name:     info.name,
-		})
+	if err := s.Manager.setControllerName(request.PortName, request.Name); err != nil {
+		return nil, toStatusError(err)
 	}
 
-	return &proto.ControllerListResponse{ControllerInfos: infos}, nil
+	return s.controllerList(), nil
 }
 
 // ResetUsb for the grpc NervoService
-func (s *GrpcServer) ResetUsb(context.Context, *proto.ResetUsbRequest) (*proto.ResetUsbResponse, error) {
+func (s *GrpcServer) ResetUsb(ctx context.Context, _ *proto.ResetUsbRequest) (*proto.ResetUsbResponse, error) {
+	log.Println(clientIdentity(ctx), "resets usb")
 	output, err := resetUsb()
 	if err != nil {
 		fmt.Println("resetting failed", err)
-		return nil, err
+		return nil, toStatusError(err)
 	}
 
 	return &proto.ResetUsbResponse{
@@ -132,10 +272,14 @@ func (s *GrpcServer) ResetUsb(context.Context, *proto.ResetUsbRequest) (*proto.R
 // WriteToController for the grpc NervoService
 func (s *GrpcServer) WriteToController(_ context.Context, request *proto.WriteToControllerRequest) (*proto.WriteToControllerResponse, error) {
 	err := s.Manager.writeToController(request.ControllerPortName, request.Message)
-	return &proto.WriteToControllerResponse{}, err
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &proto.WriteToControllerResponse{}, nil
 }
 
-// WriteToControllerContinuously for the grpc NervoService
+// WriteToControllerContinuously for the grpc NervoService. The stream is bound to the port of the first message,
+// the ports of later messages are ignored. WriteToControllers writes to several controllers
 func (s *GrpcServer) WriteToControllerContinuously(stream proto.NervoService_WriteToControllerContinuouslyServer) error {
 	firstMessage, err := stream.Recv()
 	if err != nil {
@@ -144,31 +288,50 @@ func (s *GrpcServer) WriteToControllerContinuously(stream proto.NervoService_Wri
 	writeChan := make(chan []byte)
 	answer := s.Manager.writeToControllerContinuously(firstMessage.ControllerPortName, writeChan)
 	if answer.err != nil {
-		return answer.err
+		return toStatusError(answer.err)
 	}
 
-	receivedChan := make(chan []byte)
-	doneReceivingChan := make(chan error)
+	receivedChan := make(chan []byte, 1)
+	receivedChan <- firstMessage.Message
 	go func() {
-		var err error
+		defer close(receivedChan)
 		for {
 			message, err := stream.Recv()
 			if err != nil {
-				fmt.Println(err)
-				break
+				if err != io.EOF {
+					fmt.Println(err)
+				}
+				return
+			}
+			select {
+			case receivedChan <- message.Message:
+			case <-stream.Context().Done():
+				return
 			}
-			receivedChan <- message.Message
 		}
-		close(receivedChan)
-		doneReceivingChan <- err
 	}()
 
 	for {
 		select {
-		case message := <-receivedChan:
-			writeChan <- message
-			break
+		case message, ok := <-receivedChan:
+			if !ok {
+				close(writeChan)
+				if err := <-answer.doneChan; err != nil {
+					return toStatusError(err)
+				}
+				return stream.SendAndClose(&proto.WriteToControllerResponse{})
+			}
+			select {
+			case writeChan <- message:
+			case err := <-answer.doneChan:
+				return toStatusError(err)
+			}
+		case err := <-answer.doneChan:
+			return toStatusError(err)
 		case <-stream.Context().Done():
+			close(writeChan)
+			<-answer.doneChan
+			return stream.Context().Err()
 		case message := <-answer.stopChan:
 			close(writeChan)
 			err := <-answer.doneChan
@@ -177,3 +340,28 @@ func (s *GrpcServer) WriteToControllerContinuously(stream proto.NervoService_Wri
 		}
 	}
 }
+
+// ExplainDiscovery for the grpc NervoService
+func (s *GrpcServer) ExplainDiscovery(context.Context, *proto.ExplainDiscoveryRequest) (*proto.ExplainDiscoveryResponse, error) {
+	candidates, err := s.Manager.explainDiscovery()
+	if err == ErrDiscoveryNotExplainable {
+		return nil, status.Errorf(codes.Unimplemented, "the discovery backend %s can't explain its decisions", s.Manager.discoverer.Name())
+	}
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	response := &proto.ExplainDiscoveryResponse{}
+	for _, candidate := range candidates {
+		response.Candidates = append(response.Candidates, &proto.DiscoveryCandidate{
+			PortName:   candidate.PortName,
+			DevicePath: candidate.DevicePath,
+			ByIdPath:   candidate.ByIDPath,
+			VendorId:   candidate.VendorID,
+			ProductId:  candidate.ProductID,
+			Accepted:   candidate.Accepted,
+			Reason:     candidate.Reason,
+		})
+	}
+	return response, nil
+}
@@ -0,0 +1,426 @@
+package nervo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codeuniversity/nervo/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func Test_GrpcServer_ListControllers(t *testing.T) {
+	h := newTestHarness(t)
+	defer h.close()
+
+	h.attach("/dev/ttyACM0", "leg1")
+	h.attach("/dev/ttyACM1", "leg2")
+
+	response, err := h.client.ListControllers(context.Background(), &proto.ControllerListRequest{})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []*proto.ControllerInfo{
+		{PortName: "/dev/ttyACM0", Name: "leg1", State: "connected", StableId: "/dev/ttyACM0"},
+		{PortName: "/dev/ttyACM1", Name: "leg2", State: "connected", StableId: "/dev/ttyACM1"},
+	}, response.ControllerInfos)
+}
+
+func Test_GrpcServer_ReadControllerOutput(t *testing.T) {
+	h := newTestHarness(t)
+	defer h.close()
+
+	device := h.attach("/dev/ttyACM0", "leg1")
+	device.send("hello")
+	device.send("world")
+
+	output := ""
+	require.Eventually(t, func() bool {
+		response, err := h.client.ReadControllerOutput(context.Background(), &proto.ReadControllerOutputRequest{
+			ControllerPortName: "/dev/ttyACM0",
+		})
+		if err != nil {
+			return false
+		}
+		output += response.Output
+		return output == "hello\nworld\n"
+	}, testWaitTimeout, testTick)
+
+	response, err := h.client.ReadControllerOutput(context.Background(), &proto.ReadControllerOutputRequest{
+		ControllerPortName: "/dev/ttyACM0",
+	})
+	require.NoError(t, err)
+	assert.Empty(t, response.Output, "reading drains the buffer")
+}
+
+func Test_GrpcServer_ReadControllerOutputContinuously(t *testing.T) {
+	h := newTestHarness(t)
+	defer h.close()
+
+	device := h.attach("/dev/ttyACM0", "leg1")
+	device.send("before streaming")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := h.client.ReadControllerOutputContinuously(ctx, &proto.ReadControllerOutputRequest{
+		ControllerPortName: "/dev/ttyACM0",
+	})
+	require.NoError(t, err)
+
+	device.send("while streaming 1")
+	device.send("while streaming 2")
+
+	output := ""
+	for !strings.Contains(output, "while streaming 2\n") {
+		response, err := stream.Recv()
+		require.NoError(t, err)
+		output += response.Output
+	}
+	assert.Equal(t, "before streaming\nwhile streaming 1\nwhile streaming 2\n", output)
+}
+
+func Test_GrpcServer_ReadControllerOutputContinuously_Resume(t *testing.T) {
+	h := newTestHarness(t)
+	defer h.close()
+
+	device := h.attach("/dev/ttyACM0", "leg1")
+	for i := 1; i <= 3; i++ {
+		device.send(fmt.Sprintf("line %d", i))
+	}
+	waitForSequence := func(sequence uint64) {
+		ctx, cancel := context.WithTimeout(context.Background(), testWaitTimeout)
+		defer cancel()
+		stream, err := h.client.ReadControllerOutputContinuously(ctx, &proto.ReadControllerOutputRequest{
+			ControllerPortName: "/dev/ttyACM0",
+			Resume:             true,
+			FromSequence:       sequence,
+		})
+		require.NoError(t, err)
+		for {
+			response, err := stream.Recv()
+			require.NoError(t, err)
+			if response.Sequence >= sequence {
+				return
+			}
+		}
+	}
+	waitForSequence(3)
+
+	t.Run("continues at the sequence without draining the buffer", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		stream, err := h.client.ReadControllerOutputContinuously(ctx, &proto.ReadControllerOutputRequest{
+			ControllerPortName: "/dev/ttyACM0",
+			Resume:             true,
+			FromSequence:       2,
+		})
+		require.NoError(t, err)
+
+		for _, expected := range []string{"line 2\n", "line 3\n"} {
+			response, err := stream.Recv()
+			require.NoError(t, err)
+			assert.Equal(t, expected, response.Output)
+			assert.NotZero(t, response.TimeUnixNano)
+		}
+		device.send("line 4")
+		response, err := stream.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, "line 4\n", response.Output)
+		assert.Equal(t, uint64(4), response.Sequence)
+
+		output, err := h.manager.readFromController("/dev/ttyACM0")
+		require.NoError(t, err)
+		assert.Contains(t, output, "line 1\n")
+	})
+
+	t.Run("marks evicted lines as a gap", func(t *testing.T) {
+		for i := 5; i <= outputHistoryLength+10; i++ {
+			device.send(fmt.Sprintf("line %d", i))
+		}
+		waitForSequence(outputHistoryLength + 10)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		stream, err := h.client.ReadControllerOutputContinuously(ctx, &proto.ReadControllerOutputRequest{
+			ControllerPortName: "/dev/ttyACM0",
+			Resume:             true,
+			FromSequence:       1,
+		})
+		require.NoError(t, err)
+
+		response, err := stream.Recv()
+		require.NoError(t, err)
+		assert.True(t, response.Gap)
+		assert.Equal(t, uint64(1), response.Sequence)
+		assert.Equal(t, uint64(10), response.Missed)
+
+		response, err = stream.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, uint64(11), response.Sequence)
+		assert.Equal(t, "line 11\n", response.Output)
+	})
+}
+
+func Test_GrpcServer_WriteToController(t *testing.T) {
+	h := newTestHarness(t)
+	defer h.close()
+
+	device := h.attach("/dev/ttyACM0", "leg1")
+
+	_, err := h.client.WriteToController(context.Background(), &proto.WriteToControllerRequest{
+		ControllerPortName: "/dev/ttyACM0",
+		Message:            []byte("step 1\n"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "step 1\n", device.receive(t))
+}
+
+func Test_GrpcServer_WriteToControllerContinuously(t *testing.T) {
+	h := newTestHarness(t)
+	defer h.close()
+
+	device := h.attach("/dev/ttyACM0", "leg1")
+
+	stream, err := h.client.WriteToControllerContinuously(context.Background())
+	require.NoError(t, err)
+	for _, message := range []string{"step 1\n", "step 2\n", "step 3\n"} {
+		require.NoError(t, stream.Send(&proto.WriteToControllerRequest{
+			ControllerPortName: "/dev/ttyACM0",
+			Message:            []byte(message),
+		}))
+	}
+
+	assert.Equal(t, "step 1\n", device.receive(t))
+	assert.Equal(t, "step 2\n", device.receive(t))
+	assert.Equal(t, "step 3\n", device.receive(t))
+
+	_, err = stream.CloseAndRecv()
+	assert.NoError(t, err)
+
+	t.Run("a following stream takes over", func(t *testing.T) {
+		stream, err := h.client.WriteToControllerContinuously(context.Background())
+		require.NoError(t, err)
+		require.NoError(t, stream.Send(&proto.WriteToControllerRequest{
+			ControllerPortName: "/dev/ttyACM0",
+			Message:            []byte("step 4\n"),
+		}))
+		assert.Equal(t, "step 4\n", device.receive(t))
+		_, err = stream.CloseAndRecv()
+		assert.NoError(t, err)
+	})
+
+	t.Run("the ports of later messages are ignored", func(t *testing.T) {
+		h.attach("/dev/ttyACM1", "leg2")
+
+		stream, err := h.client.WriteToControllerContinuously(context.Background())
+		require.NoError(t, err)
+		require.NoError(t, stream.Send(&proto.WriteToControllerRequest{
+			ControllerPortName: "/dev/ttyACM0",
+			Message:            []byte("step 5\n"),
+		}))
+		assert.Equal(t, "step 5\n", device.receive(t))
+		require.NoError(t, stream.Send(&proto.WriteToControllerRequest{
+			ControllerPortName: "/dev/ttyACM1",
+			Message:            []byte("step 6\n"),
+		}))
+		assert.Equal(t, "step 6\n", device.receive(t))
+		_, err = stream.CloseAndRecv()
+		assert.NoError(t, err)
+	})
+}
+
+func Test_GrpcServer_FlashController(t *testing.T) {
+	h := newTestHarness(t)
+	defer h.close()
+
+	device := h.attach("/dev/ttyACM0", "leg1")
+	hexFile := []byte(":040000000C94340028\n:00000001FF\n")
+
+	response, err := h.client.FlashController(context.Background(), &proto.FlashControllerRequest{
+		ControllerPortName: "/dev/ttyACM0",
+		HexFileContent:     hexFile,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "fake flash done", response.Output)
+	assert.Equal(t, [][]byte{hexFile}, h.flasher.flashedImages("/dev/ttyACM0"))
+
+	t.Run("the controller is read from again afterwards", func(t *testing.T) {
+		device.send("announce leg1")
+		device.send("after flashing")
+
+		output := ""
+		require.Eventually(t, func() bool {
+			response, err := h.client.ReadControllerOutput(context.Background(), &proto.ReadControllerOutputRequest{
+				ControllerPortName: "/dev/ttyACM0",
+			})
+			if err != nil {
+				return false
+			}
+			output += response.Output
+			return strings.Contains(output, "after flashing\n")
+		}, testWaitTimeout, testTick)
+	})
+	t.Run("raw images are converted to hex files", func(t *testing.T) {
+		_, err := h.client.FlashController(context.Background(), &proto.FlashControllerRequest{
+			ControllerPortName: "/dev/ttyACM0",
+			HexFileContent:     []byte{0x0C, 0x94, 0x34, 0x00},
+			Format:             proto.FirmwareFormat_BIN,
+			BaseAddress:        0x7E00,
+		})
+		require.NoError(t, err)
+		images := h.flasher.flashedImages("/dev/ttyACM0")
+		assert.Equal(t, ":047E00000C943400AA\n:00000001FF\n", string(images[len(images)-1]))
+	})
+	t.Run("unknown formats are rejected", func(t *testing.T) {
+		_, err := h.client.FlashController(context.Background(), &proto.FlashControllerRequest{
+			ControllerPortName: "/dev/ttyACM0",
+			HexFileContent:     []byte{0x0C, 0x94, 0x34, 0x00},
+			Format:             proto.FirmwareFormat(42),
+		})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func Test_GrpcServer_DiscoveryChurn(t *testing.T) {
+	h := newTestHarness(t)
+	defer h.close()
+
+	h.attach("/dev/ttyACM0", "leg1")
+	h.attach("/dev/ttyACM1", "leg2")
+
+	listedPorts := func() []string {
+		response, err := h.client.ListControllers(context.Background(), &proto.ControllerListRequest{})
+		require.NoError(t, err)
+		ports := []string{}
+		for _, info := range response.ControllerInfos {
+			ports = append(ports, info.PortName)
+		}
+		return ports
+	}
+
+	h.detach("/dev/ttyACM0")
+	assert.Equal(t, []string{"/dev/ttyACM1"}, listedPorts())
+
+	_, err := h.client.WriteToController(context.Background(), &proto.WriteToControllerRequest{
+		ControllerPortName: "/dev/ttyACM0",
+		Message:            []byte("step 1\n"),
+	})
+	assert.Equal(t, codes.NotFound, status.Code(err), "writing to a detached controller fails")
+
+	device := h.attach("/dev/ttyACM0", "leg1")
+	assert.ElementsMatch(t, []string{"/dev/ttyACM0", "/dev/ttyACM1"}, listedPorts())
+
+	_, err = h.client.WriteToController(context.Background(), &proto.WriteToControllerRequest{
+		ControllerPortName: "/dev/ttyACM0",
+		Message:            []byte("step 1\n"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "step 1\n", device.receive(t))
+}
+
+func Test_GrpcServer_ReconnectWithoutDiscovery(t *testing.T) {
+	h := newTestHarness(t)
+	defer h.close()
+
+	device := h.attach("/dev/ttyACM0", "leg1")
+	(<-device.transports).Close()
+
+	select {
+	case <-device.transports:
+	case <-time.After(testWaitTimeout):
+		t.Fatal("the errored controller wasn't reconnected")
+	}
+	require.Eventually(t, func() bool {
+		return h.manager.listControllers()[0].state == controllerStateConnected
+	}, testWaitTimeout, testTick)
+
+	_, err := h.client.WriteToController(context.Background(), &proto.WriteToControllerRequest{
+		ControllerPortName: "/dev/ttyACM0",
+		Message:            []byte("step 1\n"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "step 1\n", device.receive(t))
+}
+
+func Test_GrpcServer_StatusErrors(t *testing.T) {
+	h := newTestHarness(t)
+	defer h.close()
+
+	h.attach("/dev/ttyACM0", "leg1")
+
+	t.Run("reading from an unknown port", func(t *testing.T) {
+		_, err := h.client.ReadControllerOutput(context.Background(), &proto.ReadControllerOutputRequest{
+			ControllerPortName: "/dev/ttyACM9",
+		})
+		s := status.Convert(err)
+		require.Equal(t, codes.NotFound, s.Code())
+		require.Len(t, s.Details(), 1)
+		assert.Equal(t, "/dev/ttyACM9", s.Details()[0].(*errdetails.ResourceInfo).ResourceName)
+	})
+
+	t.Run("renaming an unknown port", func(t *testing.T) {
+		_, err := h.client.SetControllerName(context.Background(), &proto.ControllerInfo{PortName: "/dev/ttyACM9", Name: "leg9"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("explaining a discovery backend that can't", func(t *testing.T) {
+		_, err := h.client.ExplainDiscovery(context.Background(), &proto.ExplainDiscoveryRequest{})
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+	})
+
+	t.Run("flashing a too large hex file", func(t *testing.T) {
+		_, err := h.client.FlashController(context.Background(), &proto.FlashControllerRequest{
+			ControllerPortName: "/dev/ttyACM0",
+			HexFileContent:     make([]byte, maxHexFileSize+1),
+		})
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	})
+
+	t.Run("flashing a malformed hex file", func(t *testing.T) {
+		_, err := h.client.FlashController(context.Background(), &proto.FlashControllerRequest{
+			ControllerPortName: "/dev/ttyACM0",
+			HexFileContent:     []byte(":040000000C94340029\n:00000001FF\n"),
+		})
+		s := status.Convert(err)
+		require.Equal(t, codes.InvalidArgument, s.Code())
+		require.Len(t, s.Details(), 1)
+		assert.Equal(t, "invalid hex file, line 1: checksum is 29, expected 28", s.Details()[0].(*errdetails.BadRequest).FieldViolations[0].Description)
+		assert.Empty(t, h.flasher.flashedImages("/dev/ttyACM0"))
+	})
+
+	t.Run("a failing flasher", func(t *testing.T) {
+		h.flasher.mutex.Lock()
+		h.flasher.output = "avrdude: stk500_recv(): programmer is not responding"
+		h.flasher.err = errors.New("exit status 1")
+		h.flasher.mutex.Unlock()
+
+		_, err := h.client.FlashController(context.Background(), &proto.FlashControllerRequest{
+			ControllerPortName: "/dev/ttyACM0",
+			HexFileContent:     []byte(":040000000C94340028\n:00000001FF\n"),
+		})
+		s := status.Convert(err)
+		require.Equal(t, codes.Aborted, s.Code())
+		require.Len(t, s.Details(), 2)
+		assert.Equal(t, "avrdude: stk500_recv(): programmer is not responding", s.Details()[0].(*errdetails.DebugInfo).Detail)
+		assert.Equal(t, proto.FlashReport_NOT_IN_SYNC, s.Details()[1].(*proto.FlashReport).Failure)
+	})
+}
+
+func Test_GrpcServer_GetServerInfo(t *testing.T) {
+	h := newTestHarness(t)
+	defer h.close()
+
+	info, err := h.client.GetServerInfo(context.Background(), &proto.GetServerInfoRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, Version, info.Version)
+	assert.Equal(t, "fake", info.DiscoveryBackend)
+	assert.NotEmpty(t, info.Os)
+	assert.NotEmpty(t, info.GoVersion)
+	assert.False(t, info.MhistConnected)
+	assert.Empty(t, info.Features)
+}
@@ -0,0 +1,125 @@
+package nervo
+
+import (
+	"io"
+	"log"
+	"time"
+
+	"github.com/codeuniversity/nervo/proto"
+
+	"google.golang.org/grpc/status"
+)
+
+// Session for the grpc NervoService. The client writes and receives the output of one controller on the same stream.
+// Output is observed, so it isn't taken away from other readers. After the client half-closes, the server answers with CLOSED and ends the stream
+func (s *GrpcServer) Session(stream proto.NervoService_SessionServer) error {
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	portName := first.ControllerPortName
+	log.Println(clientIdentity(stream.Context()), "opens a session with", portName)
+
+	lines, err := s.Manager.observeController(portName)
+	if err != nil {
+		return toStatusError(err)
+	}
+	defer s.Manager.stopObservingController(portName, lines)
+
+	if err := stream.Send(&proto.SessionEvent{
+		Type:               proto.SessionEvent_OPENED,
+		ControllerPortName: portName,
+		TimeUnixNano:       time.Now().UnixNano(),
+	}); err != nil {
+		return err
+	}
+
+	requests := make(chan *proto.SessionRequest, 1)
+	requests <- first
+	receiveErrChan := make(chan error, 1)
+	go func() {
+		defer close(requests)
+		for {
+			request, err := stream.Recv()
+			if err != nil {
+				receiveErrChan <- err
+				return
+			}
+			select {
+			case requests <- request:
+			case <-stream.Context().Done():
+				receiveErrChan <- stream.Context().Err()
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				return stream.Send(&proto.SessionEvent{
+					Type:               proto.SessionEvent_CLOSED,
+					ControllerPortName: portName,
+					TimeUnixNano:       time.Now().UnixNano(),
+					Reason:             "the controller was detached",
+				})
+			}
+			if err := stream.Send(&proto.SessionEvent{
+				Type:               proto.SessionEvent_OUTPUT,
+				ControllerPortName: portName,
+				Data:               line.data,
+				TimeUnixNano:       line.time.UnixNano(),
+			}); err != nil {
+				return err
+			}
+		case request, ok := <-requests:
+			if !ok {
+				if err := <-receiveErrChan; err != io.EOF {
+					return err
+				}
+				return stream.Send(&proto.SessionEvent{
+					Type:               proto.SessionEvent_CLOSED,
+					ControllerPortName: portName,
+					TimeUnixNano:       time.Now().UnixNano(),
+					Reason:             "the client closed the session",
+				})
+			}
+			event := s.writeInSession(portName, request.Message, first.Echo)
+			if event == nil {
+				continue
+			}
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+// writeInSession writes message and returns the event telling the client about it, if there is one
+func (s *GrpcServer) writeInSession(portName string, message []byte, echo bool) *proto.SessionEvent {
+	if len(message) == 0 {
+		return nil
+	}
+
+	if err := s.Manager.writeToController(portName, message); err != nil {
+		return &proto.SessionEvent{
+			Type:               proto.SessionEvent_ERROR,
+			ControllerPortName: portName,
+			Data:               message,
+			TimeUnixNano:       time.Now().UnixNano(),
+			Reason:             status.Convert(toStatusError(err)).Message(),
+		}
+	}
+	if !echo {
+		return nil
+	}
+	return &proto.SessionEvent{
+		Type:               proto.SessionEvent_ECHO,
+		ControllerPortName: portName,
+		Data:               message,
+		TimeUnixNano:       time.Now().UnixNano(),
+	}
+}
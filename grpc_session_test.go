@@ -0,0 +1,71 @@
+package nervo
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/codeuniversity/nervo/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func Test_GrpcServer_Session(t *testing.T) {
+	h := newTestHarness(t)
+	defer h.close()
+
+	device := h.attach("/dev/ttyACM0", "leg1")
+
+	stream, err := h.client.Session(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&proto.SessionRequest{ControllerPortName: "/dev/ttyACM0", Echo: true}))
+
+	event, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, proto.SessionEvent_OPENED, event.Type)
+
+	t.Run("receives output", func(t *testing.T) {
+		device.send("sensor_data 12")
+
+		event, err := stream.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, proto.SessionEvent_OUTPUT, event.Type)
+		assert.Equal(t, "sensor_data 12\n", string(event.Data))
+		assert.NotZero(t, event.TimeUnixNano)
+	})
+
+	t.Run("writes and echoes", func(t *testing.T) {
+		require.NoError(t, stream.Send(&proto.SessionRequest{Message: []byte("step 1\n")}))
+		assert.Equal(t, "step 1\n", device.receive(t))
+
+		event, err := stream.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, proto.SessionEvent_ECHO, event.Type)
+		assert.Equal(t, "step 1\n", string(event.Data))
+	})
+
+	t.Run("half-closing ends the session cleanly", func(t *testing.T) {
+		require.NoError(t, stream.CloseSend())
+
+		event, err := stream.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, proto.SessionEvent_CLOSED, event.Type)
+
+		_, err = stream.Recv()
+		assert.Equal(t, io.EOF, err)
+	})
+}
+
+func Test_GrpcServer_Session_UnknownPort(t *testing.T) {
+	h := newTestHarness(t)
+	defer h.close()
+
+	stream, err := h.client.Session(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&proto.SessionRequest{ControllerPortName: "/dev/ttyACM9"}))
+
+	_, err = stream.Recv()
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
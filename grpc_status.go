@@ -0,0 +1,90 @@
+package nervo
+
+import (
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// retryUnavailableControllerAfter is a bit longer than the reconnectInterval of the manager
+const retryUnavailableControllerAfter = time.Second * 2
+
+// toStatusError translates errors of the manager into grpc status errors with details the client can act on
+func toStatusError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	switch e := err.(type) {
+	case *ControllerNotFoundError:
+		return statusWithDetails(codes.NotFound, err, &errdetails.ResourceInfo{
+			ResourceType: "controller",
+			ResourceName: e.PortName,
+			Description:  "no controller is attached at this port",
+		})
+	case *ArtifactNotFoundError:
+		return statusWithDetails(codes.NotFound, err, &errdetails.ResourceInfo{
+			ResourceType: "artifact",
+			ResourceName: e.Reference,
+			Description:  "no artifact has this id, or this tag or version for its name",
+		})
+	case *ArtifactExistsError:
+		return statusWithDetails(codes.AlreadyExists, err, &errdetails.ResourceInfo{
+			ResourceType: "artifact",
+			ResourceName: e.ID,
+			Description:  "artifacts are identified by the sha256 of their content",
+		})
+	case *ControllerUnavailableError:
+		return statusWithDetails(codes.Unavailable, err, &errdetails.RetryInfo{
+			RetryDelay: ptypes.DurationProto(retryUnavailableControllerAfter),
+		})
+	case *UnsupportedError:
+		return statusWithDetails(codes.FailedPrecondition, err, &errdetails.PreconditionFailure{
+			Violations: []*errdetails.PreconditionFailure_Violation{
+				{Type: "UNSUPPORTED", Subject: e.PortName, Description: e.Reason},
+			},
+		})
+	case *ResourceExhaustedError:
+		return statusWithDetails(codes.ResourceExhausted, err, &errdetails.QuotaFailure{
+			Violations: []*errdetails.QuotaFailure_Violation{
+				{Subject: e.Resource, Description: err.Error()},
+			},
+		})
+	case *HexFileError:
+		return statusWithDetails(codes.InvalidArgument, err, &errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{
+				{Field: "hex_file_content", Description: err.Error()},
+			},
+		})
+	case *FirmwareFormatError:
+		return statusWithDetails(codes.InvalidArgument, err, &errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{
+				{Field: "format", Description: err.Error()},
+			},
+		})
+	case *FlashError:
+		return statusWithDetails(codes.Aborted, err, &errdetails.DebugInfo{Detail: e.Output})
+	case *CommandError:
+		return statusWithDetails(codes.Internal, err, &errdetails.DebugInfo{Detail: e.Output})
+	}
+
+	if err == ErrTimeoutReached {
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	return status.Error(codes.Unknown, err.Error())
+}
+
+func statusWithDetails(code codes.Code, err error, details ...proto.Message) error {
+	s, detailsErr := status.New(code, err.Error()).WithDetails(details...)
+	if detailsErr != nil {
+		return status.Error(code, err.Error())
+	}
+	return s.Err()
+}
@@ -0,0 +1,301 @@
+package nervo
+
+import (
+	"context"
+	"io"
+	"net"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/codeuniversity/nervo/proto"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const (
+	testWaitTimeout = time.Second * 3
+	testTick        = time.Millisecond * 10
+)
+
+// testHarness runs a Manager and GrpcServer against in memory controllers instead of real serial ports
+type testHarness struct {
+	t          *testing.T
+	manager    *Manager
+	server     *GrpcServer
+	client     proto.NervoServiceClient
+	flasher    *fakeFlasher
+	discoverer *fakeDiscoverer
+
+	devicesMutex *sync.Mutex
+	devices      map[string]*testDevice
+	cleanup      func()
+}
+
+func newTestHarness(t *testing.T) *testHarness {
+	return newConfiguredTestHarness(t, func(*GrpcServer) {})
+}
+
+// newConfiguredTestHarness lets configure change the GrpcServer before it starts serving and adds dialOptions to the client
+func newConfiguredTestHarness(t *testing.T, configure func(*GrpcServer), dialOptions ...grpc.DialOption) *testHarness {
+	h := &testHarness{
+		t:            t,
+		flasher:      &fakeFlasher{mutex: &sync.Mutex{}, flashed: map[string][][]byte{}, output: "fake flash done"},
+		discoverer:   &fakeDiscoverer{portsChan: make(chan []string)},
+		devicesMutex: &sync.Mutex{},
+		devices:      map[string]*testDevice{},
+	}
+
+	h.manager = NewManager(ManagerConfig{
+		Discoverer:    h.discoverer,
+		OpenTransport: h.openTransport,
+		Flasher:       h.flasher,
+	})
+
+	listener := bufconn.Listen(1 << 20)
+	h.server = NewGrpcServer(h.manager, 0)
+	configure(h.server)
+	grpcServer := h.server.newGrpcServer()
+	go grpcServer.Serve(listener)
+
+	dialOptions = append(dialOptions,
+		grpc.WithInsecure(),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return listener.Dial()
+		}),
+	)
+	conn, err := grpc.Dial("bufconn", dialOptions...)
+	require.NoError(t, err)
+	h.client = proto.NewNervoServiceClient(conn)
+
+	h.cleanup = func() {
+		conn.Close()
+		grpcServer.Stop()
+	}
+	return h
+}
+
+func (h *testHarness) close() {
+	h.cleanup()
+}
+
+// attach plugs in a controller that announces itself with name and waits until the manager knows it
+func (h *testHarness) attach(portName, name string) *testDevice {
+	device := &testDevice{
+		portName:   portName,
+		lines:      make(chan []byte, 100),
+		written:    make(chan []byte, 100),
+		transports: make(chan *memTransport, 100),
+	}
+	h.devicesMutex.Lock()
+	h.devices[portName] = device
+	h.devicesMutex.Unlock()
+
+	device.send("announce " + name)
+	h.reportPorts()
+	h.waitForController(portName, name)
+	return device
+}
+
+// detach unplugs the controller at portName and waits until the manager noticed
+func (h *testHarness) detach(portName string) {
+	h.devicesMutex.Lock()
+	delete(h.devices, portName)
+	h.devicesMutex.Unlock()
+
+	h.reportPorts()
+	require.Eventually(h.t, func() bool {
+		for _, info := range h.manager.listControllers() {
+			if info.portName == portName {
+				return false
+			}
+		}
+		return true
+	}, testWaitTimeout, testTick)
+}
+
+func (h *testHarness) reportPorts() {
+	h.devicesMutex.Lock()
+	ports := []string{}
+	for port := range h.devices {
+		ports = append(ports, port)
+	}
+	h.devicesMutex.Unlock()
+
+	sort.Strings(ports)
+	h.discoverer.portsChan <- ports
+}
+
+func (h *testHarness) waitForController(portName, name string) {
+	require.Eventually(h.t, func() bool {
+		for _, info := range h.manager.listControllers() {
+			if info.portName == portName && info.name == name {
+				return true
+			}
+		}
+		return false
+	}, testWaitTimeout, testTick)
+}
+
+// waitForObserversOfAllControllers waits until count streams observe all controllers, so no line sent afterwards is missed
+func (h *testHarness) waitForObserversOfAllControllers(count int) {
+	require.Eventually(h.t, func() bool {
+		observers := h.manager.allObservers
+		observers.mutex.Lock()
+		defer observers.mutex.Unlock()
+		return len(observers.observers) == count
+	}, testWaitTimeout, testTick)
+}
+
+func (h *testHarness) openTransport(portName string) (Transport, error) {
+	h.devicesMutex.Lock()
+	defer h.devicesMutex.Unlock()
+
+	device, ok := h.devices[portName]
+	if !ok {
+		return nil, io.ErrClosedPipe
+	}
+	return device.open(), nil
+}
+
+// testDevice is the controller side of an in memory transport. It survives reopening the transport, e.g. when flashing
+type testDevice struct {
+	portName string
+	lines    chan []byte
+	written  chan []byte
+	// transports receives every transport nervo opened, so tests can break the connection
+	transports chan *memTransport
+}
+
+func (d *testDevice) open() *memTransport {
+	transport := &memTransport{
+		device:    d,
+		closed:    make(chan struct{}),
+		closeOnce: &sync.Once{},
+	}
+	d.transports <- transport
+	return transport
+}
+
+// send writes a line from the controller to nervo
+func (d *testDevice) send(line string) {
+	d.lines <- []byte(line + "\n")
+}
+
+// receive waits for the next message written by nervo
+func (d *testDevice) receive(t *testing.T) string {
+	select {
+	case message := <-d.written:
+		return string(message)
+	case <-time.After(testWaitTimeout):
+		t.Fatal("timed out waiting for a message to", d.portName)
+		return ""
+	}
+}
+
+type memTransport struct {
+	device    *testDevice
+	pending   []byte
+	closed    chan struct{}
+	closeOnce *sync.Once
+}
+
+func (t *memTransport) Read(p []byte) (int, error) {
+	if len(t.pending) == 0 {
+		select {
+		case line := <-t.device.lines:
+			t.pending = line
+		case <-t.closed:
+			return 0, io.EOF
+		}
+	}
+
+	n := copy(p, t.pending)
+	t.pending = t.pending[n:]
+	return n, nil
+}
+
+func (t *memTransport) Write(p []byte) (int, error) {
+	message := make([]byte, len(p))
+	copy(message, p)
+	select {
+	case t.device.written <- message:
+		return len(p), nil
+	case <-t.closed:
+		return 0, io.ErrClosedPipe
+	}
+}
+
+func (t *memTransport) Close() error {
+	t.closeOnce.Do(func() {
+		close(t.closed)
+	})
+	return nil
+}
+
+func (t *memTransport) SetControlLines(dtr, rts bool) error {
+	return &UnsupportedError{Reason: "test devices have no control lines"}
+}
+
+type fakeDiscoverer struct {
+	portsChan chan []string
+}
+
+func (d *fakeDiscoverer) Run(portsChan chan<- []string) {
+	for ports := range d.portsChan {
+		portsChan <- ports
+	}
+}
+
+func (d *fakeDiscoverer) Name() string {
+	return "fake"
+}
+
+type fakeFlasher struct {
+	mutex   *sync.Mutex
+	flashed map[string][][]byte
+	output  string
+	err     error
+	// portErrors fail the flashes of single ports
+	portErrors map[string]error
+	// delay makes every flash take a while, maxActive is how many ran at the same time
+	delay     time.Duration
+	active    int
+	maxActive int
+}
+
+func (f *fakeFlasher) Flash(portName string, hexFileContent []byte) (string, error) {
+	f.mutex.Lock()
+	f.active++
+	if f.active > f.maxActive {
+		f.maxActive = f.active
+	}
+	delay := f.delay
+	f.mutex.Unlock()
+
+	time.Sleep(delay)
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.active--
+	f.flashed[portName] = append(f.flashed[portName], hexFileContent)
+	if err, ok := f.portErrors[portName]; ok {
+		return f.output, err
+	}
+	return f.output, f.err
+}
+
+func (f *fakeFlasher) FlashWithProgress(portName string, hexFileContent []byte, progress func(FlashProgress)) (string, error) {
+	progress(FlashProgress{Phase: FlashPhaseWrite, Percent: 50})
+	progress(FlashProgress{Phase: FlashPhaseWrite, Percent: 100})
+	return f.Flash(portName, hexFileContent)
+}
+
+func (f *fakeFlasher) flashedImages(portName string) [][]byte {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.flashed[portName]
+}
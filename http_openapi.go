@@ -0,0 +1,298 @@
+package nervo
+
+// openAPISpec describes the endpoints of the HTTPServer, it is served at /api/openapi.json
+const openAPISpec = `{
+  "openapi": "3.0.3",
+  "info": {
+    "title": "nervo",
+    "description": "Json gateway to the NervoService. Every request needs an 'Authorization: Bearer <token>' header if the server has a tokens file.",
+    "version": "1"
+  },
+  "paths": {
+    "/api/controllers": {
+      "get": {
+        "summary": "List the attached controllers",
+        "operationId": "listControllers",
+        "responses": {
+          "200": {
+            "description": "The attached controllers",
+            "content": { "application/json": { "schema": { "$ref": "#/components/schemas/ControllerList" } } }
+          },
+          "default": { "$ref": "#/components/responses/Error" }
+        }
+      }
+    },
+    "/api/controllers/output": {
+      "get": {
+        "summary": "Read the output a controller buffered since the last read",
+        "operationId": "readControllerOutput",
+        "parameters": [{ "$ref": "#/components/parameters/PortName" }],
+        "responses": {
+          "200": {
+            "description": "The buffered output",
+            "content": { "application/json": { "schema": { "$ref": "#/components/schemas/Output" } } }
+          },
+          "default": { "$ref": "#/components/responses/Error" }
+        }
+      }
+    },
+    "/api/controllers/stream": {
+      "get": {
+        "summary": "Websocket streaming every output line of a controller as StreamEvent. Messages sent as {\"message\": \"...\"} are written to the controller",
+        "operationId": "streamController",
+        "parameters": [
+          { "$ref": "#/components/parameters/PortName" },
+          { "$ref": "#/components/parameters/AccessToken" },
+          { "$ref": "#/components/parameters/Verb" },
+          { "$ref": "#/components/parameters/Include" },
+          { "$ref": "#/components/parameters/Exclude" },
+          { "$ref": "#/components/parameters/SampleEveryNth" }
+        ],
+        "responses": {
+          "101": { "description": "Switching to the websocket protocol" },
+          "default": { "$ref": "#/components/responses/Error" }
+        }
+      }
+    },
+    "/api/controllers/events": {
+      "get": {
+        "summary": "Server-sent events with every output line of a controller as StreamEvent. An end event follows when the controller is detached",
+        "operationId": "controllerEvents",
+        "parameters": [
+          { "$ref": "#/components/parameters/PortName" },
+          { "$ref": "#/components/parameters/AccessToken" },
+          { "$ref": "#/components/parameters/Verb" },
+          { "$ref": "#/components/parameters/Include" },
+          { "$ref": "#/components/parameters/Exclude" },
+          { "$ref": "#/components/parameters/SampleEveryNth" }
+        ],
+        "responses": {
+          "200": {
+            "description": "The event stream",
+            "content": { "text/event-stream": { "schema": { "$ref": "#/components/schemas/StreamEvent" } } }
+          },
+          "default": { "$ref": "#/components/responses/Error" }
+        }
+      }
+    },
+    "/api/controllers/write": {
+      "post": {
+        "summary": "Write a message to a controller",
+        "operationId": "writeToController",
+        "requestBody": {
+          "required": true,
+          "content": { "application/json": { "schema": { "$ref": "#/components/schemas/WriteRequest" } } }
+        },
+        "responses": {
+          "200": {
+            "description": "The message was written",
+            "content": { "application/json": { "schema": { "type": "object" } } }
+          },
+          "default": { "$ref": "#/components/responses/Error" }
+        }
+      }
+    },
+    "/api/controllers/name": {
+      "post": {
+        "summary": "Rename a controller",
+        "operationId": "setControllerName",
+        "requestBody": {
+          "required": true,
+          "content": { "application/json": { "schema": { "$ref": "#/components/schemas/ControllerInfo" } } }
+        },
+        "responses": {
+          "200": {
+            "description": "The attached controllers after renaming",
+            "content": { "application/json": { "schema": { "$ref": "#/components/schemas/ControllerList" } } }
+          },
+          "default": { "$ref": "#/components/responses/Error" }
+        }
+      }
+    },
+    "/api/controllers/flash": {
+      "post": {
+        "summary": "Flash a hex, elf or raw bin file onto a controller, it is converted to a hex file on the server",
+        "operationId": "flashController",
+        "parameters": [
+          { "$ref": "#/components/parameters/PortName" },
+          {
+            "name": "format",
+            "in": "query",
+            "description": "Without a format, hex and elf files are told apart by their content and .bin files are taken as raw images",
+            "schema": { "type": "string", "enum": ["hex", "elf", "bin"] }
+          },
+          {
+            "name": "base_address",
+            "in": "query",
+            "description": "Where a raw image is written to, decimal or 0x prefixed",
+            "schema": { "type": "string" },
+            "example": "0x0"
+          }
+        ],
+        "requestBody": {
+          "required": true,
+          "content": {
+            "multipart/form-data": {
+              "schema": {
+                "type": "object",
+                "required": ["hex_file"],
+                "properties": { "hex_file": { "type": "string", "format": "binary" } }
+              }
+            }
+          }
+        },
+        "responses": {
+          "200": {
+            "description": "The output of the flasher and what was parsed from it",
+            "content": { "application/json": { "schema": { "$ref": "#/components/schemas/FlashOutput" } } }
+          },
+          "default": { "$ref": "#/components/responses/Error" }
+        }
+      }
+    },
+    "/api/usb/reset": {
+      "post": {
+        "summary": "Reset the usb devices of the server",
+        "operationId": "resetUsb",
+        "responses": {
+          "200": {
+            "description": "The output of the reset",
+            "content": { "application/json": { "schema": { "$ref": "#/components/schemas/Output" } } }
+          },
+          "default": { "$ref": "#/components/responses/Error" }
+        }
+      }
+    }
+  },
+  "components": {
+    "parameters": {
+      "PortName": {
+        "name": "port_name",
+        "in": "query",
+        "required": true,
+        "schema": { "type": "string" },
+        "example": "/dev/ttyACM0"
+      },
+      "AccessToken": {
+        "name": "access_token",
+        "in": "query",
+        "description": "The api token, for clients that can't set the Authorization header",
+        "schema": { "type": "string" }
+      },
+      "Verb": {
+        "name": "verb",
+        "in": "query",
+        "description": "Only stream lines starting with one of the verbs",
+        "schema": { "type": "array", "items": { "type": "string" } },
+        "example": ["feedback"]
+      },
+      "Include": {
+        "name": "include",
+        "in": "query",
+        "description": "Regular expressions, only lines matching any of them are streamed",
+        "schema": { "type": "array", "items": { "type": "string" } }
+      },
+      "Exclude": {
+        "name": "exclude",
+        "in": "query",
+        "description": "Regular expressions, lines matching any of them are not streamed",
+        "schema": { "type": "array", "items": { "type": "string" } }
+      },
+      "SampleEveryNth": {
+        "name": "sample_every_nth",
+        "in": "query",
+        "description": "Only stream every nth line that passed the other filters",
+        "schema": { "type": "integer", "minimum": 0 }
+      }
+    },
+    "responses": {
+      "Error": {
+        "description": "The grpc status code of the failure, mapped onto http the way the grpc-gateway does",
+        "content": {
+          "application/json": {
+            "schema": {
+              "type": "object",
+              "properties": { "error": { "$ref": "#/components/schemas/Error" } }
+            }
+          }
+        }
+      }
+    },
+    "securitySchemes": {
+      "token": { "type": "http", "scheme": "bearer" }
+    },
+    "schemas": {
+      "ControllerInfo": {
+        "type": "object",
+        "properties": {
+          "port_name": { "type": "string" },
+          "name": { "type": "string" },
+          "state": { "type": "string", "enum": ["connecting", "connected", "disconnected", "flashing", "finished"], "readOnly": true },
+          "stable_id": { "type": "string", "description": "The /dev/serial/by-id link of the port if there is one", "readOnly": true }
+        }
+      },
+      "ControllerList": {
+        "type": "object",
+        "properties": {
+          "controllers": { "type": "array", "items": { "$ref": "#/components/schemas/ControllerInfo" } }
+        }
+      },
+      "Output": {
+        "type": "object",
+        "properties": { "output": { "type": "string" } }
+      },
+      "FlashOutput": {
+        "type": "object",
+        "properties": {
+          "output": { "type": "string" },
+          "report": {
+            "type": "object",
+            "description": "What avrdude printed about the flash, fields it didn't print are left out or zero",
+            "properties": {
+              "device_signature": { "type": "string", "example": "0x1e950f" },
+              "device": { "type": "string", "example": "m328p" },
+              "bytes_written": { "type": "integer" },
+              "bytes_verified": { "type": "integer" },
+              "verified": { "type": "boolean" },
+              "fuses": { "type": "object", "additionalProperties": { "type": "string" }, "example": { "lfuse": "0xFF" } },
+              "elapsed_seconds": { "type": "number" }
+            }
+          }
+        }
+      },
+      "WriteRequest": {
+        "type": "object",
+        "required": ["port_name", "message"],
+        "properties": {
+          "port_name": { "type": "string" },
+          "message": { "type": "string" }
+        }
+      },
+      "StreamEvent": {
+        "type": "object",
+        "properties": {
+          "type": { "type": "string", "enum": ["line", "error"] },
+          "port_name": { "type": "string" },
+          "time": { "type": "string", "format": "date-time" },
+          "line": { "type": "string" },
+          "error": { "$ref": "#/components/schemas/Error" }
+        }
+      },
+      "Error": {
+        "type": "object",
+        "properties": {
+          "code": { "type": "string", "example": "NotFound" },
+          "message": { "type": "string" },
+          "output": { "type": "string", "description": "What avrdude or the usb reset printed, if they failed" },
+          "failure": {
+            "type": "string",
+            "enum": ["unknown", "not_in_sync", "wrong_signature", "permission_denied", "timeout", "verification_error"],
+            "description": "Why a flash failed, if the flasher ran"
+          }
+        }
+      }
+    }
+  },
+  "security": [{ "token": [] }]
+}
+`
@@ -0,0 +1,412 @@
+package nervo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codeuniversity/nervo/proto"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// HTTPServer exposes the NervoService as json endpoints for clients that can't speak grpc.
+// It uses the Manager, TLSConfig and Authenticator of the GrpcServer it is created for
+type HTTPServer struct {
+	// AllowedOrigins are the pages browsers may open the websocket from, e.g. "https://dashboard.example.com".
+	// If empty, only pages served by the gateway itself may
+	AllowedOrigins []string
+	grpcServer     *GrpcServer
+	httpPort       int
+}
+
+// NewHTTPServer creates an HTTPServer next to the given grpc server
+func NewHTTPServer(s *GrpcServer, httpPort int) *HTTPServer {
+	s.httpGateway = true
+	return &HTTPServer{
+		grpcServer: s,
+		httpPort:   httpPort,
+	}
+}
+
+// Listen blocks, while listening for http requests on the port specified in the HTTPServer struct
+func (s *HTTPServer) Listen() {
+	server := &http.Server{
+		Addr:      fmt.Sprintf(":%v", s.httpPort),
+		Handler:   s.handler(),
+		TLSConfig: s.grpcServer.TLSConfig,
+	}
+
+	var err error
+	if server.TLSConfig != nil {
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
+	log.Fatalf("failed to serve http: %v", err)
+}
+
+// httpRoute maps an http endpoint onto the grpc method it is equivalent to, which also decides the role it needs.
+// Routes either handle a request with a json answer or serve the response themselves, e.g. for streaming
+type httpRoute struct {
+	method     string
+	grpcMethod string
+	handle     func(ctx context.Context, r *http.Request) (interface{}, error)
+	serve      func(ctx context.Context, w http.ResponseWriter, r *http.Request)
+	// maxBodySize limits the request body if it is set
+	maxBodySize int64
+}
+
+type httpControllerInfo struct {
+	PortName string `json:"port_name"`
+	Name     string `json:"name"`
+	State    string `json:"state,omitempty"`
+	StableID string `json:"stable_id,omitempty"`
+}
+
+type httpControllerList struct {
+	Controllers []httpControllerInfo `json:"controllers"`
+}
+
+type httpOutput struct {
+	Output string `json:"output"`
+}
+
+type httpFlashOutput struct {
+	Output string           `json:"output"`
+	Report *httpFlashReport `json:"report,omitempty"`
+}
+
+type httpFlashReport struct {
+	DeviceSignature string            `json:"device_signature,omitempty"`
+	Device          string            `json:"device,omitempty"`
+	BytesWritten    uint64            `json:"bytes_written"`
+	BytesVerified   uint64            `json:"bytes_verified"`
+	Verified        bool              `json:"verified"`
+	Fuses           map[string]string `json:"fuses,omitempty"`
+	ElapsedSeconds  float64           `json:"elapsed_seconds"`
+}
+
+type httpWriteRequest struct {
+	PortName string `json:"port_name"`
+	Message  string `json:"message"`
+}
+
+type httpError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Output  string `json:"output,omitempty"`
+	// Failure classifies failed flashes, e.g. "not_in_sync"
+	Failure string `json:"failure,omitempty"`
+}
+
+func (s *HTTPServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			writeJSON(w, http.StatusNotFound, map[string]httpError{"error": {
+				Code:    codes.NotFound.String(),
+				Message: r.URL.Path + " doesn't exist",
+			}})
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, dashboardHTML)
+	})
+	mux.HandleFunc("/api/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, openAPISpec)
+	})
+
+	mux.Handle("/api/controllers", s.route(httpRoute{
+		method:     http.MethodGet,
+		grpcMethod: "/proto.NervoService/ListControllers",
+		handle: func(ctx context.Context, r *http.Request) (interface{}, error) {
+			response, err := s.grpcServer.ListControllers(ctx, &proto.ControllerListRequest{})
+			if err != nil {
+				return nil, err
+			}
+			return toHTTPControllerList(response), nil
+		},
+	}))
+	mux.Handle("/api/controllers/output", s.route(httpRoute{
+		method:     http.MethodGet,
+		grpcMethod: "/proto.NervoService/ReadControllerOutput",
+		handle: func(ctx context.Context, r *http.Request) (interface{}, error) {
+			response, err := s.grpcServer.ReadControllerOutput(ctx, &proto.ReadControllerOutputRequest{
+				ControllerPortName: r.URL.Query().Get("port_name"),
+			})
+			if err != nil {
+				return nil, err
+			}
+			return httpOutput{Output: response.Output}, nil
+		},
+	}))
+	mux.Handle("/api/controllers/stream", s.route(httpRoute{
+		method:     http.MethodGet,
+		grpcMethod: "/proto.NervoService/ReadControllerOutputContinuously",
+		serve:      s.serveWebSocket,
+	}))
+	mux.Handle("/api/controllers/events", s.route(httpRoute{
+		method:     http.MethodGet,
+		grpcMethod: "/proto.NervoService/ReadControllerOutputContinuously",
+		serve:      s.serveEvents,
+	}))
+	mux.Handle("/api/controllers/write", s.route(httpRoute{
+		method:     http.MethodPost,
+		grpcMethod: "/proto.NervoService/WriteToController",
+		handle: func(ctx context.Context, r *http.Request) (interface{}, error) {
+			request := httpWriteRequest{}
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				return nil, status.Error(codes.InvalidArgument, err.Error())
+			}
+			_, err := s.grpcServer.WriteToController(ctx, &proto.WriteToControllerRequest{
+				ControllerPortName: request.PortName,
+				Message:            []byte(request.Message),
+			})
+			return struct{}{}, err
+		},
+	}))
+	mux.Handle("/api/controllers/name", s.route(httpRoute{
+		method:     http.MethodPost,
+		grpcMethod: "/proto.NervoService/SetControllerName",
+		handle: func(ctx context.Context, r *http.Request) (interface{}, error) {
+			request := httpControllerInfo{}
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				return nil, status.Error(codes.InvalidArgument, err.Error())
+			}
+			response, err := s.grpcServer.SetControllerName(ctx, &proto.ControllerInfo{
+				PortName: request.PortName,
+				Name:     request.Name,
+			})
+			if err != nil {
+				return nil, err
+			}
+			return toHTTPControllerList(response), nil
+		},
+	}))
+	mux.Handle("/api/controllers/flash", s.route(httpRoute{
+		method:     http.MethodPost,
+		grpcMethod: "/proto.NervoService/FlashController",
+		// leaves room for the multipart headers around the hex file
+		maxBodySize: 2 * maxHexFileSize,
+		handle: func(ctx context.Context, r *http.Request) (interface{}, error) {
+			file, header, err := r.FormFile("hex_file")
+			if err != nil {
+				return nil, status.Error(codes.InvalidArgument, "expected the hex file as multipart form field hex_file: "+err.Error())
+			}
+			defer file.Close()
+			format, baseAddress, err := httpFirmwareFormat(r.URL.Query(), header.Filename)
+			if err != nil {
+				return nil, err
+			}
+
+			// one byte more than allowed, so too large files are rejected by FlashController instead of silently cut
+			hexFileContent, err := ioutil.ReadAll(io.LimitReader(file, maxHexFileSize+1))
+			if err != nil {
+				return nil, status.Error(codes.InvalidArgument, err.Error())
+			}
+			response, err := s.grpcServer.FlashController(ctx, &proto.FlashControllerRequest{
+				ControllerPortName: r.URL.Query().Get("port_name"),
+				HexFileContent:     hexFileContent,
+				Format:             format,
+				BaseAddress:        baseAddress,
+			})
+			if err != nil {
+				return nil, err
+			}
+			return httpFlashOutput{Output: response.Output, Report: httpFlashReportFromProto(response.Report)}, nil
+		},
+	}))
+	mux.Handle("/api/usb/reset", s.route(httpRoute{
+		method:     http.MethodPost,
+		grpcMethod: "/proto.NervoService/ResetUsb",
+		handle: func(ctx context.Context, r *http.Request) (interface{}, error) {
+			response, err := s.grpcServer.ResetUsb(ctx, &proto.ResetUsbRequest{})
+			if err != nil {
+				return nil, err
+			}
+			return httpOutput{Output: response.Output}, nil
+		},
+	}))
+	return mux
+}
+
+// route checks the method and api token of requests before handing them to the route and writes the json answer
+func (s *HTTPServer) route(route httpRoute) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != route.method {
+			w.Header().Set("Allow", route.method)
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]httpError{"error": {
+				Code:    codes.Unimplemented.String(),
+				Message: r.Method + " is not allowed, use " + route.method,
+			}})
+			return
+		}
+
+		ctx := httpRequestContext(r)
+		if s.grpcServer.Authenticator != nil {
+			// browsers can't set headers for websockets and event sources, so they pass the token as query parameter
+			authorization := r.Header.Get("Authorization")
+			if token := r.URL.Query().Get("access_token"); authorization == "" && token != "" {
+				authorization = "Bearer " + token
+			}
+
+			var err error
+			ctx, err = s.grpcServer.Authenticator.authorizeHeader(ctx, authorization, route.grpcMethod)
+			if err != nil {
+				writeHTTPError(w, err)
+				return
+			}
+		}
+
+		if route.maxBodySize > 0 {
+			r.Body = http.MaxBytesReader(w, r.Body, route.maxBodySize)
+		}
+		if route.serve != nil {
+			route.serve(ctx, w, r)
+			return
+		}
+		response, err := route.handle(ctx, r)
+		if err != nil {
+			writeHTTPError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, response)
+	})
+}
+
+// httpRequestContext makes the client of r known the same way grpc does, so clientIdentity works for both
+func httpRequestContext(r *http.Request) context.Context {
+	p := &peer.Peer{Addr: httpRemoteAddr(r.RemoteAddr)}
+	if r.TLS != nil {
+		p.AuthInfo = credentials.TLSInfo{State: *r.TLS}
+	}
+	return peer.NewContext(r.Context(), p)
+}
+
+type httpRemoteAddr string
+
+func (a httpRemoteAddr) Network() string { return "tcp" }
+func (a httpRemoteAddr) String() string  { return string(a) }
+
+func toHTTPControllerList(response *proto.ControllerListResponse) httpControllerList {
+	list := httpControllerList{Controllers: []httpControllerInfo{}}
+	for _, info := range response.ControllerInfos {
+		list.Controllers = append(list.Controllers, httpControllerInfo{
+			PortName: info.PortName,
+			Name:     info.Name,
+			State:    info.State,
+			StableID: info.StableId,
+		})
+	}
+	return list
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Println("writing http response failed:", err)
+	}
+}
+
+func httpFlashReportFromProto(report *proto.FlashReport) *httpFlashReport {
+	if report == nil {
+		return nil
+	}
+	return &httpFlashReport{
+		DeviceSignature: report.DeviceSignature,
+		Device:          report.Device,
+		BytesWritten:    report.BytesWritten,
+		BytesVerified:   report.BytesVerified,
+		Verified:        report.Verified,
+		Fuses:           report.Fuses,
+		ElapsedSeconds:  time.Duration(report.ElapsedNano).Seconds(),
+	}
+}
+
+func writeHTTPError(w http.ResponseWriter, err error) {
+	s := status.Convert(toStatusError(err))
+	body := httpError{Code: s.Code().String(), Message: s.Message()}
+	for _, detail := range s.Details() {
+		switch d := detail.(type) {
+		case *errdetails.DebugInfo:
+			body.Output = d.Detail
+		case *proto.FlashReport:
+			body.Failure = strings.ToLower(d.Failure.String())
+		case *errdetails.RetryInfo:
+			if d.RetryDelay != nil {
+				w.Header().Set("Retry-After", fmt.Sprint(d.RetryDelay.Seconds))
+			}
+		}
+	}
+	writeJSON(w, httpStatusFromCode(s.Code()), map[string]httpError{"error": body})
+}
+
+// httpStatusFromCode follows the mapping of the grpc-gateway
+func httpStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// httpFirmwareFormat reads the format and base_address query parameters of a flash. Without a format, .bin files are taken as raw images
+func httpFirmwareFormat(query url.Values, fileName string) (proto.FirmwareFormat, uint32, error) {
+	format := proto.FirmwareFormat_AUTO
+	if name := query.Get("format"); name != "" {
+		value, ok := proto.FirmwareFormat_value[strings.ToUpper(name)]
+		if !ok {
+			return 0, 0, status.Errorf(codes.InvalidArgument, "unknown format %q, expected hex, elf or bin", name)
+		}
+		format = proto.FirmwareFormat(value)
+	} else if strings.HasSuffix(strings.ToLower(fileName), ".bin") {
+		format = proto.FirmwareFormat_BIN
+	}
+
+	var baseAddress uint64
+	if address := query.Get("base_address"); address != "" {
+		var err error
+		// accepts 0x prefixed hex addresses as well
+		baseAddress, err = strconv.ParseUint(address, 0, 32)
+		if err != nil {
+			return 0, 0, status.Errorf(codes.InvalidArgument, "invalid base_address %q", address)
+		}
+	}
+	return format, uint32(baseAddress), nil
+}
@@ -0,0 +1,155 @@
+package nervo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestHTTPServer(t *testing.T, h *testHarness) *httptest.Server {
+	return httptest.NewServer(NewHTTPServer(h.server, 0).handler())
+}
+
+func doHTTP(t *testing.T, method, url, token string, body io.Reader, contentType string) (int, map[string]interface{}) {
+	request, err := http.NewRequest(method, url, body)
+	require.NoError(t, err)
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	decoded := map[string]interface{}{}
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&decoded))
+	return response.StatusCode, decoded
+}
+
+func Test_HTTPServer(t *testing.T) {
+	h := newTestHarness(t)
+	defer h.close()
+	server := newTestHTTPServer(t, h)
+	defer server.Close()
+
+	device := h.attach("/dev/ttyACM0", "leg1")
+
+	t.Run("listing", func(t *testing.T) {
+		code, body := doHTTP(t, http.MethodGet, server.URL+"/api/controllers", "", nil, "")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []interface{}{map[string]interface{}{
+			"port_name": "/dev/ttyACM0",
+			"name":      "leg1",
+			"state":     "connected",
+			"stable_id": "/dev/ttyACM0",
+		}}, body["controllers"])
+	})
+
+	t.Run("writing", func(t *testing.T) {
+		code, _ := doHTTP(t, http.MethodPost, server.URL+"/api/controllers/write", "",
+			strings.NewReader(`{"port_name": "/dev/ttyACM0", "message": "step 1\n"}`), "application/json")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "step 1\n", device.receive(t))
+	})
+
+	t.Run("reading from an unknown port", func(t *testing.T) {
+		code, body := doHTTP(t, http.MethodGet, server.URL+"/api/controllers/output?port_name=/dev/ttyACM9", "", nil, "")
+		assert.Equal(t, http.StatusNotFound, code)
+		assert.Equal(t, "NotFound", body["error"].(map[string]interface{})["code"])
+	})
+
+	t.Run("the dashboard", func(t *testing.T) {
+		response, err := http.Get(server.URL + "/")
+		require.NoError(t, err)
+		defer response.Body.Close()
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Equal(t, "text/html; charset=utf-8", response.Header.Get("Content-Type"))
+	})
+
+	t.Run("with the wrong method", func(t *testing.T) {
+		code, _ := doHTTP(t, http.MethodGet, server.URL+"/api/usb/reset", "", nil, "")
+		assert.Equal(t, http.StatusMethodNotAllowed, code)
+	})
+
+	flash := func(hexFile []byte) (int, map[string]interface{}) {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		part, err := writer.CreateFormFile("hex_file", "leg.hex")
+		require.NoError(t, err)
+		part.Write(hexFile)
+		require.NoError(t, writer.Close())
+		return doHTTP(t, http.MethodPost, server.URL+"/api/controllers/flash?port_name=/dev/ttyACM0", "", body, writer.FormDataContentType())
+	}
+
+	t.Run("flashing", func(t *testing.T) {
+		code, body := flash([]byte(":040000000C94340028\n:00000001FF\n"))
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "fake flash done", body["output"])
+		assert.Equal(t, [][]byte{[]byte(":040000000C94340028\n:00000001FF\n")}, h.flasher.flashedImages("/dev/ttyACM0"))
+	})
+
+	t.Run("failed flashing", func(t *testing.T) {
+		h.flasher.mutex.Lock()
+		h.flasher.output = "avrdude: ser_open(): can't open device"
+		h.flasher.err = errors.New("exit status 1")
+		h.flasher.mutex.Unlock()
+
+		code, body := flash([]byte(":040000000C94340028\n:00000001FF\n"))
+		assert.Equal(t, http.StatusConflict, code)
+		assert.Equal(t, "avrdude: ser_open(): can't open device", body["error"].(map[string]interface{})["output"])
+		assert.Equal(t, "unknown", body["error"].(map[string]interface{})["failure"])
+	})
+}
+
+func Test_HTTPServer_TokenAuthentication(t *testing.T) {
+	authenticator, err := NewTokenAuthenticator([]APIToken{
+		{Token: "viewer-token-0123456789", Name: "dashboard", Role: "viewer"},
+	})
+	require.NoError(t, err)
+
+	h := newConfiguredTestHarness(t, func(s *GrpcServer) {
+		s.Authenticator = authenticator
+	})
+	defer h.close()
+	server := newTestHTTPServer(t, h)
+	defer server.Close()
+
+	tests := []struct {
+		testMessage  string
+		method       string
+		path         string
+		token        string
+		expectedCode int
+	}{
+		{"without a token", http.MethodGet, "/api/controllers", "", http.StatusUnauthorized},
+		{"viewer listing", http.MethodGet, "/api/controllers", "viewer-token-0123456789", http.StatusOK},
+		{"viewer resetting usb", http.MethodPost, "/api/usb/reset", "viewer-token-0123456789", http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testMessage, func(t *testing.T) {
+			code, _ := doHTTP(t, test.method, server.URL+test.path, test.token, nil, "")
+			assert.Equal(t, test.expectedCode, code)
+		})
+	}
+
+	t.Run("the openapi description needs no token", func(t *testing.T) {
+		response, err := http.Get(server.URL + "/api/openapi.json")
+		require.NoError(t, err)
+		defer response.Body.Close()
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.True(t, json.Valid([]byte(openAPISpec)))
+	})
+}
@@ -0,0 +1,201 @@
+package nervo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/codeuniversity/nervo/proto"
+
+	"golang.org/x/net/websocket"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// httpStreamEvent is sent for every line a controller outputs and for failed writes over the websocket
+type httpStreamEvent struct {
+	Type     string     `json:"type"`
+	PortName string     `json:"port_name"`
+	Time     time.Time  `json:"time"`
+	Line     string     `json:"line,omitempty"`
+	Error    *httpError `json:"error,omitempty"`
+}
+
+// httpStreamMessage is what websocket clients send to write to the controller
+type httpStreamMessage struct {
+	Message string `json:"message"`
+}
+
+func lineEvent(line outputLine) httpStreamEvent {
+	return httpStreamEvent{Type: "line", PortName: line.portName, Time: line.time, Line: string(line.data)}
+}
+
+// outputFilterFromQuery reads the filter of a stream from the verb, include, exclude and sample_every_nth query parameters
+func outputFilterFromQuery(query url.Values) (*outputFilter, error) {
+	filter := &proto.OutputFilter{
+		Verbs:   query["verb"],
+		Include: query["include"],
+		Exclude: query["exclude"],
+	}
+	if nth := query.Get("sample_every_nth"); nth != "" {
+		n, err := strconv.ParseUint(nth, 10, 32)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid sample_every_nth %q", nth)
+		}
+		filter.SampleEveryNth = uint32(n)
+	}
+
+	f, err := outputFilterFromProto(filter)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return f, nil
+}
+
+// serveWebSocket streams the output of a controller to the websocket and writes the messages received over it to the controller
+func (s *HTTPServer) serveWebSocket(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	portName := r.URL.Query().Get("port_name")
+	filter, err := outputFilterFromQuery(r.URL.Query())
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+	lines, err := s.grpcServer.Manager.observeController(portName)
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+	defer s.grpcServer.Manager.stopObservingController(portName, lines)
+
+	const writeMethod = "/proto.NervoService/WriteToController"
+	handler := func(ws *websocket.Conn) {
+		receiveDone := make(chan struct{})
+		go func() {
+			defer close(receiveDone)
+			for {
+				message := httpStreamMessage{}
+				if err := websocket.JSON.Receive(ws, &message); err != nil {
+					return
+				}
+
+				var err error
+				if mayCall(ctx, writeMethod) {
+					err = s.grpcServer.Manager.writeToController(portName, []byte(message.Message))
+				} else {
+					err = status.Errorf(codes.PermissionDenied, "writing needs the %s role", requiredRole(writeMethod))
+				}
+				if err != nil {
+					writeStatus := status.Convert(toStatusError(err))
+					websocket.JSON.Send(ws, httpStreamEvent{
+						Type:     "error",
+						PortName: portName,
+						Time:     time.Now(),
+						Error:    &httpError{Code: writeStatus.Code().String(), Message: writeStatus.Message()},
+					})
+				}
+			}
+		}()
+
+		for {
+			select {
+			case line, ok := <-lines:
+				if !ok {
+					return
+				}
+				if !filter.passes(line.portName, line.data) {
+					continue
+				}
+				if err := websocket.JSON.Send(ws, lineEvent(line)); err != nil {
+					return
+				}
+			case <-receiveDone:
+				return
+			}
+		}
+	}
+
+	server := websocket.Server{
+		Handler:   handler,
+		Handshake: s.checkWebsocketOrigin,
+	}
+	server.ServeHTTP(w, r)
+}
+
+// checkWebsocketOrigin keeps other pages in a browser from using the websocket with the browser's credentials.
+// Tools outside of browsers don't send an origin, the api token protects the endpoint from them
+func (s *HTTPServer) checkWebsocketOrigin(_ *websocket.Config, r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+
+	if len(s.AllowedOrigins) > 0 {
+		for _, allowed := range s.AllowedOrigins {
+			if origin == allowed {
+				return nil
+			}
+		}
+		return fmt.Errorf("the origin %s is not allowed", origin)
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return err
+	}
+	if u.Host != r.Host {
+		return fmt.Errorf("the origin %s doesn't match the host %s", origin, r.Host)
+	}
+	return nil
+}
+
+// serveEvents streams the output of a controller as server-sent events, for read-only dashboards
+func (s *HTTPServer) serveEvents(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeHTTPError(w, status.Error(codes.Internal, "the connection doesn't support streaming"))
+		return
+	}
+
+	portName := r.URL.Query().Get("port_name")
+	filter, err := outputFilterFromQuery(r.URL.Query())
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+	lines, err := s.grpcServer.Manager.observeController(portName)
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+	defer s.grpcServer.Manager.stopObservingController(portName, lines)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				fmt.Fprint(w, "event: end\ndata: {}\n\n")
+				flusher.Flush()
+				return
+			}
+			if !filter.passes(line.portName, line.data) {
+				continue
+			}
+			data, err := json.Marshal(lineEvent(line))
+			if err != nil {
+				return
+			}
+			fmt.Fprintf(w, "event: line\ndata: %s\n\n", data)
+			flusher.Flush()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
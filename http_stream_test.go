@@ -0,0 +1,127 @@
+package nervo
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+func Test_HTTPServer_WebSocket(t *testing.T) {
+	h := newTestHarness(t)
+	defer h.close()
+	server := newTestHTTPServer(t, h)
+	defer server.Close()
+
+	device := h.attach("/dev/ttyACM0", "leg1")
+
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/controllers/stream?port_name=/dev/ttyACM0", "", server.URL)
+	require.NoError(t, err)
+	defer ws.Close()
+
+	t.Run("streams output lines with timestamps", func(t *testing.T) {
+		device.send("sensor_data 12")
+
+		event := httpStreamEvent{}
+		require.NoError(t, websocket.JSON.Receive(ws, &event))
+		assert.Equal(t, "line", event.Type)
+		assert.Equal(t, "/dev/ttyACM0", event.PortName)
+		assert.Equal(t, "sensor_data 12\n", event.Line)
+		assert.False(t, event.Time.IsZero())
+	})
+
+	t.Run("writes received messages", func(t *testing.T) {
+		require.NoError(t, websocket.JSON.Send(ws, httpStreamMessage{Message: "step 1\n"}))
+		assert.Equal(t, "step 1\n", device.receive(t))
+	})
+
+	t.Run("doesn't take the output away from reading once", func(t *testing.T) {
+		device.send("feedback done")
+
+		event := httpStreamEvent{}
+		require.NoError(t, websocket.JSON.Receive(ws, &event))
+		assert.Equal(t, "feedback done\n", event.Line)
+		require.Eventually(t, func() bool {
+			output, err := h.manager.readFromController("/dev/ttyACM0")
+			return err == nil && strings.Contains(output, "feedback done\n")
+		}, testWaitTimeout, testTick)
+	})
+
+	t.Run("rejects other origins", func(t *testing.T) {
+		_, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/controllers/stream?port_name=/dev/ttyACM0", "", "https://evil.example.com")
+		assert.Error(t, err)
+	})
+}
+
+func Test_HTTPServer_Events(t *testing.T) {
+	h := newTestHarness(t)
+	defer h.close()
+	server := newTestHTTPServer(t, h)
+	defer server.Close()
+
+	device := h.attach("/dev/ttyACM0", "leg1")
+
+	response, err := http.Get(server.URL + "/api/controllers/events?port_name=/dev/ttyACM0")
+	require.NoError(t, err)
+	defer response.Body.Close()
+	assert.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+
+	device.send("sensor_data 12")
+
+	reader := bufio.NewReader(response.Body)
+	eventLine, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "event: line\n", eventLine)
+	dataLine, err := reader.ReadString('\n')
+	require.NoError(t, err)
+
+	event := httpStreamEvent{}
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(dataLine, "data: ")), &event))
+	assert.Equal(t, "sensor_data 12\n", event.Line)
+
+	t.Run("ends when the controller is detached", func(t *testing.T) {
+		h.detach("/dev/ttyACM0")
+
+		rest := ""
+		for {
+			line, err := reader.ReadString('\n')
+			rest += line
+			if err != nil {
+				break
+			}
+		}
+		assert.Contains(t, rest, "event: end\n")
+	})
+}
+
+func Test_HTTPServer_checkWebsocketOrigin(t *testing.T) {
+	tests := []struct {
+		testMessage    string
+		allowedOrigins []string
+		origin         string
+		expectedOK     bool
+	}{
+		{testMessage: "no origin outside of browsers", origin: "", expectedOK: true},
+		{testMessage: "the gateway's own pages", origin: "http://nervo:8080", expectedOK: true},
+		{testMessage: "other pages", origin: "http://evil.example.com", expectedOK: false},
+		{testMessage: "an allowed origin", allowedOrigins: []string{"https://dashboard.example.com"}, origin: "https://dashboard.example.com", expectedOK: true},
+		{testMessage: "the host once origins are configured", allowedOrigins: []string{"https://dashboard.example.com"}, origin: "http://nervo:8080", expectedOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.testMessage, func(t *testing.T) {
+			request, err := http.NewRequest(http.MethodGet, "http://nervo:8080/api/controllers/stream", nil)
+			require.NoError(t, err)
+			if tt.origin != "" {
+				request.Header.Set("Origin", tt.origin)
+			}
+			s := &HTTPServer{AllowedOrigins: tt.allowedOrigins}
+			err = s.checkWebsocketOrigin(nil, request)
+			assert.Equal(t, tt.expectedOK, err == nil, "%v", err)
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package nervo
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+)
+
+// atmega328pFlashSize is the flash of the arduino uno, including the bootloader at its end
+const atmega328pFlashSize = 32 << 10
+
+const (
+	hexRecordData                   = 0x00
+	hexRecordEndOfFile              = 0x01
+	hexRecordExtendedSegmentAddress = 0x02
+	hexRecordStartSegmentAddress    = 0x03
+	hexRecordExtendedLinearAddress  = 0x04
+	hexRecordStartLinearAddress     = 0x05
+)
+
+// intelHexImage is what a valid hex file writes
+type intelHexImage struct {
+	// dataBytes is the number of data bytes in all records
+	dataBytes int
+	// end is the address after the highest byte written
+	end uint32
+}
+
+// flashSizer is implemented by flashers that know how much flash the controller at portName has
+type flashSizer interface {
+	flashSize(portName string) int
+}
+
+// parseIntelHex checks the record syntax and checksums, the address records and that the file ends with an end of file record.
+// Data beyond flashSize is rejected, unless flashSize is 0
+func parseIntelHex(content []byte, flashSize int) (*intelHexImage, error) {
+	image := &intelHexImage{}
+	var baseAddress uint32
+	endOfFile := false
+
+	for i, rawLine := range bytes.Split(content, []byte("\n")) {
+		lineNumber := i + 1
+		line := bytes.TrimSpace(rawLine)
+		if len(line) == 0 {
+			continue
+		}
+		if endOfFile {
+			return nil, &HexFileError{Line: lineNumber, Reason: "records after the end of file record"}
+		}
+
+		record, err := parseHexRecord(line)
+		if err != nil {
+			return nil, &HexFileError{Line: lineNumber, Reason: err.Error()}
+		}
+		length := int(record[0])
+		address := uint32(record[1])<<8 | uint32(record[2])
+		recordType := record[3]
+		data := record[4 : 4+length]
+
+		switch recordType {
+		case hexRecordData:
+			start := baseAddress + address
+			end := start + uint32(length)
+			if flashSize > 0 && end > uint32(flashSize) {
+				return nil, &HexFileError{
+					Line:   lineNumber,
+					Reason: fmt.Sprintf("data at 0x%X-0x%X is beyond the %d bytes of flash", start, end-1, flashSize),
+				}
+			}
+			image.dataBytes += length
+			if end > image.end {
+				image.end = end
+			}
+		case hexRecordEndOfFile:
+			if length != 0 {
+				return nil, &HexFileError{Line: lineNumber, Reason: "the end of file record has data"}
+			}
+			endOfFile = true
+		case hexRecordExtendedSegmentAddress:
+			if length != 2 {
+				return nil, &HexFileError{Line: lineNumber, Reason: "an extended segment address record needs 2 bytes of data"}
+			}
+			baseAddress = (uint32(data[0])<<8 | uint32(data[1])) << 4
+		case hexRecordExtendedLinearAddress:
+			if length != 2 {
+				return nil, &HexFileError{Line: lineNumber, Reason: "an extended linear address record needs 2 bytes of data"}
+			}
+			baseAddress = (uint32(data[0])<<8 | uint32(data[1])) << 16
+		case hexRecordStartSegmentAddress, hexRecordStartLinearAddress:
+			if length != 4 {
+				return nil, &HexFileError{Line: lineNumber, Reason: "a start address record needs 4 bytes of data"}
+			}
+		default:
+			return nil, &HexFileError{Line: lineNumber, Reason: fmt.Sprintf("unknown record type %02X", recordType)}
+		}
+	}
+
+	if !endOfFile {
+		return nil, &HexFileError{Reason: "the end of file record is missing, the file may be truncated"}
+	}
+	if image.dataBytes == 0 {
+		return nil, &HexFileError{Reason: "the file has no data records, there is nothing to flash"}
+	}
+	return image, nil
+}
+
+// parseHexRecord decodes a ":LLAAAATT<data>CC" line and checks its length and checksum
+func parseHexRecord(line []byte) ([]byte, error) {
+	if line[0] != ':' {
+		return nil, fmt.Errorf("a record has to start with ':', not %q", line[0])
+	}
+	record := make([]byte, hex.DecodedLen(len(line)-1))
+	if _, err := hex.Decode(record, line[1:]); err != nil {
+		return nil, fmt.Errorf("not a hex record: %v", err)
+	}
+	if len(record) < 5 {
+		return nil, fmt.Errorf("the record is too short")
+	}
+	if expected := 5 + int(record[0]); len(record) != expected {
+		return nil, fmt.Errorf("the record announces %d data bytes, but has %d", record[0], len(record)-5)
+	}
+
+	var sum byte
+	for _, b := range record {
+		sum += b
+	}
+	if sum != 0 {
+		last := record[len(record)-1]
+		return nil, fmt.Errorf("checksum is %02X, expected %02X", last, last-sum)
+	}
+	return record, nil
+}
@@ -0,0 +1,85 @@
+package nervo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseIntelHex(t *testing.T) {
+	tests := []struct {
+		testMessage string
+		content     string
+		err         *HexFileError
+		end         uint32
+	}{
+		{
+			testMessage: "a valid file",
+			content:     ":040000000C94340028\r\n:00000001FF\r\n",
+			end:         4,
+		},
+		{
+			testMessage: "an extended linear address within the flash",
+			content:     ":020000040000FA\n:040000000C94340028\n:00000001FF\n",
+			end:         4,
+		},
+		{
+			testMessage: "a wrong checksum",
+			content:     ":040000000C94340029\n:00000001FF\n",
+			err:         &HexFileError{Line: 1, Reason: "checksum is 29, expected 28"},
+		},
+		{
+			testMessage: "a missing colon",
+			content:     ":040000000C94340028\n040000000C94340028\n",
+			err:         &HexFileError{Line: 2, Reason: "a record has to start with ':', not '0'"},
+		},
+		{
+			testMessage: "a wrong length",
+			content:     ":050000000C94340028\n:00000001FF\n",
+			err:         &HexFileError{Line: 1, Reason: "the record announces 5 data bytes, but has 4"},
+		},
+		{
+			testMessage: "a missing end of file record",
+			content:     ":040000000C94340028\n",
+			err:         &HexFileError{Reason: "the end of file record is missing, the file may be truncated"},
+		},
+		{
+			testMessage: "records after the end of file",
+			content:     ":00000001FF\n:040000000C94340028\n",
+			err:         &HexFileError{Line: 2, Reason: "records after the end of file record"},
+		},
+		{
+			testMessage: "data beyond the flash",
+			content:     ":040000000C94340028\n:048000000C943400A8\n:00000001FF\n",
+			err:         &HexFileError{Line: 2, Reason: "data at 0x8000-0x8003 is beyond the 32768 bytes of flash"},
+		},
+		{
+			testMessage: "an extended linear address beyond the flash",
+			content:     ":020000040001F9\n:040000000C94340028\n:00000001FF\n",
+			err:         &HexFileError{Line: 2, Reason: "data at 0x10000-0x10003 is beyond the 32768 bytes of flash"},
+		},
+		{
+			testMessage: "only the end of file record",
+			content:     ":00000001FF\n",
+			err:         &HexFileError{Reason: "the file has no data records, there is nothing to flash"},
+		},
+		{
+			testMessage: "not hex at all",
+			content:     "\x7fELF\x02\x01",
+			err:         &HexFileError{Line: 1, Reason: "a record has to start with ':', not '\\x7f'"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testMessage, func(t *testing.T) {
+			image, err := parseIntelHex([]byte(test.content), atmega328pFlashSize)
+			if test.err != nil {
+				assert.Equal(t, test.err, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.end, image.end)
+		})
+	}
+}
@@ -0,0 +1,13 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package nervo
+
+import "golang.org/x/sys/unix"
+
+func ioctl(fd, request, argument uintptr) error {
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, fd, request, argument); errno != 0 {
+		return errno
+	}
+	return nil
+}
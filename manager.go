@@ -3,34 +3,79 @@ package nervo
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"log"
+	"sync"
 	"time"
 )
 
 type controllerInfo struct {
 	name     string
 	portName string
+	stableID string
+	state    string
+	labels   map[string]string
+}
+
+type readOutputAnswer struct {
+	output string
+	err    error
 }
 
 type readOutputMessage struct {
 	portName   string
-	answerChan chan string
+	answerChan chan readOutputAnswer
 }
 
 type flashAnswer struct {
 	Error  error
 	Output string
+	// StableID of the flashed controller, empty if it wasn't found
+	StableID string
+}
+
+// flashDoneMessage is sent by a flash running outside of the loop once it is done
+type flashDoneMessage struct {
+	controller *controller
+	answer     flashAnswer
+	timedOut   bool
+	answerChan chan flashAnswer
 }
 
 type flashMessage struct {
 	portName       string
 	hexFileContent []byte
+	progress       func(FlashProgress)
 	answerChan     chan flashAnswer
 }
 
+type readContinuousAnswer struct {
+	notifierChan chan outputLine
+	err          error
+}
+
 type readContinuousMessage struct {
 	portName   string
-	answerChan chan chan []byte
+	answerChan chan readContinuousAnswer
+}
+
+type observeAnswer struct {
+	observer chan outputLine
+	history  []outputLine
+	missed   uint64
+	err      error
+}
+
+type observeMessage struct {
+	portName   string
+	answerChan chan observeAnswer
+	// withHistoryFrom also answers with the kept lines from this sequence on, if set
+	withHistoryFrom uint64
+}
+
+type stopObservingMessage struct {
+	portName string
+	observer chan outputLine
 }
 
 type stopReadingMessage struct {
@@ -40,16 +85,32 @@ type stopReadingMessage struct {
 type nameControllerMessage struct {
 	portName string
 	name     string
+	doneChan chan error
+}
+
+type labelControllerMessage struct {
+	portName string
+	labels   map[string]string
+	doneChan chan error
 }
 
 type pingMessage struct {
 	pongChan chan struct{}
 }
 
+type listControllersMessage struct {
+	answerChan chan []controllerInfo
+}
+
 type writeToControllerMessage struct {
+	target     controllerTarget
+	message    []byte
+	answerChan chan writeToControllerAnswer
+}
+
+type writeToControllerAnswer struct {
 	portName string
-	message  []byte
-	doneChan chan error
+	err      error
 }
 
 type writeToControllerContinuouslyAnswerMessage struct {
@@ -63,36 +124,93 @@ type writeToControllerContinuouslyMessage struct {
 	answerChan chan writeToControllerContinuouslyAnswerMessage
 }
 
+// reconnectInterval is how often controllers that stopped because of an error are reconnected
+const reconnectInterval = time.Second
+
+// ManagerConfig holds everything a Manager needs from its environment. Empty fields are replaced with defaults
+type ManagerConfig struct {
+	Discoverer    Discoverer
+	OpenTransport TransportOpener
+	Flasher       Flasher
+	// RecordDirectory enables recording every session with a controller into a file in this directory
+	RecordDirectory string
+	// ByIDDirectory is where the stable ids of the controllers are looked up, it should match the DiscoveryConfig
+	ByIDDirectory string
+}
+
 // Manager controls all interactions with the controllers from outside
 type Manager struct {
 	VerbMessageHandler                func(verb, message string)
+	discoverer                        Discoverer
+	openTransport                     TransportOpener
+	flasher                           Flasher
+	recordDirectory                   string
+	byIDDirectory                     string
 	controllers                       []*controller
+	allObservers                      *outputObservers
+	labelsMutex                       *sync.Mutex
+	labelsByName                      map[string]map[string]string
 	currentPortsChan                  chan []string
 	readOutputChan                    chan readOutputMessage
 	flashChan                         chan flashMessage
+	flashDoneChan                     chan flashDoneMessage
 	readContinuousChan                chan readContinuousMessage
 	stopReadingChan                   chan stopReadingMessage
+	observeChan                       chan observeMessage
+	stopObservingChan                 chan stopObservingMessage
 	nameControllerChan                chan nameControllerMessage
+	labelControllerChan               chan labelControllerMessage
 	pingChan                          chan pingMessage
+	listControllersChan               chan listControllersMessage
 	writeToControllerChan             chan writeToControllerMessage
 	writeToControllerContinuouslyChan chan writeToControllerContinuouslyMessage
 }
 
 // NewManager retuns a Manager that is ready for use
-func NewManager() *Manager {
+func NewManager(config ManagerConfig) *Manager {
+	if config.Discoverer == nil {
+		// the default config is always valid
+		config.Discoverer, _ = NewDiscoverer(DefaultDiscoveryConfig())
+	}
+
+	if config.ByIDDirectory == "" {
+		config.ByIDDirectory = defaultByIDDirectory
+	}
+
+	if config.OpenTransport == nil {
+		config.OpenTransport = OpenTransport
+	}
+
+	if config.Flasher == nil {
+		config.Flasher = &AvrdudeFlasher{}
+	}
+
 	m := &Manager{
+		discoverer:                        config.Discoverer,
+		openTransport:                     config.OpenTransport,
+		flasher:                           config.Flasher,
+		recordDirectory:                   config.RecordDirectory,
+		byIDDirectory:                     config.ByIDDirectory,
+		allObservers:                      newOutputObservers(1000),
+		labelsMutex:                       &sync.Mutex{},
+		labelsByName:                      map[string]map[string]string{},
 		currentPortsChan:                  make(chan []string),
 		readOutputChan:                    make(chan readOutputMessage),
 		flashChan:                         make(chan flashMessage),
+		flashDoneChan:                     make(chan flashDoneMessage),
 		readContinuousChan:                make(chan readContinuousMessage),
 		stopReadingChan:                   make(chan stopReadingMessage),
+		observeChan:                       make(chan observeMessage),
+		stopObservingChan:                 make(chan stopObservingMessage),
 		nameControllerChan:                make(chan nameControllerMessage),
+		labelControllerChan:               make(chan labelControllerMessage),
 		pingChan:                          make(chan pingMessage),
+		listControllersChan:               make(chan listControllersMessage),
 		writeToControllerChan:             make(chan writeToControllerMessage),
 		writeToControllerContinuouslyChan: make(chan writeToControllerContinuouslyMessage),
 	}
 
-	go m.lookForNewPorts()
+	go m.discoverer.Run(m.currentPortsChan)
 	go m.manageControllers()
 	go watchManagerHealth(m, func() {
 		panic("I don't know, just kill him I guess")
@@ -101,11 +219,15 @@ func NewManager() *Manager {
 }
 
 func (m *Manager) manageControllers() {
+	reconnectTicker := time.NewTicker(reconnectInterval)
+	defer reconnectTicker.Stop()
 	for {
 		select {
 		case currentPorts := <-m.currentPortsChan:
 			m.handleCurrentPorts(currentPorts)
 			break
+		case <-reconnectTicker.C:
+			m.reconnectErroredControllers()
 		case message := <-m.readOutputChan:
 			controller := m.controllerForPort(message.portName)
 			if controller != nil {
@@ -115,28 +237,39 @@ func (m *Manager) manageControllers() {
 					if err != nil {
 						panic(err)
 					}
-					message.answerChan <- string(outputBuffer)
+					message.answerChan <- readOutputAnswer{output: string(outputBuffer)}
 				})
 			} else {
-				message.answerChan <- "no controller found at " + message.portName
+				message.answerChan <- readOutputAnswer{err: &ControllerNotFoundError{PortName: message.portName}}
 			}
 			break
 		case message := <-m.flashChan:
 			controller := m.controllerForPort(message.portName)
-			if controller != nil {
-				output, err := controller.flash(message.hexFileContent)
-				message.answerChan <- flashAnswer{Error: err, Output: output}
+			if controller == nil {
+				message.answerChan <- flashAnswer{Error: &ControllerNotFoundError{PortName: message.portName}}
+			} else if controller.state() == controllerStateFlashing {
+				message.answerChan <- flashAnswer{Error: &UnsupportedError{
+					PortName: message.portName,
+					Reason:   "the controller is being flashed already",
+				}}
 			} else {
-				message.answerChan <- flashAnswer{Error: errors.New("no controller found at " + message.portName)}
+				controller.startFlashing()
+				go m.runFlash(controller, message)
 			}
 			break
+		case message := <-m.flashDoneChan:
+			// a controller that was removed while flashing must not open its port again
+			stillManaged := m.controllerForPort(message.controller.SerialPortPath) == message.controller
+			message.controller.finishFlashing(stillManaged && !message.timedOut, message.answer.Error)
+			message.answerChan <- message.answer
+			break
 		case message := <-m.readContinuousChan:
 			controller := m.controllerForPort(message.portName)
 			if controller != nil {
 				notifierChan := controller.notifyOnRead()
-				message.answerChan <- notifierChan
+				message.answerChan <- readContinuousAnswer{notifierChan: notifierChan}
 			} else {
-				message.answerChan <- nil
+				message.answerChan <- readContinuousAnswer{err: &ControllerNotFoundError{PortName: message.portName}}
 			}
 			break
 		case message := <-m.stopReadingChan:
@@ -145,18 +278,53 @@ func (m *Manager) manageControllers() {
 				controller.clearNotifier()
 			}
 			break
-		case message := <-m.nameControllerChan:
+		case message := <-m.observeChan:
 			controller := m.controllerForPort(message.portName)
 			if controller != nil {
-				controller.Name = message.name
+				answer := observeAnswer{observer: controller.observe()}
+				if message.withHistoryFrom > 0 {
+					answer.history, answer.missed = controller.history.since(message.withHistoryFrom)
+				}
+				message.answerChan <- answer
+			} else {
+				message.answerChan <- observeAnswer{err: &ControllerNotFoundError{PortName: message.portName}}
 			}
 			break
-		case message := <-m.writeToControllerChan:
+		case message := <-m.stopObservingChan:
 			controller := m.controllerForPort(message.portName)
 			if controller != nil {
-				message.doneChan <- controller.write(message.message)
+				controller.stopObserving(message.observer)
+			}
+			break
+		case message := <-m.nameControllerChan:
+			controller := m.controllerForPort(message.portName)
+			if controller == nil {
+				message.doneChan <- &ControllerNotFoundError{PortName: message.portName}
 			} else {
-				message.doneChan <- errors.New("no controller found at " + message.portName)
+				controller.setName(message.name)
+				message.doneChan <- nil
+			}
+			break
+		case message := <-m.labelControllerChan:
+			controller := m.controllerForPort(message.portName)
+			if controller == nil {
+				message.doneChan <- &ControllerNotFoundError{PortName: message.portName}
+			} else if controller.name() == "" {
+				message.doneChan <- &UnsupportedError{
+					PortName: message.portName,
+					Reason:   "labels are kept by controller name, but the controller hasn't announced one yet",
+				}
+			} else {
+				m.setLabels(controller.name(), message.labels)
+				message.doneChan <- nil
+			}
+			break
+		case message := <-m.writeToControllerChan:
+			controller, err := m.controllerForTarget(message.target)
+			if controller != nil {
+				message.answerChan <- writeToControllerAnswer{portName: controller.SerialPortPath, err: controller.write(message.message)}
+			} else {
+				message.answerChan <- writeToControllerAnswer{err: err}
 			}
 			break
 		case message := <-m.writeToControllerContinuouslyChan:
@@ -169,44 +337,109 @@ func (m *Manager) manageControllers() {
 				}
 			} else {
 				message.answerChan <- writeToControllerContinuouslyAnswerMessage{
-					err: errors.New("no controller found at " + message.portName),
+					err: &ControllerNotFoundError{PortName: message.portName},
 				}
 			}
 			break
 		case m := <-m.pingChan:
 			m.pongChan <- struct{}{}
 			break
+		case message := <-m.listControllersChan:
+			message.answerChan <- m.controllerInfos()
+			break
 		}
 	}
 }
 
 func (m *Manager) listControllers() []controllerInfo {
+	answerChan := make(chan []controllerInfo)
+	m.listControllersChan <- listControllersMessage{answerChan: answerChan}
+	return <-answerChan
+}
+
+// controllerInfos describes the managed controllers. Called by the manager loop
+func (m *Manager) controllerInfos() []controllerInfo {
 	infos := []controllerInfo{}
 	for _, controller := range m.controllers {
-		infos = append(infos, controllerInfo{portName: controller.SerialPortPath, name: controller.Name})
+		name := controller.name()
+		infos = append(infos, controllerInfo{
+			portName: controller.SerialPortPath,
+			stableID: controller.StableID,
+			name:     name,
+			state:    controller.state(),
+			labels:   m.labelsFor(name),
+		})
 	}
 	return infos
 }
 
-func (m *Manager) readFromController(portName string) string {
-	answerChan := make(chan string)
+// controllerForTarget returns the controller target names. Called by the manager loop
+func (m *Manager) controllerForTarget(target controllerTarget) (*controller, error) {
+	matching := []*controller{}
+	for _, controller := range m.controllers {
+		if target.matches(controllerInfo{portName: controller.SerialPortPath, stableID: controller.StableID, name: controller.name()}) {
+			matching = append(matching, controller)
+		}
+	}
+
+	switch len(matching) {
+	case 0:
+		return nil, &ControllerNotFoundError{PortName: target.String()}
+	case 1:
+		return matching[0], nil
+	default:
+		return nil, &UnsupportedError{
+			PortName: target.String(),
+			Reason:   fmt.Sprintf("%d controllers are named %s, use their port names instead", len(matching), target.name),
+		}
+	}
+}
+
+func (m *Manager) readFromController(portName string) (string, error) {
+	answerChan := make(chan readOutputAnswer)
 	message := readOutputMessage{answerChan: answerChan, portName: portName}
 	m.readOutputChan <- message
-	return <-answerChan
+	answer := <-answerChan
+	return answer.output, answer.err
 }
 
 func (m *Manager) flashController(portName string, hexFileContent []byte) flashAnswer {
+	return m.flashControllerWithProgress(portName, hexFileContent, nil)
+}
+
+// flashControllerWithProgress calls progress while flashing if the flasher supports it.
+// progress must not block, it may still be called after the flash timed out
+func (m *Manager) flashControllerWithProgress(portName string, hexFileContent []byte, progress func(FlashProgress)) flashAnswer {
+	flashSize := 0
+	if sizer, ok := m.flasher.(flashSizer); ok {
+		flashSize = sizer.flashSize(portName)
+	}
+	if _, err := parseIntelHex(hexFileContent, flashSize); err != nil {
+		return flashAnswer{Error: err}
+	}
+
 	answerChan := make(chan flashAnswer)
-	message := flashMessage{answerChan: answerChan, portName: portName, hexFileContent: hexFileContent}
+	message := flashMessage{answerChan: answerChan, portName: portName, hexFileContent: hexFileContent, progress: progress}
 	m.flashChan <- message
 	return <-answerChan
 }
 
-func (m *Manager) readContinuouslyFromController(portName string) chan []byte {
-	answerChan := make(chan chan []byte)
+func (m *Manager) runFlash(controller *controller, message flashMessage) {
+	output, timedOut, err := controller.runFlash(m.flasher, message.hexFileContent, message.progress)
+	m.flashDoneChan <- flashDoneMessage{
+		controller: controller,
+		answer:     flashAnswer{Error: err, Output: output, StableID: controller.StableID},
+		timedOut:   timedOut,
+		answerChan: message.answerChan,
+	}
+}
+
+func (m *Manager) readContinuouslyFromController(portName string) (chan outputLine, error) {
+	answerChan := make(chan readContinuousAnswer)
 	message := readContinuousMessage{answerChan: answerChan, portName: portName}
 	m.readContinuousChan <- message
-	return <-answerChan
+	answer := <-answerChan
+	return answer.notifierChan, answer.err
 }
 
 func (m *Manager) stopReadingFromController(portName string) {
@@ -214,19 +447,85 @@ func (m *Manager) stopReadingFromController(portName string) {
 	m.stopReadingChan <- message
 }
 
-func (m *Manager) setControllerName(portName string, name string) {
-	message := nameControllerMessage{portName: portName, name: name}
-	m.nameControllerChan <- message
+// observeController returns a channel receiving every line the controller at portName outputs, until stopObservingController is called
+// or the controller is detached
+func (m *Manager) observeController(portName string) (chan outputLine, error) {
+	answerChan := make(chan observeAnswer)
+	m.observeChan <- observeMessage{portName: portName, answerChan: answerChan}
+	answer := <-answerChan
+	return answer.observer, answer.err
 }
 
-func (m *Manager) writeToController(controllerPortName string, message []byte) error {
+// resumeController observes the controller at portName like observeController and returns the kept lines from sequence on.
+// missed tells how many lines from sequence on are not kept anymore. Lines may be both in the history and sent to the observer.
+// Sequence 0 returns no history, only new lines are observed then
+func (m *Manager) resumeController(portName string, sequence uint64) (observer chan outputLine, history []outputLine, missed uint64, err error) {
+	answerChan := make(chan observeAnswer)
+	m.observeChan <- observeMessage{portName: portName, answerChan: answerChan, withHistoryFrom: sequence}
+	answer := <-answerChan
+	return answer.observer, answer.history, answer.missed, answer.err
+}
+
+func (m *Manager) stopObservingController(portName string, observer chan outputLine) {
+	m.stopObservingChan <- stopObservingMessage{portName: portName, observer: observer}
+}
+
+func (m *Manager) setControllerName(portName string, name string) error {
 	doneChan := make(chan error)
+	m.nameControllerChan <- nameControllerMessage{portName: portName, name: name, doneChan: doneChan}
+	return <-doneChan
+}
+
+// setControllerLabels replaces the labels of the controller at portName. They are kept by its name, so they survive replugging it
+func (m *Manager) setControllerLabels(portName string, labels map[string]string) error {
+	doneChan := make(chan error)
+	m.labelControllerChan <- labelControllerMessage{portName: portName, labels: labels, doneChan: doneChan}
+	return <-doneChan
+}
+
+func (m *Manager) setLabels(name string, labels map[string]string) {
+	m.labelsMutex.Lock()
+	defer m.labelsMutex.Unlock()
+
+	if len(labels) == 0 {
+		delete(m.labelsByName, name)
+		return
+	}
+	m.labelsByName[name] = labels
+}
+
+// labelsFor returns the labels of the controller named name. They must not be modified
+func (m *Manager) labelsFor(name string) map[string]string {
+	m.labelsMutex.Lock()
+	defer m.labelsMutex.Unlock()
+
+	return m.labelsByName[name]
+}
+
+// observeAllControllers returns a channel receiving the lines of every controller, including the ones attached later
+func (m *Manager) observeAllControllers() chan outputLine {
+	return m.allObservers.add()
+}
+
+func (m *Manager) stopObservingAllControllers(observer chan outputLine) {
+	m.allObservers.remove(observer)
+}
+
+func (m *Manager) writeToController(controllerPortName string, message []byte) error {
+	_, err := m.writeToTarget(controllerTarget{portName: controllerPortName}, message)
+	return err
+}
+
+// writeToTarget writes message to the controller target names and returns its port name
+func (m *Manager) writeToTarget(target controllerTarget, message []byte) (portName string, err error) {
+	answerChan := make(chan writeToControllerAnswer)
 	m.writeToControllerChan <- writeToControllerMessage{
-		portName: controllerPortName,
-		message:  message,
-		doneChan: doneChan,
+		target:     target,
+		message:    message,
+		answerChan: answerChan,
 	}
-	return <-doneChan
+	answer := <-answerChan
+	return answer.portName, answer.err
 }
 
 func (m *Manager) writeToControllerContinuously(controllerPortName string, writeChan chan []byte) writeToControllerContinuouslyAnswerMessage {
@@ -240,6 +539,18 @@ func (m *Manager) writeToControllerContinuously(controllerPortName string, write
 	return <-answerChan
 }
 
+// ErrDiscoveryNotExplainable is returned by explainDiscovery if the discovery backend can't explain its decisions
+var ErrDiscoveryNotExplainable = errors.New("the discovery backend can't explain its decisions")
+
+func (m *Manager) explainDiscovery() ([]DiscoveryCandidate, error) {
+	explainer, ok := m.discoverer.(discoveryExplainer)
+	if !ok {
+		return nil, ErrDiscoveryNotExplainable
+	}
+
+	return explainer.explain()
+}
+
 func (m *Manager) controllerForPort(portName string) *controller {
 	for _, controller := range m.controllers {
 		if controller.SerialPortPath == portName {
@@ -250,19 +561,6 @@ func (m *Manager) controllerForPort(portName string) *controller {
 	return nil
 }
 
-func (m *Manager) lookForNewPorts() {
-	t := time.NewTicker(time.Second)
-	for {
-		<-t.C
-		ports, err := discoverAttachedControllers()
-		if err != nil {
-			panic(err)
-		}
-
-		m.currentPortsChan <- ports
-	}
-}
-
 func (m *Manager) pingWithTimeout(timeout time.Duration) error {
 	return withTimeOut(timeout, func() {
 		pongChan := make(chan struct{})
@@ -273,6 +571,18 @@ func (m *Manager) pingWithTimeout(timeout time.Duration) error {
 	})
 }
 
+// reconnectErroredControllers starts reading again from controllers that stopped because of an error.
+// It runs on every discovery and every reconnectInterval, since the uevent discovery only rescans on hotplugs and rarely otherwise
+func (m *Manager) reconnectErroredControllers() {
+	for _, controller := range m.controllers {
+		// the flasher has the port of a flashing controller, it reads again once the flash is done
+		if err := controller.disconnectedBy(); err != nil {
+			log.Println("reconnecting to", controller.SerialPortPath, "after:", err)
+			controller.startReading()
+		}
+	}
+}
+
 func (m *Manager) handleCurrentPorts(currentPorts []string) {
 	newPorts := []string{}
 	for _, port := range currentPorts {
@@ -288,18 +598,14 @@ func (m *Manager) handleCurrentPorts(currentPorts []string) {
 		}
 	}
 
+	m.reconnectErroredControllers()
+
 	for _, newPort := range newPorts {
 		log.Println("discovered new port: ", newPort)
-		controller := newController(newPort)
+		controller := newController(newPort, m.byIDDirectory, m.openTransport, m.allObservers)
 		controller.handleVerbMessage = m.VerbMessageHandler
-		go func() {
-			defer func() {
-				if r := recover(); r != nil {
-					log.Println(r)
-				}
-			}()
-			controller.readFromSerial()
-		}()
+		controller.recordDirectory = m.recordDirectory
+		controller.startReading()
 		m.controllers = append(m.controllers, controller)
 	}
 
@@ -318,7 +624,8 @@ func (m *Manager) handleCurrentPorts(currentPorts []string) {
 	}
 
 	for _, removed := range removedControllers {
-		removed.closeSerial()
+		removed.closeTransport()
+		removed.closeObservers()
 	}
 
 	currentControllers := []*controller{}
@@ -7,11 +7,13 @@ import (
 	"github.com/alexmorten/mhist/models"
 	"github.com/alexmorten/mhist/proto"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
 )
 
 // MhistConnector reads new messages from mhist and distributes them to the correct controller
 type MhistConnector struct {
 	manager     *Manager
+	conn        *grpc.ClientConn
 	client      proto.MhistClient
 	filter      *proto.Filter
 	writeStream proto.Mhist_StoreStreamClient
@@ -30,12 +32,18 @@ func NewMhistConnector(address string, filter *proto.Filter, manager *Manager) (
 	}
 	return &MhistConnector{
 		manager:     manager,
+		conn:        conn,
 		client:      c,
 		filter:      filter,
 		writeStream: stream,
 	}, nil
 }
 
+// Connected tells whether the connection to mhist is currently up
+func (c *MhistConnector) Connected() bool {
+	return c.conn.GetState() == connectivity.Ready
+}
+
 // WriteMessage to mhist
 func (c *MhistConnector) WriteMessage(verb string, message string) {
 	err := c.writeStream.Send(&proto.MeasurementMessage{
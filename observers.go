@@ -0,0 +1,75 @@
+package nervo
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// outputLine is a line read from a controller, passed to everyone observing it
+type outputLine struct {
+	sequence uint64
+	portName string
+	name     string
+	time     time.Time
+	data     []byte
+}
+
+// outputObservers passes lines on to channels without ever blocking the reading from the controller.
+// Observers that fall behind miss lines
+type outputObservers struct {
+	mutex      *sync.Mutex
+	observers  map[chan outputLine]struct{}
+	bufferSize int
+}
+
+func newOutputObservers(bufferSize int) *outputObservers {
+	return &outputObservers{
+		mutex:      &sync.Mutex{},
+		observers:  map[chan outputLine]struct{}{},
+		bufferSize: bufferSize,
+	}
+}
+
+// add returns a channel receiving every line from now on
+func (o *outputObservers) add() chan outputLine {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	observer := make(chan outputLine, o.bufferSize)
+	o.observers[observer] = struct{}{}
+	return observer
+}
+
+func (o *outputObservers) remove(observer chan outputLine) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if _, ok := o.observers[observer]; ok {
+		delete(o.observers, observer)
+		close(observer)
+	}
+}
+
+func (o *outputObservers) removeAll() {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	for observer := range o.observers {
+		close(observer)
+	}
+	o.observers = map[chan outputLine]struct{}{}
+}
+
+func (o *outputObservers) notify(line outputLine) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	for observer := range o.observers {
+		select {
+		case observer <- line:
+		default:
+			log.Println("an observer of", line.portName, "fell behind, dropping a line")
+		}
+	}
+}
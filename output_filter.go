@@ -0,0 +1,109 @@
+package nervo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/codeuniversity/nervo/proto"
+)
+
+// outputFilter decides which output lines are streamed to a client, so only those go over the network
+type outputFilter struct {
+	// verbs lets only lines through that start with one of them, e.g. "feedback"
+	verbs   map[string]bool
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+	// sampleEveryNth lets only every nth line per controller through that passed the other filters
+	sampleEveryNth uint64
+	passedByPort   map[string]uint64
+}
+
+// outputFilterFromProto returns nil if filter doesn't filter anything. A nil *outputFilter lets every line through
+func outputFilterFromProto(filter *proto.OutputFilter) (*outputFilter, error) {
+	if filter == nil || (len(filter.Verbs) == 0 && len(filter.Include) == 0 && len(filter.Exclude) == 0 && filter.SampleEveryNth <= 1) {
+		return nil, nil
+	}
+
+	f := &outputFilter{
+		verbs:          map[string]bool{},
+		sampleEveryNth: uint64(filter.SampleEveryNth),
+		passedByPort:   map[string]uint64{},
+	}
+	for _, verb := range filter.Verbs {
+		f.verbs[strings.ToLower(verb)] = true
+	}
+	var err error
+	if f.include, err = compileFilterPatterns(filter.Include); err != nil {
+		return nil, err
+	}
+	if f.exclude, err = compileFilterPatterns(filter.Exclude); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func compileFilterPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		r, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter pattern %q: %v", pattern, err)
+		}
+		compiled = append(compiled, r)
+	}
+	return compiled, nil
+}
+
+// passes if the line of the controller at portName should be streamed.
+// A line passes if it has one of the verbs, matches any include and no exclude pattern and is picked by the sampling
+func (f *outputFilter) passes(portName string, line []byte) bool {
+	if f == nil {
+		return true
+	}
+
+	text := removeNewLineChars(string(line))
+	if len(f.verbs) > 0 {
+		verb := strings.ToLower(strings.SplitN(text, " ", 2)[0])
+		if !f.verbs[verb] {
+			return false
+		}
+	}
+	if len(f.include) > 0 && !anyMatches(f.include, text) {
+		return false
+	}
+	if anyMatches(f.exclude, text) {
+		return false
+	}
+
+	if f.sampleEveryNth > 1 {
+		passed := f.passedByPort[portName]
+		f.passedByPort[portName] = passed + 1
+		return passed%f.sampleEveryNth == 0
+	}
+	return true
+}
+
+// filterOutput filters the lines of output read from the controller at portName at once
+func (f *outputFilter) filterOutput(portName, output string) string {
+	if f == nil {
+		return output
+	}
+
+	filtered := ""
+	for _, line := range strings.SplitAfter(output, "\n") {
+		if line != "" && f.passes(portName, []byte(line)) {
+			filtered += line
+		}
+	}
+	return filtered
+}
+
+func anyMatches(patterns []*regexp.Regexp, text string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
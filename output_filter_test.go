@@ -0,0 +1,57 @@
+package nervo
+
+import (
+	"testing"
+
+	"github.com/codeuniversity/nervo/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_outputFilter_passes(t *testing.T) {
+	tests := []struct {
+		testMessage string
+		filter      *proto.OutputFilter
+		line        string
+		passes      bool
+	}{
+		{"no filter", nil, "sensor_data 12\n", true},
+		{"verb", &proto.OutputFilter{Verbs: []string{"feedback"}}, "FEEDBACK done\n", true},
+		{"other verb", &proto.OutputFilter{Verbs: []string{"feedback"}}, "sensor_data 12\n", false},
+		{"include", &proto.OutputFilter{Include: []string{"^error", "fail"}}, "step failed\n", true},
+		{"not included", &proto.OutputFilter{Include: []string{"^error"}}, "step done\n", false},
+		{"exclude", &proto.OutputFilter{Exclude: []string{"\\d+$"}}, "sensor_data 12\n", false},
+		{"verb and exclude", &proto.OutputFilter{Verbs: []string{"feedback"}, Exclude: []string{"debug"}}, "feedback debug on\n", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testMessage, func(t *testing.T) {
+			filter, err := outputFilterFromProto(test.filter)
+			require.NoError(t, err)
+			assert.Equal(t, test.passes, filter.passes("/dev/ttyACM0", []byte(test.line)))
+		})
+	}
+
+	t.Run("samples every nth line per controller", func(t *testing.T) {
+		filter, err := outputFilterFromProto(&proto.OutputFilter{Verbs: []string{"sensor_data"}, SampleEveryNth: 2})
+		require.NoError(t, err)
+
+		passed := []bool{}
+		for _, line := range []string{"sensor_data 1\n", "feedback done\n", "sensor_data 2\n", "sensor_data 3\n"} {
+			passed = append(passed, filter.passes("/dev/ttyACM0", []byte(line)))
+		}
+		assert.Equal(t, []bool{true, false, false, true}, passed)
+		assert.True(t, filter.passes("/dev/ttyACM1", []byte("sensor_data 1\n")))
+	})
+
+	t.Run("filters buffered output line by line", func(t *testing.T) {
+		filter, err := outputFilterFromProto(&proto.OutputFilter{Verbs: []string{"feedback"}})
+		require.NoError(t, err)
+		assert.Equal(t, "feedback done\n", filter.filterOutput("/dev/ttyACM0", "sensor_data 1\nfeedback done\nsensor_data 2\n"))
+	})
+
+	t.Run("an invalid pattern is rejected", func(t *testing.T) {
+		_, err := outputFilterFromProto(&proto.OutputFilter{Include: []string{"("}})
+		assert.Error(t, err)
+	})
+}
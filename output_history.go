@@ -0,0 +1,59 @@
+package nervo
+
+import (
+	"sync"
+	"time"
+)
+
+const outputHistoryLength = 1000
+
+// outputHistory numbers the lines of a controller and keeps the last of them, so streams can resume where they stopped
+type outputHistory struct {
+	mutex *sync.Mutex
+	// lines is a ring buffer, the line with sequence s is at (s-1) % len(lines)
+	lines        []outputLine
+	nextSequence uint64
+}
+
+func newOutputHistory(length int) *outputHistory {
+	return &outputHistory{
+		mutex:        &sync.Mutex{},
+		lines:        make([]outputLine, length),
+		nextSequence: 1,
+	}
+}
+
+// append numbers the line and keeps it
+func (h *outputHistory) append(portName, name string, data []byte) outputLine {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	line := outputLine{sequence: h.nextSequence, portName: portName, name: name, time: time.Now(), data: data}
+	h.lines[(line.sequence-1)%uint64(len(h.lines))] = line
+	h.nextSequence++
+	return line
+}
+
+// since returns the kept lines from sequence on and how many lines from sequence on were already evicted
+func (h *outputHistory) since(sequence uint64) (lines []outputLine, missed uint64) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if sequence == 0 || sequence > h.nextSequence {
+		// a sequence beyond the next one comes from an earlier numbering, e.g. before the controller was replugged
+		sequence = 1
+	}
+	oldest := uint64(1)
+	if h.nextSequence > uint64(len(h.lines)) {
+		oldest = h.nextSequence - uint64(len(h.lines))
+	}
+	if sequence < oldest {
+		missed = oldest - sequence
+		sequence = oldest
+	}
+
+	for s := sequence; s < h.nextSequence; s++ {
+		lines = append(lines, h.lines[(s-1)%uint64(len(h.lines))])
+	}
+	return lines, missed
+}
@@ -0,0 +1,46 @@
+package nervo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_outputHistory_since(t *testing.T) {
+	history := newOutputHistory(3)
+	for _, line := range []string{"a", "b", "c", "d", "e"} {
+		history.append("/dev/ttyACM0", "leg1", []byte(line))
+	}
+
+	sequences := func(lines []outputLine) []uint64 {
+		result := []uint64{}
+		for _, line := range lines {
+			result = append(result, line.sequence)
+		}
+		return result
+	}
+
+	tests := []struct {
+		testMessage       string
+		sequence          uint64
+		expectedSequences []uint64
+		expectedMissed    uint64
+	}{
+		{"from the beginning", 0, []uint64{3, 4, 5}, 2},
+		{"from an evicted line", 2, []uint64{3, 4, 5}, 1},
+		{"from a kept line", 4, []uint64{4, 5}, 0},
+		{"from the next line", 6, []uint64{}, 0},
+		{"from an earlier numbering", 10, []uint64{3, 4, 5}, 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testMessage, func(t *testing.T) {
+			lines, missed := history.since(test.sequence)
+			assert.Equal(t, test.expectedSequences, sequences(lines))
+			assert.Equal(t, test.expectedMissed, missed)
+		})
+	}
+
+	lines, _ := history.since(5)
+	assert.Equal(t, "e", string(lines[0].data))
+}
@@ -3,13 +3,14 @@
 
 package proto
 
-import proto "github.com/golang/protobuf/proto"
-import fmt "fmt"
-import math "math"
-
 import (
-	context "golang.org/x/net/context"
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
 	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
 )
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -21,11 +22,161 @@ var _ = math.Inf
 // is compatible with the proto package it is being compiled against.
 // A compilation error at this line likely means your copy of the
 // proto package needs to be updated.
-const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+// FirmwareFormat of an image to flash, it is converted to Intel HEX on the server
+type FirmwareFormat int32
+
+const (
+	// tells HEX and ELF apart by the content
+	FirmwareFormat_AUTO FirmwareFormat = 0
+	FirmwareFormat_HEX  FirmwareFormat = 1
+	// the loadable segments are flashed
+	FirmwareFormat_ELF FirmwareFormat = 2
+	// raw image, written from base_address on
+	FirmwareFormat_BIN FirmwareFormat = 3
+)
+
+var FirmwareFormat_name = map[int32]string{
+	0: "AUTO",
+	1: "HEX",
+	2: "ELF",
+	3: "BIN",
+}
+
+var FirmwareFormat_value = map[string]int32{
+	"AUTO": 0,
+	"HEX":  1,
+	"ELF":  2,
+	"BIN":  3,
+}
+
+func (x FirmwareFormat) String() string {
+	return proto.EnumName(FirmwareFormat_name, int32(x))
+}
+
+func (FirmwareFormat) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{0}
+}
+
+type FlashReport_Failure int32
+
+const (
+	FlashReport_NONE    FlashReport_Failure = 0
+	FlashReport_UNKNOWN FlashReport_Failure = 1
+	// the bootloader didn't answer, e.g. because the board wasn't reset or the baud rate is wrong
+	FlashReport_NOT_IN_SYNC        FlashReport_Failure = 2
+	FlashReport_WRONG_SIGNATURE    FlashReport_Failure = 3
+	FlashReport_PERMISSION_DENIED  FlashReport_Failure = 4
+	FlashReport_TIMEOUT            FlashReport_Failure = 5
+	FlashReport_VERIFICATION_ERROR FlashReport_Failure = 6
+)
+
+var FlashReport_Failure_name = map[int32]string{
+	0: "NONE",
+	1: "UNKNOWN",
+	2: "NOT_IN_SYNC",
+	3: "WRONG_SIGNATURE",
+	4: "PERMISSION_DENIED",
+	5: "TIMEOUT",
+	6: "VERIFICATION_ERROR",
+}
+
+var FlashReport_Failure_value = map[string]int32{
+	"NONE":               0,
+	"UNKNOWN":            1,
+	"NOT_IN_SYNC":        2,
+	"WRONG_SIGNATURE":    3,
+	"PERMISSION_DENIED":  4,
+	"TIMEOUT":            5,
+	"VERIFICATION_ERROR": 6,
+}
+
+func (x FlashReport_Failure) String() string {
+	return proto.EnumName(FlashReport_Failure_name, int32(x))
+}
+
+func (FlashReport_Failure) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{12, 0}
+}
+
+type FlashProgress_Phase int32
+
+const (
+	FlashProgress_UNKNOWN FlashProgress_Phase = 0
+	FlashProgress_ERASE   FlashProgress_Phase = 1
+	FlashProgress_WRITE   FlashProgress_Phase = 2
+	FlashProgress_VERIFY  FlashProgress_Phase = 3
+	// the last message of the stream, if flashing succeeded
+	FlashProgress_DONE FlashProgress_Phase = 4
+)
+
+var FlashProgress_Phase_name = map[int32]string{
+	0: "UNKNOWN",
+	1: "ERASE",
+	2: "WRITE",
+	3: "VERIFY",
+	4: "DONE",
+}
+
+var FlashProgress_Phase_value = map[string]int32{
+	"UNKNOWN": 0,
+	"ERASE":   1,
+	"WRITE":   2,
+	"VERIFY":  3,
+	"DONE":    4,
+}
+
+func (x FlashProgress_Phase) String() string {
+	return proto.EnumName(FlashProgress_Phase_name, int32(x))
+}
+
+func (FlashProgress_Phase) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{30, 0}
+}
+
+type SessionEvent_Type int32
+
+const (
+	SessionEvent_OUTPUT SessionEvent_Type = 0
+	SessionEvent_ECHO   SessionEvent_Type = 1
+	SessionEvent_OPENED SessionEvent_Type = 2
+	SessionEvent_ERROR  SessionEvent_Type = 3
+	SessionEvent_CLOSED SessionEvent_Type = 4
+)
+
+var SessionEvent_Type_name = map[int32]string{
+	0: "OUTPUT",
+	1: "ECHO",
+	2: "OPENED",
+	3: "ERROR",
+	4: "CLOSED",
+}
+
+var SessionEvent_Type_value = map[string]int32{
+	"OUTPUT": 0,
+	"ECHO":   1,
+	"OPENED": 2,
+	"ERROR":  3,
+	"CLOSED": 4,
+}
+
+func (x SessionEvent_Type) String() string {
+	return proto.EnumName(SessionEvent_Type_name, int32(x))
+}
+
+func (SessionEvent_Type) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{44, 0}
+}
 
 type ControllerInfo struct {
-	PortName             string   `protobuf:"bytes,1,opt,name=portName,proto3" json:"portName,omitempty"`
-	Name                 string   `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	PortName string `protobuf:"bytes,1,opt,name=portName,proto3" json:"portName,omitempty"`
+	Name     string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// connecting, connected, disconnected, flashing or finished (a replay that played all of its events). Ignored when renaming
+	State  string            `protobuf:"bytes,3,opt,name=state,proto3" json:"state,omitempty"`
+	Labels map[string]string `protobuf:"bytes,4,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// the /dev/serial/by-id link of the port if there is one, the port name otherwise. Ignored when renaming
+	StableId             string   `protobuf:"bytes,5,opt,name=stable_id,json=stableId,proto3" json:"stable_id,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -35,16 +186,17 @@ func (m *ControllerInfo) Reset()         { *m = ControllerInfo{} }
 func (m *ControllerInfo) String() string { return proto.CompactTextString(m) }
 func (*ControllerInfo) ProtoMessage()    {}
 func (*ControllerInfo) Descriptor() ([]byte, []int) {
-	return fileDescriptor_protocol_3860ec6f7903b783, []int{0}
+	return fileDescriptor_dc3f62d3478b1775, []int{0}
 }
+
 func (m *ControllerInfo) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_ControllerInfo.Unmarshal(m, b)
 }
 func (m *ControllerInfo) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
 	return xxx_messageInfo_ControllerInfo.Marshal(b, m, deterministic)
 }
-func (dst *ControllerInfo) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_ControllerInfo.Merge(dst, src)
+func (m *ControllerInfo) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ControllerInfo.Merge(m, src)
 }
 func (m *ControllerInfo) XXX_Size() int {
 	return xxx_messageInfo_ControllerInfo.Size(m)
@@ -69,6 +221,316 @@ func (m *ControllerInfo) GetName() string {
 	return ""
 }
 
+func (m *ControllerInfo) GetState() string {
+	if m != nil {
+		return m.State
+	}
+	return ""
+}
+
+func (m *ControllerInfo) GetLabels() map[string]string {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
+func (m *ControllerInfo) GetStableId() string {
+	if m != nil {
+		return m.StableId
+	}
+	return ""
+}
+
+type SetControllerLabelsRequest struct {
+	ControllerPortName string `protobuf:"bytes,1,opt,name=controller_port_name,json=controllerPortName,proto3" json:"controller_port_name,omitempty"`
+	// replace all labels of the controller
+	Labels               map[string]string `protobuf:"bytes,2,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *SetControllerLabelsRequest) Reset()         { *m = SetControllerLabelsRequest{} }
+func (m *SetControllerLabelsRequest) String() string { return proto.CompactTextString(m) }
+func (*SetControllerLabelsRequest) ProtoMessage()    {}
+func (*SetControllerLabelsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{1}
+}
+
+func (m *SetControllerLabelsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetControllerLabelsRequest.Unmarshal(m, b)
+}
+func (m *SetControllerLabelsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetControllerLabelsRequest.Marshal(b, m, deterministic)
+}
+func (m *SetControllerLabelsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetControllerLabelsRequest.Merge(m, src)
+}
+func (m *SetControllerLabelsRequest) XXX_Size() int {
+	return xxx_messageInfo_SetControllerLabelsRequest.Size(m)
+}
+func (m *SetControllerLabelsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetControllerLabelsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetControllerLabelsRequest proto.InternalMessageInfo
+
+func (m *SetControllerLabelsRequest) GetControllerPortName() string {
+	if m != nil {
+		return m.ControllerPortName
+	}
+	return ""
+}
+
+func (m *SetControllerLabelsRequest) GetLabels() map[string]string {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
+// ControllerSelector matches controllers by any of port names, names or labels. Labels only match if the controller has all of them
+type ControllerSelector struct {
+	All                  bool              `protobuf:"varint,1,opt,name=all,proto3" json:"all,omitempty"`
+	PortNames            []string          `protobuf:"bytes,2,rep,name=port_names,json=portNames,proto3" json:"port_names,omitempty"`
+	Names                []string          `protobuf:"bytes,3,rep,name=names,proto3" json:"names,omitempty"`
+	Labels               map[string]string `protobuf:"bytes,4,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *ControllerSelector) Reset()         { *m = ControllerSelector{} }
+func (m *ControllerSelector) String() string { return proto.CompactTextString(m) }
+func (*ControllerSelector) ProtoMessage()    {}
+func (*ControllerSelector) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{2}
+}
+
+func (m *ControllerSelector) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ControllerSelector.Unmarshal(m, b)
+}
+func (m *ControllerSelector) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ControllerSelector.Marshal(b, m, deterministic)
+}
+func (m *ControllerSelector) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ControllerSelector.Merge(m, src)
+}
+func (m *ControllerSelector) XXX_Size() int {
+	return xxx_messageInfo_ControllerSelector.Size(m)
+}
+func (m *ControllerSelector) XXX_DiscardUnknown() {
+	xxx_messageInfo_ControllerSelector.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ControllerSelector proto.InternalMessageInfo
+
+func (m *ControllerSelector) GetAll() bool {
+	if m != nil {
+		return m.All
+	}
+	return false
+}
+
+func (m *ControllerSelector) GetPortNames() []string {
+	if m != nil {
+		return m.PortNames
+	}
+	return nil
+}
+
+func (m *ControllerSelector) GetNames() []string {
+	if m != nil {
+		return m.Names
+	}
+	return nil
+}
+
+func (m *ControllerSelector) GetLabels() map[string]string {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
+// OutputFilter is applied by the server before lines are streamed. Empty fields don't filter
+type OutputFilter struct {
+	// lines starting with one of the verbs pass, e.g. "feedback"
+	Verbs []string `protobuf:"bytes,1,rep,name=verbs,proto3" json:"verbs,omitempty"`
+	// regular expressions, lines matching any include and no exclude pattern pass
+	Include []string `protobuf:"bytes,2,rep,name=include,proto3" json:"include,omitempty"`
+	Exclude []string `protobuf:"bytes,3,rep,name=exclude,proto3" json:"exclude,omitempty"`
+	// only every nth line per controller passes that passed the other filters
+	SampleEveryNth       uint32   `protobuf:"varint,4,opt,name=sample_every_nth,json=sampleEveryNth,proto3" json:"sample_every_nth,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *OutputFilter) Reset()         { *m = OutputFilter{} }
+func (m *OutputFilter) String() string { return proto.CompactTextString(m) }
+func (*OutputFilter) ProtoMessage()    {}
+func (*OutputFilter) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{3}
+}
+
+func (m *OutputFilter) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_OutputFilter.Unmarshal(m, b)
+}
+func (m *OutputFilter) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_OutputFilter.Marshal(b, m, deterministic)
+}
+func (m *OutputFilter) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_OutputFilter.Merge(m, src)
+}
+func (m *OutputFilter) XXX_Size() int {
+	return xxx_messageInfo_OutputFilter.Size(m)
+}
+func (m *OutputFilter) XXX_DiscardUnknown() {
+	xxx_messageInfo_OutputFilter.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_OutputFilter proto.InternalMessageInfo
+
+func (m *OutputFilter) GetVerbs() []string {
+	if m != nil {
+		return m.Verbs
+	}
+	return nil
+}
+
+func (m *OutputFilter) GetInclude() []string {
+	if m != nil {
+		return m.Include
+	}
+	return nil
+}
+
+func (m *OutputFilter) GetExclude() []string {
+	if m != nil {
+		return m.Exclude
+	}
+	return nil
+}
+
+func (m *OutputFilter) GetSampleEveryNth() uint32 {
+	if m != nil {
+		return m.SampleEveryNth
+	}
+	return 0
+}
+
+type ReadMultiplexedOutputRequest struct {
+	Selector             *ControllerSelector `protobuf:"bytes,1,opt,name=selector,proto3" json:"selector,omitempty"`
+	Filter               *OutputFilter       `protobuf:"bytes,2,opt,name=filter,proto3" json:"filter,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
+	XXX_unrecognized     []byte              `json:"-"`
+	XXX_sizecache        int32               `json:"-"`
+}
+
+func (m *ReadMultiplexedOutputRequest) Reset()         { *m = ReadMultiplexedOutputRequest{} }
+func (m *ReadMultiplexedOutputRequest) String() string { return proto.CompactTextString(m) }
+func (*ReadMultiplexedOutputRequest) ProtoMessage()    {}
+func (*ReadMultiplexedOutputRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{4}
+}
+
+func (m *ReadMultiplexedOutputRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReadMultiplexedOutputRequest.Unmarshal(m, b)
+}
+func (m *ReadMultiplexedOutputRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReadMultiplexedOutputRequest.Marshal(b, m, deterministic)
+}
+func (m *ReadMultiplexedOutputRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReadMultiplexedOutputRequest.Merge(m, src)
+}
+func (m *ReadMultiplexedOutputRequest) XXX_Size() int {
+	return xxx_messageInfo_ReadMultiplexedOutputRequest.Size(m)
+}
+func (m *ReadMultiplexedOutputRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReadMultiplexedOutputRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReadMultiplexedOutputRequest proto.InternalMessageInfo
+
+func (m *ReadMultiplexedOutputRequest) GetSelector() *ControllerSelector {
+	if m != nil {
+		return m.Selector
+	}
+	return nil
+}
+
+func (m *ReadMultiplexedOutputRequest) GetFilter() *OutputFilter {
+	if m != nil {
+		return m.Filter
+	}
+	return nil
+}
+
+type MultiplexedOutputLine struct {
+	ControllerPortName   string   `protobuf:"bytes,1,opt,name=controller_port_name,json=controllerPortName,proto3" json:"controller_port_name,omitempty"`
+	ControllerName       string   `protobuf:"bytes,2,opt,name=controller_name,json=controllerName,proto3" json:"controller_name,omitempty"`
+	Line                 string   `protobuf:"bytes,3,opt,name=line,proto3" json:"line,omitempty"`
+	TimeUnixNano         int64    `protobuf:"varint,4,opt,name=time_unix_nano,json=timeUnixNano,proto3" json:"time_unix_nano,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MultiplexedOutputLine) Reset()         { *m = MultiplexedOutputLine{} }
+func (m *MultiplexedOutputLine) String() string { return proto.CompactTextString(m) }
+func (*MultiplexedOutputLine) ProtoMessage()    {}
+func (*MultiplexedOutputLine) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{5}
+}
+
+func (m *MultiplexedOutputLine) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MultiplexedOutputLine.Unmarshal(m, b)
+}
+func (m *MultiplexedOutputLine) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MultiplexedOutputLine.Marshal(b, m, deterministic)
+}
+func (m *MultiplexedOutputLine) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MultiplexedOutputLine.Merge(m, src)
+}
+func (m *MultiplexedOutputLine) XXX_Size() int {
+	return xxx_messageInfo_MultiplexedOutputLine.Size(m)
+}
+func (m *MultiplexedOutputLine) XXX_DiscardUnknown() {
+	xxx_messageInfo_MultiplexedOutputLine.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MultiplexedOutputLine proto.InternalMessageInfo
+
+func (m *MultiplexedOutputLine) GetControllerPortName() string {
+	if m != nil {
+		return m.ControllerPortName
+	}
+	return ""
+}
+
+func (m *MultiplexedOutputLine) GetControllerName() string {
+	if m != nil {
+		return m.ControllerName
+	}
+	return ""
+}
+
+func (m *MultiplexedOutputLine) GetLine() string {
+	if m != nil {
+		return m.Line
+	}
+	return ""
+}
+
+func (m *MultiplexedOutputLine) GetTimeUnixNano() int64 {
+	if m != nil {
+		return m.TimeUnixNano
+	}
+	return 0
+}
+
 type ControllerListRequest struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
@@ -79,16 +541,17 @@ func (m *ControllerListRequest) Reset()         { *m = ControllerListRequest{} }
 func (m *ControllerListRequest) String() string { return proto.CompactTextString(m) }
 func (*ControllerListRequest) ProtoMessage()    {}
 func (*ControllerListRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_protocol_3860ec6f7903b783, []int{1}
+	return fileDescriptor_dc3f62d3478b1775, []int{6}
 }
+
 func (m *ControllerListRequest) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_ControllerListRequest.Unmarshal(m, b)
 }
 func (m *ControllerListRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
 	return xxx_messageInfo_ControllerListRequest.Marshal(b, m, deterministic)
 }
-func (dst *ControllerListRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_ControllerListRequest.Merge(dst, src)
+func (m *ControllerListRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ControllerListRequest.Merge(m, src)
 }
 func (m *ControllerListRequest) XXX_Size() int {
 	return xxx_messageInfo_ControllerListRequest.Size(m)
@@ -110,16 +573,17 @@ func (m *ControllerListResponse) Reset()         { *m = ControllerListResponse{}
 func (m *ControllerListResponse) String() string { return proto.CompactTextString(m) }
 func (*ControllerListResponse) ProtoMessage()    {}
 func (*ControllerListResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_protocol_3860ec6f7903b783, []int{2}
+	return fileDescriptor_dc3f62d3478b1775, []int{7}
 }
+
 func (m *ControllerListResponse) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_ControllerListResponse.Unmarshal(m, b)
 }
 func (m *ControllerListResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
 	return xxx_messageInfo_ControllerListResponse.Marshal(b, m, deterministic)
 }
-func (dst *ControllerListResponse) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_ControllerListResponse.Merge(dst, src)
+func (m *ControllerListResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ControllerListResponse.Merge(m, src)
 }
 func (m *ControllerListResponse) XXX_Size() int {
 	return xxx_messageInfo_ControllerListResponse.Size(m)
@@ -138,26 +602,32 @@ func (m *ControllerListResponse) GetControllerInfos() []*ControllerInfo {
 }
 
 type ReadControllerOutputRequest struct {
-	ControllerPortName   string   `protobuf:"bytes,1,opt,name=controller_port_name,json=controllerPortName,proto3" json:"controller_port_name,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	ControllerPortName string `protobuf:"bytes,1,opt,name=controller_port_name,json=controllerPortName,proto3" json:"controller_port_name,omitempty"`
+	// continue at from_sequence instead of sending and draining the buffered output first.
+	// from_sequence 0 only streams new lines. Only for ReadControllerOutputContinuously
+	Resume               bool          `protobuf:"varint,2,opt,name=resume,proto3" json:"resume,omitempty"`
+	FromSequence         uint64        `protobuf:"varint,3,opt,name=from_sequence,json=fromSequence,proto3" json:"from_sequence,omitempty"`
+	Filter               *OutputFilter `protobuf:"bytes,4,opt,name=filter,proto3" json:"filter,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
 }
 
 func (m *ReadControllerOutputRequest) Reset()         { *m = ReadControllerOutputRequest{} }
 func (m *ReadControllerOutputRequest) String() string { return proto.CompactTextString(m) }
 func (*ReadControllerOutputRequest) ProtoMessage()    {}
 func (*ReadControllerOutputRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_protocol_3860ec6f7903b783, []int{3}
+	return fileDescriptor_dc3f62d3478b1775, []int{8}
 }
+
 func (m *ReadControllerOutputRequest) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_ReadControllerOutputRequest.Unmarshal(m, b)
 }
 func (m *ReadControllerOutputRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
 	return xxx_messageInfo_ReadControllerOutputRequest.Marshal(b, m, deterministic)
 }
-func (dst *ReadControllerOutputRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_ReadControllerOutputRequest.Merge(dst, src)
+func (m *ReadControllerOutputRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReadControllerOutputRequest.Merge(m, src)
 }
 func (m *ReadControllerOutputRequest) XXX_Size() int {
 	return xxx_messageInfo_ReadControllerOutputRequest.Size(m)
@@ -175,8 +645,36 @@ func (m *ReadControllerOutputRequest) GetControllerPortName() string {
 	return ""
 }
 
+func (m *ReadControllerOutputRequest) GetResume() bool {
+	if m != nil {
+		return m.Resume
+	}
+	return false
+}
+
+func (m *ReadControllerOutputRequest) GetFromSequence() uint64 {
+	if m != nil {
+		return m.FromSequence
+	}
+	return 0
+}
+
+func (m *ReadControllerOutputRequest) GetFilter() *OutputFilter {
+	if m != nil {
+		return m.Filter
+	}
+	return nil
+}
+
 type ReadControllerOutputResponse struct {
-	Output               string   `protobuf:"bytes,1,opt,name=output,proto3" json:"output,omitempty"`
+	Output string `protobuf:"bytes,1,opt,name=output,proto3" json:"output,omitempty"`
+	// of the line in output. 0 for buffered output, which isn't numbered
+	Sequence uint64 `protobuf:"varint,2,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	// when the line was read
+	TimeUnixNano int64 `protobuf:"varint,3,opt,name=time_unix_nano,json=timeUnixNano,proto3" json:"time_unix_nano,omitempty"`
+	// marks that the missed lines from sequence on are lost, output is empty then
+	Gap                  bool     `protobuf:"varint,4,opt,name=gap,proto3" json:"gap,omitempty"`
+	Missed               uint64   `protobuf:"varint,5,opt,name=missed,proto3" json:"missed,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -186,16 +684,17 @@ func (m *ReadControllerOutputResponse) Reset()         { *m = ReadControllerOutp
 func (m *ReadControllerOutputResponse) String() string { return proto.CompactTextString(m) }
 func (*ReadControllerOutputResponse) ProtoMessage()    {}
 func (*ReadControllerOutputResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_protocol_3860ec6f7903b783, []int{4}
+	return fileDescriptor_dc3f62d3478b1775, []int{9}
 }
+
 func (m *ReadControllerOutputResponse) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_ReadControllerOutputResponse.Unmarshal(m, b)
 }
 func (m *ReadControllerOutputResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
 	return xxx_messageInfo_ReadControllerOutputResponse.Marshal(b, m, deterministic)
 }
-func (dst *ReadControllerOutputResponse) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_ReadControllerOutputResponse.Merge(dst, src)
+func (m *ReadControllerOutputResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReadControllerOutputResponse.Merge(m, src)
 }
 func (m *ReadControllerOutputResponse) XXX_Size() int {
 	return xxx_messageInfo_ReadControllerOutputResponse.Size(m)
@@ -213,28 +712,60 @@ func (m *ReadControllerOutputResponse) GetOutput() string {
 	return ""
 }
 
+func (m *ReadControllerOutputResponse) GetSequence() uint64 {
+	if m != nil {
+		return m.Sequence
+	}
+	return 0
+}
+
+func (m *ReadControllerOutputResponse) GetTimeUnixNano() int64 {
+	if m != nil {
+		return m.TimeUnixNano
+	}
+	return 0
+}
+
+func (m *ReadControllerOutputResponse) GetGap() bool {
+	if m != nil {
+		return m.Gap
+	}
+	return false
+}
+
+func (m *ReadControllerOutputResponse) GetMissed() uint64 {
+	if m != nil {
+		return m.Missed
+	}
+	return 0
+}
+
 type FlashControllerRequest struct {
-	ControllerPortName   string   `protobuf:"bytes,1,opt,name=controller_port_name,json=controllerPortName,proto3" json:"controller_port_name,omitempty"`
-	HexFileContent       []byte   `protobuf:"bytes,2,opt,name=hex_file_content,json=hexFileContent,proto3" json:"hex_file_content,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	ControllerPortName string `protobuf:"bytes,1,opt,name=controller_port_name,json=controllerPortName,proto3" json:"controller_port_name,omitempty"`
+	// the firmware, not necessarily a hex file
+	HexFileContent       []byte         `protobuf:"bytes,2,opt,name=hex_file_content,json=hexFileContent,proto3" json:"hex_file_content,omitempty"`
+	Format               FirmwareFormat `protobuf:"varint,3,opt,name=format,proto3,enum=proto.FirmwareFormat" json:"format,omitempty"`
+	BaseAddress          uint32         `protobuf:"varint,4,opt,name=base_address,json=baseAddress,proto3" json:"base_address,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
 }
 
 func (m *FlashControllerRequest) Reset()         { *m = FlashControllerRequest{} }
 func (m *FlashControllerRequest) String() string { return proto.CompactTextString(m) }
 func (*FlashControllerRequest) ProtoMessage()    {}
 func (*FlashControllerRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_protocol_3860ec6f7903b783, []int{5}
+	return fileDescriptor_dc3f62d3478b1775, []int{10}
 }
+
 func (m *FlashControllerRequest) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_FlashControllerRequest.Unmarshal(m, b)
 }
 func (m *FlashControllerRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
 	return xxx_messageInfo_FlashControllerRequest.Marshal(b, m, deterministic)
 }
-func (dst *FlashControllerRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_FlashControllerRequest.Merge(dst, src)
+func (m *FlashControllerRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FlashControllerRequest.Merge(m, src)
 }
 func (m *FlashControllerRequest) XXX_Size() int {
 	return xxx_messageInfo_FlashControllerRequest.Size(m)
@@ -259,27 +790,43 @@ func (m *FlashControllerRequest) GetHexFileContent() []byte {
 	return nil
 }
 
-type FlashControllerResponse struct {
-	Output               string   `protobuf:"bytes,1,opt,name=output,proto3" json:"output,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+func (m *FlashControllerRequest) GetFormat() FirmwareFormat {
+	if m != nil {
+		return m.Format
+	}
+	return FirmwareFormat_AUTO
+}
+
+func (m *FlashControllerRequest) GetBaseAddress() uint32 {
+	if m != nil {
+		return m.BaseAddress
+	}
+	return 0
+}
+
+type FlashControllerResponse struct {
+	Output               string       `protobuf:"bytes,1,opt,name=output,proto3" json:"output,omitempty"`
+	Report               *FlashReport `protobuf:"bytes,2,opt,name=report,proto3" json:"report,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
 }
 
 func (m *FlashControllerResponse) Reset()         { *m = FlashControllerResponse{} }
 func (m *FlashControllerResponse) String() string { return proto.CompactTextString(m) }
 func (*FlashControllerResponse) ProtoMessage()    {}
 func (*FlashControllerResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_protocol_3860ec6f7903b783, []int{6}
+	return fileDescriptor_dc3f62d3478b1775, []int{11}
 }
+
 func (m *FlashControllerResponse) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_FlashControllerResponse.Unmarshal(m, b)
 }
 func (m *FlashControllerResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
 	return xxx_messageInfo_FlashControllerResponse.Marshal(b, m, deterministic)
 }
-func (dst *FlashControllerResponse) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_FlashControllerResponse.Merge(dst, src)
+func (m *FlashControllerResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FlashControllerResponse.Merge(m, src)
 }
 func (m *FlashControllerResponse) XXX_Size() int {
 	return xxx_messageInfo_FlashControllerResponse.Size(m)
@@ -297,162 +844,2230 @@ func (m *FlashControllerResponse) GetOutput() string {
 	return ""
 }
 
-type ResetUsbRequest struct {
+func (m *FlashControllerResponse) GetReport() *FlashReport {
+	if m != nil {
+		return m.Report
+	}
+	return nil
+}
+
+// FlashReport is what avrdude printed about a flash. Fields avrdude didn't print stay empty.
+// Failed flashes attach it to their ABORTED or DEADLINE_EXCEEDED status as a detail
+type FlashReport struct {
+	// e.g. "0x1e950f"
+	DeviceSignature string `protobuf:"bytes,1,opt,name=device_signature,json=deviceSignature,proto3" json:"device_signature,omitempty"`
+	// the part avrdude guessed from the signature, e.g. "m328p"
+	Device        string `protobuf:"bytes,2,opt,name=device,proto3" json:"device,omitempty"`
+	BytesWritten  uint64 `protobuf:"varint,3,opt,name=bytes_written,json=bytesWritten,proto3" json:"bytes_written,omitempty"`
+	BytesVerified uint64 `protobuf:"varint,4,opt,name=bytes_verified,json=bytesVerified,proto3" json:"bytes_verified,omitempty"`
+	// the flash was read back and matched
+	Verified bool `protobuf:"varint,5,opt,name=verified,proto3" json:"verified,omitempty"`
+	// e.g. "lfuse": "0xFF"
+	Fuses map[string]string `protobuf:"bytes,6,rep,name=fuses,proto3" json:"fuses,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// the time of all of avrdude's progress bars
+	ElapsedNano          int64               `protobuf:"varint,7,opt,name=elapsed_nano,json=elapsedNano,proto3" json:"elapsed_nano,omitempty"`
+	Failure              FlashReport_Failure `protobuf:"varint,8,opt,name=failure,proto3,enum=proto.FlashReport_Failure" json:"failure,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
+	XXX_unrecognized     []byte              `json:"-"`
+	XXX_sizecache        int32               `json:"-"`
+}
+
+func (m *FlashReport) Reset()         { *m = FlashReport{} }
+func (m *FlashReport) String() string { return proto.CompactTextString(m) }
+func (*FlashReport) ProtoMessage()    {}
+func (*FlashReport) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{12}
+}
+
+func (m *FlashReport) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FlashReport.Unmarshal(m, b)
+}
+func (m *FlashReport) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FlashReport.Marshal(b, m, deterministic)
+}
+func (m *FlashReport) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FlashReport.Merge(m, src)
+}
+func (m *FlashReport) XXX_Size() int {
+	return xxx_messageInfo_FlashReport.Size(m)
+}
+func (m *FlashReport) XXX_DiscardUnknown() {
+	xxx_messageInfo_FlashReport.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FlashReport proto.InternalMessageInfo
+
+func (m *FlashReport) GetDeviceSignature() string {
+	if m != nil {
+		return m.DeviceSignature
+	}
+	return ""
+}
+
+func (m *FlashReport) GetDevice() string {
+	if m != nil {
+		return m.Device
+	}
+	return ""
+}
+
+func (m *FlashReport) GetBytesWritten() uint64 {
+	if m != nil {
+		return m.BytesWritten
+	}
+	return 0
+}
+
+func (m *FlashReport) GetBytesVerified() uint64 {
+	if m != nil {
+		return m.BytesVerified
+	}
+	return 0
+}
+
+func (m *FlashReport) GetVerified() bool {
+	if m != nil {
+		return m.Verified
+	}
+	return false
+}
+
+func (m *FlashReport) GetFuses() map[string]string {
+	if m != nil {
+		return m.Fuses
+	}
+	return nil
+}
+
+func (m *FlashReport) GetElapsedNano() int64 {
+	if m != nil {
+		return m.ElapsedNano
+	}
+	return 0
+}
+
+func (m *FlashReport) GetFailure() FlashReport_Failure {
+	if m != nil {
+		return m.Failure
+	}
+	return FlashReport_NONE
+}
+
+// FirmwareChunk is a part of a firmware image uploaded with UploadFirmware, in order
+type FirmwareChunk struct {
+	Data                 []byte   `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *ResetUsbRequest) Reset()         { *m = ResetUsbRequest{} }
-func (m *ResetUsbRequest) String() string { return proto.CompactTextString(m) }
-func (*ResetUsbRequest) ProtoMessage()    {}
-func (*ResetUsbRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_protocol_3860ec6f7903b783, []int{7}
+func (m *FirmwareChunk) Reset()         { *m = FirmwareChunk{} }
+func (m *FirmwareChunk) String() string { return proto.CompactTextString(m) }
+func (*FirmwareChunk) ProtoMessage()    {}
+func (*FirmwareChunk) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{13}
 }
-func (m *ResetUsbRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_ResetUsbRequest.Unmarshal(m, b)
+
+func (m *FirmwareChunk) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FirmwareChunk.Unmarshal(m, b)
 }
-func (m *ResetUsbRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_ResetUsbRequest.Marshal(b, m, deterministic)
+func (m *FirmwareChunk) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FirmwareChunk.Marshal(b, m, deterministic)
 }
-func (dst *ResetUsbRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_ResetUsbRequest.Merge(dst, src)
+func (m *FirmwareChunk) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FirmwareChunk.Merge(m, src)
 }
-func (m *ResetUsbRequest) XXX_Size() int {
-	return xxx_messageInfo_ResetUsbRequest.Size(m)
+func (m *FirmwareChunk) XXX_Size() int {
+	return xxx_messageInfo_FirmwareChunk.Size(m)
 }
-func (m *ResetUsbRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_ResetUsbRequest.DiscardUnknown(m)
+func (m *FirmwareChunk) XXX_DiscardUnknown() {
+	xxx_messageInfo_FirmwareChunk.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_ResetUsbRequest proto.InternalMessageInfo
+var xxx_messageInfo_FirmwareChunk proto.InternalMessageInfo
 
-type ResetUsbResponse struct {
-	Output               string   `protobuf:"bytes,1,opt,name=output,proto3" json:"output,omitempty"`
+func (m *FirmwareChunk) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+type UploadFirmwareResponse struct {
+	// the sha256 of the content, flash it with FlashFirmware within an hour
+	UploadId             string   `protobuf:"bytes,1,opt,name=upload_id,json=uploadId,proto3" json:"upload_id,omitempty"`
+	Size                 uint64   `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *ResetUsbResponse) Reset()         { *m = ResetUsbResponse{} }
-func (m *ResetUsbResponse) String() string { return proto.CompactTextString(m) }
-func (*ResetUsbResponse) ProtoMessage()    {}
-func (*ResetUsbResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_protocol_3860ec6f7903b783, []int{8}
+func (m *UploadFirmwareResponse) Reset()         { *m = UploadFirmwareResponse{} }
+func (m *UploadFirmwareResponse) String() string { return proto.CompactTextString(m) }
+func (*UploadFirmwareResponse) ProtoMessage()    {}
+func (*UploadFirmwareResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{14}
 }
-func (m *ResetUsbResponse) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_ResetUsbResponse.Unmarshal(m, b)
+
+func (m *UploadFirmwareResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UploadFirmwareResponse.Unmarshal(m, b)
 }
-func (m *ResetUsbResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_ResetUsbResponse.Marshal(b, m, deterministic)
+func (m *UploadFirmwareResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UploadFirmwareResponse.Marshal(b, m, deterministic)
 }
-func (dst *ResetUsbResponse) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_ResetUsbResponse.Merge(dst, src)
+func (m *UploadFirmwareResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UploadFirmwareResponse.Merge(m, src)
 }
-func (m *ResetUsbResponse) XXX_Size() int {
-	return xxx_messageInfo_ResetUsbResponse.Size(m)
+func (m *UploadFirmwareResponse) XXX_Size() int {
+	return xxx_messageInfo_UploadFirmwareResponse.Size(m)
 }
-func (m *ResetUsbResponse) XXX_DiscardUnknown() {
-	xxx_messageInfo_ResetUsbResponse.DiscardUnknown(m)
+func (m *UploadFirmwareResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_UploadFirmwareResponse.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_ResetUsbResponse proto.InternalMessageInfo
+var xxx_messageInfo_UploadFirmwareResponse proto.InternalMessageInfo
 
-func (m *ResetUsbResponse) GetOutput() string {
+func (m *UploadFirmwareResponse) GetUploadId() string {
 	if m != nil {
-		return m.Output
+		return m.UploadId
 	}
 	return ""
 }
 
-type WriteToControllerRequest struct {
-	ControllerPortName   string   `protobuf:"bytes,1,opt,name=controller_port_name,json=controllerPortName,proto3" json:"controller_port_name,omitempty"`
-	Message              []byte   `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+func (m *UploadFirmwareResponse) GetSize() uint64 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+type FlashFirmwareRequest struct {
+	ControllerPortName string         `protobuf:"bytes,1,opt,name=controller_port_name,json=controllerPortName,proto3" json:"controller_port_name,omitempty"`
+	UploadId           string         `protobuf:"bytes,2,opt,name=upload_id,json=uploadId,proto3" json:"upload_id,omitempty"`
+	Format             FirmwareFormat `protobuf:"varint,3,opt,name=format,proto3,enum=proto.FirmwareFormat" json:"format,omitempty"`
+	BaseAddress        uint32         `protobuf:"varint,4,opt,name=base_address,json=baseAddress,proto3" json:"base_address,omitempty"`
+	// flashes an artifact instead of an upload, by id or as "<name>:<tag or version>". Its format is used then
+	Artifact             string   `protobuf:"bytes,5,opt,name=artifact,proto3" json:"artifact,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *WriteToControllerRequest) Reset()         { *m = WriteToControllerRequest{} }
-func (m *WriteToControllerRequest) String() string { return proto.CompactTextString(m) }
-func (*WriteToControllerRequest) ProtoMessage()    {}
-func (*WriteToControllerRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_protocol_3860ec6f7903b783, []int{9}
+func (m *FlashFirmwareRequest) Reset()         { *m = FlashFirmwareRequest{} }
+func (m *FlashFirmwareRequest) String() string { return proto.CompactTextString(m) }
+func (*FlashFirmwareRequest) ProtoMessage()    {}
+func (*FlashFirmwareRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{15}
 }
-func (m *WriteToControllerRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_WriteToControllerRequest.Unmarshal(m, b)
+
+func (m *FlashFirmwareRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FlashFirmwareRequest.Unmarshal(m, b)
 }
-func (m *WriteToControllerRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_WriteToControllerRequest.Marshal(b, m, deterministic)
+func (m *FlashFirmwareRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FlashFirmwareRequest.Marshal(b, m, deterministic)
 }
-func (dst *WriteToControllerRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_WriteToControllerRequest.Merge(dst, src)
+func (m *FlashFirmwareRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FlashFirmwareRequest.Merge(m, src)
 }
-func (m *WriteToControllerRequest) XXX_Size() int {
-	return xxx_messageInfo_WriteToControllerRequest.Size(m)
+func (m *FlashFirmwareRequest) XXX_Size() int {
+	return xxx_messageInfo_FlashFirmwareRequest.Size(m)
 }
-func (m *WriteToControllerRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_WriteToControllerRequest.DiscardUnknown(m)
+func (m *FlashFirmwareRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_FlashFirmwareRequest.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_WriteToControllerRequest proto.InternalMessageInfo
+var xxx_messageInfo_FlashFirmwareRequest proto.InternalMessageInfo
 
-func (m *WriteToControllerRequest) GetControllerPortName() string {
+func (m *FlashFirmwareRequest) GetControllerPortName() string {
 	if m != nil {
 		return m.ControllerPortName
 	}
 	return ""
 }
 
-func (m *WriteToControllerRequest) GetMessage() []byte {
+func (m *FlashFirmwareRequest) GetUploadId() string {
 	if m != nil {
-		return m.Message
+		return m.UploadId
+	}
+	return ""
+}
+
+func (m *FlashFirmwareRequest) GetFormat() FirmwareFormat {
+	if m != nil {
+		return m.Format
+	}
+	return FirmwareFormat_AUTO
+}
+
+func (m *FlashFirmwareRequest) GetBaseAddress() uint32 {
+	if m != nil {
+		return m.BaseAddress
+	}
+	return 0
+}
+
+func (m *FlashFirmwareRequest) GetArtifact() string {
+	if m != nil {
+		return m.Artifact
+	}
+	return ""
+}
+
+type Artifact struct {
+	// the sha256 of the content
+	Id      string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name    string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Version string `protobuf:"bytes,3,opt,name=version,proto3" json:"version,omitempty"`
+	// the board the firmware is built for, e.g. "uno"
+	Board       string         `protobuf:"bytes,4,opt,name=board,proto3" json:"board,omitempty"`
+	Notes       string         `protobuf:"bytes,5,opt,name=notes,proto3" json:"notes,omitempty"`
+	Format      FirmwareFormat `protobuf:"varint,6,opt,name=format,proto3,enum=proto.FirmwareFormat" json:"format,omitempty"`
+	BaseAddress uint32         `protobuf:"varint,7,opt,name=base_address,json=baseAddress,proto3" json:"base_address,omitempty"`
+	Size        uint64         `protobuf:"varint,8,opt,name=size,proto3" json:"size,omitempty"`
+	// unique among the artifacts of the same name, e.g. "stable"
+	Tags                 []string `protobuf:"bytes,9,rep,name=tags,proto3" json:"tags,omitempty"`
+	UploadedAtUnixNano   int64    `protobuf:"varint,10,opt,name=uploaded_at_unix_nano,json=uploadedAtUnixNano,proto3" json:"uploaded_at_unix_nano,omitempty"`
+	UploadedBy           string   `protobuf:"bytes,11,opt,name=uploaded_by,json=uploadedBy,proto3" json:"uploaded_by,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Artifact) Reset()         { *m = Artifact{} }
+func (m *Artifact) String() string { return proto.CompactTextString(m) }
+func (*Artifact) ProtoMessage()    {}
+func (*Artifact) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{16}
+}
+
+func (m *Artifact) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Artifact.Unmarshal(m, b)
+}
+func (m *Artifact) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Artifact.Marshal(b, m, deterministic)
+}
+func (m *Artifact) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Artifact.Merge(m, src)
+}
+func (m *Artifact) XXX_Size() int {
+	return xxx_messageInfo_Artifact.Size(m)
+}
+func (m *Artifact) XXX_DiscardUnknown() {
+	xxx_messageInfo_Artifact.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Artifact proto.InternalMessageInfo
+
+func (m *Artifact) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *Artifact) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Artifact) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+func (m *Artifact) GetBoard() string {
+	if m != nil {
+		return m.Board
+	}
+	return ""
+}
+
+func (m *Artifact) GetNotes() string {
+	if m != nil {
+		return m.Notes
+	}
+	return ""
+}
+
+func (m *Artifact) GetFormat() FirmwareFormat {
+	if m != nil {
+		return m.Format
+	}
+	return FirmwareFormat_AUTO
+}
+
+func (m *Artifact) GetBaseAddress() uint32 {
+	if m != nil {
+		return m.BaseAddress
+	}
+	return 0
+}
+
+func (m *Artifact) GetSize() uint64 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+func (m *Artifact) GetTags() []string {
+	if m != nil {
+		return m.Tags
 	}
 	return nil
 }
 
-type WriteToControllerResponse struct {
+func (m *Artifact) GetUploadedAtUnixNano() int64 {
+	if m != nil {
+		return m.UploadedAtUnixNano
+	}
+	return 0
+}
+
+func (m *Artifact) GetUploadedBy() string {
+	if m != nil {
+		return m.UploadedBy
+	}
+	return ""
+}
+
+// ArtifactChunk is a part of an artifact uploaded with UploadArtifact. The first chunk carries the metadata
+type ArtifactChunk struct {
+	// id, size, uploaded_at and uploaded_by are set by the server
+	Metadata             *Artifact `protobuf:"bytes,1,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	Data                 []byte    `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *ArtifactChunk) Reset()         { *m = ArtifactChunk{} }
+func (m *ArtifactChunk) String() string { return proto.CompactTextString(m) }
+func (*ArtifactChunk) ProtoMessage()    {}
+func (*ArtifactChunk) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{17}
+}
+
+func (m *ArtifactChunk) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ArtifactChunk.Unmarshal(m, b)
+}
+func (m *ArtifactChunk) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ArtifactChunk.Marshal(b, m, deterministic)
+}
+func (m *ArtifactChunk) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ArtifactChunk.Merge(m, src)
+}
+func (m *ArtifactChunk) XXX_Size() int {
+	return xxx_messageInfo_ArtifactChunk.Size(m)
+}
+func (m *ArtifactChunk) XXX_DiscardUnknown() {
+	xxx_messageInfo_ArtifactChunk.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ArtifactChunk proto.InternalMessageInfo
+
+func (m *ArtifactChunk) GetMetadata() *Artifact {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
+func (m *ArtifactChunk) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+type ListArtifactsRequest struct {
+	// lists all artifacts if empty
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *WriteToControllerResponse) Reset()         { *m = WriteToControllerResponse{} }
-func (m *WriteToControllerResponse) String() string { return proto.CompactTextString(m) }
-func (*WriteToControllerResponse) ProtoMessage()    {}
-func (*WriteToControllerResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_protocol_3860ec6f7903b783, []int{10}
+func (m *ListArtifactsRequest) Reset()         { *m = ListArtifactsRequest{} }
+func (m *ListArtifactsRequest) String() string { return proto.CompactTextString(m) }
+func (*ListArtifactsRequest) ProtoMessage()    {}
+func (*ListArtifactsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{18}
 }
-func (m *WriteToControllerResponse) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_WriteToControllerResponse.Unmarshal(m, b)
+
+func (m *ListArtifactsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListArtifactsRequest.Unmarshal(m, b)
 }
-func (m *WriteToControllerResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_WriteToControllerResponse.Marshal(b, m, deterministic)
+func (m *ListArtifactsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListArtifactsRequest.Marshal(b, m, deterministic)
+}
+func (m *ListArtifactsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListArtifactsRequest.Merge(m, src)
+}
+func (m *ListArtifactsRequest) XXX_Size() int {
+	return xxx_messageInfo_ListArtifactsRequest.Size(m)
+}
+func (m *ListArtifactsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListArtifactsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListArtifactsRequest proto.InternalMessageInfo
+
+func (m *ListArtifactsRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type ListArtifactsResponse struct {
+	// the newest first
+	Artifacts            []*Artifact `protobuf:"bytes,1,rep,name=artifacts,proto3" json:"artifacts,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *ListArtifactsResponse) Reset()         { *m = ListArtifactsResponse{} }
+func (m *ListArtifactsResponse) String() string { return proto.CompactTextString(m) }
+func (*ListArtifactsResponse) ProtoMessage()    {}
+func (*ListArtifactsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{19}
+}
+
+func (m *ListArtifactsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListArtifactsResponse.Unmarshal(m, b)
+}
+func (m *ListArtifactsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListArtifactsResponse.Marshal(b, m, deterministic)
+}
+func (m *ListArtifactsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListArtifactsResponse.Merge(m, src)
+}
+func (m *ListArtifactsResponse) XXX_Size() int {
+	return xxx_messageInfo_ListArtifactsResponse.Size(m)
 }
-func (dst *WriteToControllerResponse) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_WriteToControllerResponse.Merge(dst, src)
+func (m *ListArtifactsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListArtifactsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListArtifactsResponse proto.InternalMessageInfo
+
+func (m *ListArtifactsResponse) GetArtifacts() []*Artifact {
+	if m != nil {
+		return m.Artifacts
+	}
+	return nil
+}
+
+type TagArtifactRequest struct {
+	// the id or "<name>:<tag or version>"
+	Reference string `protobuf:"bytes,1,opt,name=reference,proto3" json:"reference,omitempty"`
+	Tag       string `protobuf:"bytes,2,opt,name=tag,proto3" json:"tag,omitempty"`
+	// removes the tag instead of adding it
+	Remove               bool     `protobuf:"varint,3,opt,name=remove,proto3" json:"remove,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TagArtifactRequest) Reset()         { *m = TagArtifactRequest{} }
+func (m *TagArtifactRequest) String() string { return proto.CompactTextString(m) }
+func (*TagArtifactRequest) ProtoMessage()    {}
+func (*TagArtifactRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{20}
+}
+
+func (m *TagArtifactRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TagArtifactRequest.Unmarshal(m, b)
+}
+func (m *TagArtifactRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TagArtifactRequest.Marshal(b, m, deterministic)
+}
+func (m *TagArtifactRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TagArtifactRequest.Merge(m, src)
+}
+func (m *TagArtifactRequest) XXX_Size() int {
+	return xxx_messageInfo_TagArtifactRequest.Size(m)
+}
+func (m *TagArtifactRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_TagArtifactRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TagArtifactRequest proto.InternalMessageInfo
+
+func (m *TagArtifactRequest) GetReference() string {
+	if m != nil {
+		return m.Reference
+	}
+	return ""
+}
+
+func (m *TagArtifactRequest) GetTag() string {
+	if m != nil {
+		return m.Tag
+	}
+	return ""
+}
+
+func (m *TagArtifactRequest) GetRemove() bool {
+	if m != nil {
+		return m.Remove
+	}
+	return false
+}
+
+type DeleteArtifactRequest struct {
+	// the id or "<name>:<tag or version>"
+	Reference            string   `protobuf:"bytes,1,opt,name=reference,proto3" json:"reference,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DeleteArtifactRequest) Reset()         { *m = DeleteArtifactRequest{} }
+func (m *DeleteArtifactRequest) String() string { return proto.CompactTextString(m) }
+func (*DeleteArtifactRequest) ProtoMessage()    {}
+func (*DeleteArtifactRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{21}
+}
+
+func (m *DeleteArtifactRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeleteArtifactRequest.Unmarshal(m, b)
+}
+func (m *DeleteArtifactRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeleteArtifactRequest.Marshal(b, m, deterministic)
+}
+func (m *DeleteArtifactRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeleteArtifactRequest.Merge(m, src)
+}
+func (m *DeleteArtifactRequest) XXX_Size() int {
+	return xxx_messageInfo_DeleteArtifactRequest.Size(m)
+}
+func (m *DeleteArtifactRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeleteArtifactRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeleteArtifactRequest proto.InternalMessageInfo
+
+func (m *DeleteArtifactRequest) GetReference() string {
+	if m != nil {
+		return m.Reference
+	}
+	return ""
+}
+
+type DeleteArtifactResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DeleteArtifactResponse) Reset()         { *m = DeleteArtifactResponse{} }
+func (m *DeleteArtifactResponse) String() string { return proto.CompactTextString(m) }
+func (*DeleteArtifactResponse) ProtoMessage()    {}
+func (*DeleteArtifactResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{22}
+}
+
+func (m *DeleteArtifactResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeleteArtifactResponse.Unmarshal(m, b)
+}
+func (m *DeleteArtifactResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeleteArtifactResponse.Marshal(b, m, deterministic)
+}
+func (m *DeleteArtifactResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeleteArtifactResponse.Merge(m, src)
+}
+func (m *DeleteArtifactResponse) XXX_Size() int {
+	return xxx_messageInfo_DeleteArtifactResponse.Size(m)
+}
+func (m *DeleteArtifactResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeleteArtifactResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeleteArtifactResponse proto.InternalMessageInfo
+
+type FlashControllersRequest struct {
+	Selector *ControllerSelector `protobuf:"bytes,1,opt,name=selector,proto3" json:"selector,omitempty"`
+	// the image is an upload or an artifact, like for FlashFirmware
+	UploadId    string         `protobuf:"bytes,2,opt,name=upload_id,json=uploadId,proto3" json:"upload_id,omitempty"`
+	Format      FirmwareFormat `protobuf:"varint,3,opt,name=format,proto3,enum=proto.FirmwareFormat" json:"format,omitempty"`
+	BaseAddress uint32         `protobuf:"varint,4,opt,name=base_address,json=baseAddress,proto3" json:"base_address,omitempty"`
+	Artifact    string         `protobuf:"bytes,5,opt,name=artifact,proto3" json:"artifact,omitempty"`
+	// how many controllers are flashed at once, 1 if not set
+	Parallelism int32 `protobuf:"varint,6,opt,name=parallelism,proto3" json:"parallelism,omitempty"`
+	// flashes the remaining controllers after a failure as well. Otherwise no more flashes are started after the first failure
+	ContinueOnFailure    bool     `protobuf:"varint,7,opt,name=continue_on_failure,json=continueOnFailure,proto3" json:"continue_on_failure,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FlashControllersRequest) Reset()         { *m = FlashControllersRequest{} }
+func (m *FlashControllersRequest) String() string { return proto.CompactTextString(m) }
+func (*FlashControllersRequest) ProtoMessage()    {}
+func (*FlashControllersRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{23}
+}
+
+func (m *FlashControllersRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FlashControllersRequest.Unmarshal(m, b)
+}
+func (m *FlashControllersRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FlashControllersRequest.Marshal(b, m, deterministic)
+}
+func (m *FlashControllersRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FlashControllersRequest.Merge(m, src)
+}
+func (m *FlashControllersRequest) XXX_Size() int {
+	return xxx_messageInfo_FlashControllersRequest.Size(m)
+}
+func (m *FlashControllersRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_FlashControllersRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FlashControllersRequest proto.InternalMessageInfo
+
+func (m *FlashControllersRequest) GetSelector() *ControllerSelector {
+	if m != nil {
+		return m.Selector
+	}
+	return nil
+}
+
+func (m *FlashControllersRequest) GetUploadId() string {
+	if m != nil {
+		return m.UploadId
+	}
+	return ""
+}
+
+func (m *FlashControllersRequest) GetFormat() FirmwareFormat {
+	if m != nil {
+		return m.Format
+	}
+	return FirmwareFormat_AUTO
+}
+
+func (m *FlashControllersRequest) GetBaseAddress() uint32 {
+	if m != nil {
+		return m.BaseAddress
+	}
+	return 0
+}
+
+func (m *FlashControllersRequest) GetArtifact() string {
+	if m != nil {
+		return m.Artifact
+	}
+	return ""
+}
+
+func (m *FlashControllersRequest) GetParallelism() int32 {
+	if m != nil {
+		return m.Parallelism
+	}
+	return 0
+}
+
+func (m *FlashControllersRequest) GetContinueOnFailure() bool {
+	if m != nil {
+		return m.ContinueOnFailure
+	}
+	return false
+}
+
+type FlashControllersResponse struct {
+	// one result per selected controller, ordered by port name
+	Results              []*FlashControllerResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                 `json:"-"`
+	XXX_unrecognized     []byte                   `json:"-"`
+	XXX_sizecache        int32                    `json:"-"`
+}
+
+func (m *FlashControllersResponse) Reset()         { *m = FlashControllersResponse{} }
+func (m *FlashControllersResponse) String() string { return proto.CompactTextString(m) }
+func (*FlashControllersResponse) ProtoMessage()    {}
+func (*FlashControllersResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{24}
+}
+
+func (m *FlashControllersResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FlashControllersResponse.Unmarshal(m, b)
+}
+func (m *FlashControllersResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FlashControllersResponse.Marshal(b, m, deterministic)
+}
+func (m *FlashControllersResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FlashControllersResponse.Merge(m, src)
+}
+func (m *FlashControllersResponse) XXX_Size() int {
+	return xxx_messageInfo_FlashControllersResponse.Size(m)
+}
+func (m *FlashControllersResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_FlashControllersResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FlashControllersResponse proto.InternalMessageInfo
+
+func (m *FlashControllersResponse) GetResults() []*FlashControllerResult {
+	if m != nil {
+		return m.Results
+	}
+	return nil
+}
+
+type FlashControllerResult struct {
+	ControllerPortName string `protobuf:"bytes,1,opt,name=controller_port_name,json=controllerPortName,proto3" json:"controller_port_name,omitempty"`
+	ControllerName     string `protobuf:"bytes,2,opt,name=controller_name,json=controllerName,proto3" json:"controller_name,omitempty"`
+	ControllerStableId string `protobuf:"bytes,3,opt,name=controller_stable_id,json=controllerStableId,proto3" json:"controller_stable_id,omitempty"`
+	// the grpc status code of the flash, 0 (OK) if it succeeded
+	Code  int32  `protobuf:"varint,4,opt,name=code,proto3" json:"code,omitempty"`
+	Error string `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	// what the flasher printed
+	Output string `protobuf:"bytes,6,opt,name=output,proto3" json:"output,omitempty"`
+	// not flashed, because an earlier flash failed
+	Skipped      bool  `protobuf:"varint,7,opt,name=skipped,proto3" json:"skipped,omitempty"`
+	DurationNano int64 `protobuf:"varint,8,opt,name=duration_nano,json=durationNano,proto3" json:"duration_nano,omitempty"`
+	// set if the flasher ran
+	Report               *FlashReport `protobuf:"bytes,9,opt,name=report,proto3" json:"report,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *FlashControllerResult) Reset()         { *m = FlashControllerResult{} }
+func (m *FlashControllerResult) String() string { return proto.CompactTextString(m) }
+func (*FlashControllerResult) ProtoMessage()    {}
+func (*FlashControllerResult) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{25}
+}
+
+func (m *FlashControllerResult) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FlashControllerResult.Unmarshal(m, b)
+}
+func (m *FlashControllerResult) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FlashControllerResult.Marshal(b, m, deterministic)
+}
+func (m *FlashControllerResult) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FlashControllerResult.Merge(m, src)
+}
+func (m *FlashControllerResult) XXX_Size() int {
+	return xxx_messageInfo_FlashControllerResult.Size(m)
+}
+func (m *FlashControllerResult) XXX_DiscardUnknown() {
+	xxx_messageInfo_FlashControllerResult.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FlashControllerResult proto.InternalMessageInfo
+
+func (m *FlashControllerResult) GetControllerPortName() string {
+	if m != nil {
+		return m.ControllerPortName
+	}
+	return ""
+}
+
+func (m *FlashControllerResult) GetControllerName() string {
+	if m != nil {
+		return m.ControllerName
+	}
+	return ""
+}
+
+func (m *FlashControllerResult) GetControllerStableId() string {
+	if m != nil {
+		return m.ControllerStableId
+	}
+	return ""
+}
+
+func (m *FlashControllerResult) GetCode() int32 {
+	if m != nil {
+		return m.Code
+	}
+	return 0
+}
+
+func (m *FlashControllerResult) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *FlashControllerResult) GetOutput() string {
+	if m != nil {
+		return m.Output
+	}
+	return ""
+}
+
+func (m *FlashControllerResult) GetSkipped() bool {
+	if m != nil {
+		return m.Skipped
+	}
+	return false
+}
+
+func (m *FlashControllerResult) GetDurationNano() int64 {
+	if m != nil {
+		return m.DurationNano
+	}
+	return 0
+}
+
+func (m *FlashControllerResult) GetReport() *FlashReport {
+	if m != nil {
+		return m.Report
+	}
+	return nil
+}
+
+// FlashRecord is one flash of a controller in its flash history
+type FlashRecord struct {
+	// the sha256 of the flashed hex file
+	ImageId string `protobuf:"bytes,1,opt,name=image_id,json=imageId,proto3" json:"image_id,omitempty"`
+	// set if an artifact was flashed
+	ArtifactId string `protobuf:"bytes,2,opt,name=artifact_id,json=artifactId,proto3" json:"artifact_id,omitempty"`
+	// what was flashed, e.g. "artifact leg-firmware 1.0"
+	Source               string   `protobuf:"bytes,3,opt,name=source,proto3" json:"source,omitempty"`
+	ControllerPortName   string   `protobuf:"bytes,4,opt,name=controller_port_name,json=controllerPortName,proto3" json:"controller_port_name,omitempty"`
+	Client               string   `protobuf:"bytes,5,opt,name=client,proto3" json:"client,omitempty"`
+	StartedAtUnixNano    int64    `protobuf:"varint,6,opt,name=started_at_unix_nano,json=startedAtUnixNano,proto3" json:"started_at_unix_nano,omitempty"`
+	DurationNano         int64    `protobuf:"varint,7,opt,name=duration_nano,json=durationNano,proto3" json:"duration_nano,omitempty"`
+	Succeeded            bool     `protobuf:"varint,8,opt,name=succeeded,proto3" json:"succeeded,omitempty"`
+	Error                string   `protobuf:"bytes,9,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FlashRecord) Reset()         { *m = FlashRecord{} }
+func (m *FlashRecord) String() string { return proto.CompactTextString(m) }
+func (*FlashRecord) ProtoMessage()    {}
+func (*FlashRecord) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{26}
+}
+
+func (m *FlashRecord) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FlashRecord.Unmarshal(m, b)
+}
+func (m *FlashRecord) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FlashRecord.Marshal(b, m, deterministic)
+}
+func (m *FlashRecord) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FlashRecord.Merge(m, src)
+}
+func (m *FlashRecord) XXX_Size() int {
+	return xxx_messageInfo_FlashRecord.Size(m)
+}
+func (m *FlashRecord) XXX_DiscardUnknown() {
+	xxx_messageInfo_FlashRecord.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FlashRecord proto.InternalMessageInfo
+
+func (m *FlashRecord) GetImageId() string {
+	if m != nil {
+		return m.ImageId
+	}
+	return ""
+}
+
+func (m *FlashRecord) GetArtifactId() string {
+	if m != nil {
+		return m.ArtifactId
+	}
+	return ""
+}
+
+func (m *FlashRecord) GetSource() string {
+	if m != nil {
+		return m.Source
+	}
+	return ""
+}
+
+func (m *FlashRecord) GetControllerPortName() string {
+	if m != nil {
+		return m.ControllerPortName
+	}
+	return ""
+}
+
+func (m *FlashRecord) GetClient() string {
+	if m != nil {
+		return m.Client
+	}
+	return ""
+}
+
+func (m *FlashRecord) GetStartedAtUnixNano() int64 {
+	if m != nil {
+		return m.StartedAtUnixNano
+	}
+	return 0
+}
+
+func (m *FlashRecord) GetDurationNano() int64 {
+	if m != nil {
+		return m.DurationNano
+	}
+	return 0
+}
+
+func (m *FlashRecord) GetSucceeded() bool {
+	if m != nil {
+		return m.Succeeded
+	}
+	return false
+}
+
+func (m *FlashRecord) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+type FlashHistoryRequest struct {
+	// the history of the controller at this port, or by stable_id, which works for detached controllers as well
+	ControllerPortName   string   `protobuf:"bytes,1,opt,name=controller_port_name,json=controllerPortName,proto3" json:"controller_port_name,omitempty"`
+	ControllerStableId   string   `protobuf:"bytes,2,opt,name=controller_stable_id,json=controllerStableId,proto3" json:"controller_stable_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FlashHistoryRequest) Reset()         { *m = FlashHistoryRequest{} }
+func (m *FlashHistoryRequest) String() string { return proto.CompactTextString(m) }
+func (*FlashHistoryRequest) ProtoMessage()    {}
+func (*FlashHistoryRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{27}
+}
+
+func (m *FlashHistoryRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FlashHistoryRequest.Unmarshal(m, b)
+}
+func (m *FlashHistoryRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FlashHistoryRequest.Marshal(b, m, deterministic)
+}
+func (m *FlashHistoryRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FlashHistoryRequest.Merge(m, src)
+}
+func (m *FlashHistoryRequest) XXX_Size() int {
+	return xxx_messageInfo_FlashHistoryRequest.Size(m)
+}
+func (m *FlashHistoryRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_FlashHistoryRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FlashHistoryRequest proto.InternalMessageInfo
+
+func (m *FlashHistoryRequest) GetControllerPortName() string {
+	if m != nil {
+		return m.ControllerPortName
+	}
+	return ""
+}
+
+func (m *FlashHistoryRequest) GetControllerStableId() string {
+	if m != nil {
+		return m.ControllerStableId
+	}
+	return ""
+}
+
+type FlashHistoryResponse struct {
+	ControllerStableId string `protobuf:"bytes,1,opt,name=controller_stable_id,json=controllerStableId,proto3" json:"controller_stable_id,omitempty"`
+	// the newest first
+	Records              []*FlashRecord `protobuf:"bytes,2,rep,name=records,proto3" json:"records,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *FlashHistoryResponse) Reset()         { *m = FlashHistoryResponse{} }
+func (m *FlashHistoryResponse) String() string { return proto.CompactTextString(m) }
+func (*FlashHistoryResponse) ProtoMessage()    {}
+func (*FlashHistoryResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{28}
+}
+
+func (m *FlashHistoryResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FlashHistoryResponse.Unmarshal(m, b)
+}
+func (m *FlashHistoryResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FlashHistoryResponse.Marshal(b, m, deterministic)
+}
+func (m *FlashHistoryResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FlashHistoryResponse.Merge(m, src)
+}
+func (m *FlashHistoryResponse) XXX_Size() int {
+	return xxx_messageInfo_FlashHistoryResponse.Size(m)
+}
+func (m *FlashHistoryResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_FlashHistoryResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FlashHistoryResponse proto.InternalMessageInfo
+
+func (m *FlashHistoryResponse) GetControllerStableId() string {
+	if m != nil {
+		return m.ControllerStableId
+	}
+	return ""
+}
+
+func (m *FlashHistoryResponse) GetRecords() []*FlashRecord {
+	if m != nil {
+		return m.Records
+	}
+	return nil
+}
+
+type RollbackControllerRequest struct {
+	ControllerPortName   string   `protobuf:"bytes,1,opt,name=controller_port_name,json=controllerPortName,proto3" json:"controller_port_name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RollbackControllerRequest) Reset()         { *m = RollbackControllerRequest{} }
+func (m *RollbackControllerRequest) String() string { return proto.CompactTextString(m) }
+func (*RollbackControllerRequest) ProtoMessage()    {}
+func (*RollbackControllerRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{29}
+}
+
+func (m *RollbackControllerRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RollbackControllerRequest.Unmarshal(m, b)
+}
+func (m *RollbackControllerRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RollbackControllerRequest.Marshal(b, m, deterministic)
+}
+func (m *RollbackControllerRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RollbackControllerRequest.Merge(m, src)
+}
+func (m *RollbackControllerRequest) XXX_Size() int {
+	return xxx_messageInfo_RollbackControllerRequest.Size(m)
+}
+func (m *RollbackControllerRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RollbackControllerRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RollbackControllerRequest proto.InternalMessageInfo
+
+func (m *RollbackControllerRequest) GetControllerPortName() string {
+	if m != nil {
+		return m.ControllerPortName
+	}
+	return ""
+}
+
+type FlashProgress struct {
+	Phase FlashProgress_Phase `protobuf:"varint,1,opt,name=phase,proto3,enum=proto.FlashProgress_Phase" json:"phase,omitempty"`
+	// of the phase
+	Percent int32 `protobuf:"varint,2,opt,name=percent,proto3" json:"percent,omitempty"`
+	// what the flasher printed, set with DONE
+	Output string `protobuf:"bytes,3,opt,name=output,proto3" json:"output,omitempty"`
+	// set with DONE
+	Report               *FlashReport `protobuf:"bytes,4,opt,name=report,proto3" json:"report,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *FlashProgress) Reset()         { *m = FlashProgress{} }
+func (m *FlashProgress) String() string { return proto.CompactTextString(m) }
+func (*FlashProgress) ProtoMessage()    {}
+func (*FlashProgress) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{30}
+}
+
+func (m *FlashProgress) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FlashProgress.Unmarshal(m, b)
+}
+func (m *FlashProgress) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FlashProgress.Marshal(b, m, deterministic)
+}
+func (m *FlashProgress) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FlashProgress.Merge(m, src)
+}
+func (m *FlashProgress) XXX_Size() int {
+	return xxx_messageInfo_FlashProgress.Size(m)
+}
+func (m *FlashProgress) XXX_DiscardUnknown() {
+	xxx_messageInfo_FlashProgress.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FlashProgress proto.InternalMessageInfo
+
+func (m *FlashProgress) GetPhase() FlashProgress_Phase {
+	if m != nil {
+		return m.Phase
+	}
+	return FlashProgress_UNKNOWN
+}
+
+func (m *FlashProgress) GetPercent() int32 {
+	if m != nil {
+		return m.Percent
+	}
+	return 0
+}
+
+func (m *FlashProgress) GetOutput() string {
+	if m != nil {
+		return m.Output
+	}
+	return ""
+}
+
+func (m *FlashProgress) GetReport() *FlashReport {
+	if m != nil {
+		return m.Report
+	}
+	return nil
+}
+
+type ResetUsbRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ResetUsbRequest) Reset()         { *m = ResetUsbRequest{} }
+func (m *ResetUsbRequest) String() string { return proto.CompactTextString(m) }
+func (*ResetUsbRequest) ProtoMessage()    {}
+func (*ResetUsbRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{31}
+}
+
+func (m *ResetUsbRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ResetUsbRequest.Unmarshal(m, b)
+}
+func (m *ResetUsbRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ResetUsbRequest.Marshal(b, m, deterministic)
+}
+func (m *ResetUsbRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ResetUsbRequest.Merge(m, src)
+}
+func (m *ResetUsbRequest) XXX_Size() int {
+	return xxx_messageInfo_ResetUsbRequest.Size(m)
+}
+func (m *ResetUsbRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ResetUsbRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ResetUsbRequest proto.InternalMessageInfo
+
+type ResetUsbResponse struct {
+	Output               string   `protobuf:"bytes,1,opt,name=output,proto3" json:"output,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ResetUsbResponse) Reset()         { *m = ResetUsbResponse{} }
+func (m *ResetUsbResponse) String() string { return proto.CompactTextString(m) }
+func (*ResetUsbResponse) ProtoMessage()    {}
+func (*ResetUsbResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{32}
+}
+
+func (m *ResetUsbResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ResetUsbResponse.Unmarshal(m, b)
+}
+func (m *ResetUsbResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ResetUsbResponse.Marshal(b, m, deterministic)
+}
+func (m *ResetUsbResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ResetUsbResponse.Merge(m, src)
+}
+func (m *ResetUsbResponse) XXX_Size() int {
+	return xxx_messageInfo_ResetUsbResponse.Size(m)
+}
+func (m *ResetUsbResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ResetUsbResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ResetUsbResponse proto.InternalMessageInfo
+
+func (m *ResetUsbResponse) GetOutput() string {
+	if m != nil {
+		return m.Output
+	}
+	return ""
+}
+
+type WriteToControllerRequest struct {
+	ControllerPortName   string   `protobuf:"bytes,1,opt,name=controller_port_name,json=controllerPortName,proto3" json:"controller_port_name,omitempty"`
+	Message              []byte   `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *WriteToControllerRequest) Reset()         { *m = WriteToControllerRequest{} }
+func (m *WriteToControllerRequest) String() string { return proto.CompactTextString(m) }
+func (*WriteToControllerRequest) ProtoMessage()    {}
+func (*WriteToControllerRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{33}
+}
+
+func (m *WriteToControllerRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WriteToControllerRequest.Unmarshal(m, b)
+}
+func (m *WriteToControllerRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WriteToControllerRequest.Marshal(b, m, deterministic)
+}
+func (m *WriteToControllerRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WriteToControllerRequest.Merge(m, src)
+}
+func (m *WriteToControllerRequest) XXX_Size() int {
+	return xxx_messageInfo_WriteToControllerRequest.Size(m)
+}
+func (m *WriteToControllerRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_WriteToControllerRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WriteToControllerRequest proto.InternalMessageInfo
+
+func (m *WriteToControllerRequest) GetControllerPortName() string {
+	if m != nil {
+		return m.ControllerPortName
+	}
+	return ""
+}
+
+func (m *WriteToControllerRequest) GetMessage() []byte {
+	if m != nil {
+		return m.Message
+	}
+	return nil
+}
+
+type WriteToControllerResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *WriteToControllerResponse) Reset()         { *m = WriteToControllerResponse{} }
+func (m *WriteToControllerResponse) String() string { return proto.CompactTextString(m) }
+func (*WriteToControllerResponse) ProtoMessage()    {}
+func (*WriteToControllerResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{34}
+}
+
+func (m *WriteToControllerResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WriteToControllerResponse.Unmarshal(m, b)
+}
+func (m *WriteToControllerResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WriteToControllerResponse.Marshal(b, m, deterministic)
+}
+func (m *WriteToControllerResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WriteToControllerResponse.Merge(m, src)
+}
+func (m *WriteToControllerResponse) XXX_Size() int {
+	return xxx_messageInfo_WriteToControllerResponse.Size(m)
+}
+func (m *WriteToControllerResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_WriteToControllerResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WriteToControllerResponse proto.InternalMessageInfo
+
+// WriteToControllersRequest names its own target, the first of port name, stable id and name that is set
+type WriteToControllersRequest struct {
+	// chosen by the client and repeated in the acknowledgement
+	Id                   uint64   `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	ControllerPortName   string   `protobuf:"bytes,2,opt,name=controller_port_name,json=controllerPortName,proto3" json:"controller_port_name,omitempty"`
+	ControllerStableId   string   `protobuf:"bytes,3,opt,name=controller_stable_id,json=controllerStableId,proto3" json:"controller_stable_id,omitempty"`
+	ControllerName       string   `protobuf:"bytes,4,opt,name=controller_name,json=controllerName,proto3" json:"controller_name,omitempty"`
+	Message              []byte   `protobuf:"bytes,5,opt,name=message,proto3" json:"message,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *WriteToControllersRequest) Reset()         { *m = WriteToControllersRequest{} }
+func (m *WriteToControllersRequest) String() string { return proto.CompactTextString(m) }
+func (*WriteToControllersRequest) ProtoMessage()    {}
+func (*WriteToControllersRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{35}
+}
+
+func (m *WriteToControllersRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WriteToControllersRequest.Unmarshal(m, b)
+}
+func (m *WriteToControllersRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WriteToControllersRequest.Marshal(b, m, deterministic)
+}
+func (m *WriteToControllersRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WriteToControllersRequest.Merge(m, src)
+}
+func (m *WriteToControllersRequest) XXX_Size() int {
+	return xxx_messageInfo_WriteToControllersRequest.Size(m)
+}
+func (m *WriteToControllersRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_WriteToControllersRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WriteToControllersRequest proto.InternalMessageInfo
+
+func (m *WriteToControllersRequest) GetId() uint64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *WriteToControllersRequest) GetControllerPortName() string {
+	if m != nil {
+		return m.ControllerPortName
+	}
+	return ""
+}
+
+func (m *WriteToControllersRequest) GetControllerStableId() string {
+	if m != nil {
+		return m.ControllerStableId
+	}
+	return ""
+}
+
+func (m *WriteToControllersRequest) GetControllerName() string {
+	if m != nil {
+		return m.ControllerName
+	}
+	return ""
+}
+
+func (m *WriteToControllersRequest) GetMessage() []byte {
+	if m != nil {
+		return m.Message
+	}
+	return nil
+}
+
+// WriteToControllersAck answers every WriteToControllersRequest in order
+type WriteToControllersAck struct {
+	Id uint64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// the port the message was written to, empty if the target wasn't found
+	ControllerPortName string `protobuf:"bytes,2,opt,name=controller_port_name,json=controllerPortName,proto3" json:"controller_port_name,omitempty"`
+	// the grpc status code of the write, 0 (OK) if it succeeded
+	Code                 int32    `protobuf:"varint,3,opt,name=code,proto3" json:"code,omitempty"`
+	Error                string   `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *WriteToControllersAck) Reset()         { *m = WriteToControllersAck{} }
+func (m *WriteToControllersAck) String() string { return proto.CompactTextString(m) }
+func (*WriteToControllersAck) ProtoMessage()    {}
+func (*WriteToControllersAck) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{36}
+}
+
+func (m *WriteToControllersAck) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WriteToControllersAck.Unmarshal(m, b)
+}
+func (m *WriteToControllersAck) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WriteToControllersAck.Marshal(b, m, deterministic)
+}
+func (m *WriteToControllersAck) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WriteToControllersAck.Merge(m, src)
+}
+func (m *WriteToControllersAck) XXX_Size() int {
+	return xxx_messageInfo_WriteToControllersAck.Size(m)
+}
+func (m *WriteToControllersAck) XXX_DiscardUnknown() {
+	xxx_messageInfo_WriteToControllersAck.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WriteToControllersAck proto.InternalMessageInfo
+
+func (m *WriteToControllersAck) GetId() uint64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *WriteToControllersAck) GetControllerPortName() string {
+	if m != nil {
+		return m.ControllerPortName
+	}
+	return ""
+}
+
+func (m *WriteToControllersAck) GetCode() int32 {
+	if m != nil {
+		return m.Code
+	}
+	return 0
+}
+
+func (m *WriteToControllersAck) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+type ExplainDiscoveryRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ExplainDiscoveryRequest) Reset()         { *m = ExplainDiscoveryRequest{} }
+func (m *ExplainDiscoveryRequest) String() string { return proto.CompactTextString(m) }
+func (*ExplainDiscoveryRequest) ProtoMessage()    {}
+func (*ExplainDiscoveryRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{37}
+}
+
+func (m *ExplainDiscoveryRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExplainDiscoveryRequest.Unmarshal(m, b)
+}
+func (m *ExplainDiscoveryRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExplainDiscoveryRequest.Marshal(b, m, deterministic)
+}
+func (m *ExplainDiscoveryRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExplainDiscoveryRequest.Merge(m, src)
+}
+func (m *ExplainDiscoveryRequest) XXX_Size() int {
+	return xxx_messageInfo_ExplainDiscoveryRequest.Size(m)
+}
+func (m *ExplainDiscoveryRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExplainDiscoveryRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExplainDiscoveryRequest proto.InternalMessageInfo
+
+type DiscoveryCandidate struct {
+	PortName             string   `protobuf:"bytes,1,opt,name=port_name,json=portName,proto3" json:"port_name,omitempty"`
+	DevicePath           string   `protobuf:"bytes,2,opt,name=device_path,json=devicePath,proto3" json:"device_path,omitempty"`
+	ByIdPath             string   `protobuf:"bytes,3,opt,name=by_id_path,json=byIdPath,proto3" json:"by_id_path,omitempty"`
+	VendorId             string   `protobuf:"bytes,4,opt,name=vendor_id,json=vendorId,proto3" json:"vendor_id,omitempty"`
+	ProductId            string   `protobuf:"bytes,5,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	Accepted             bool     `protobuf:"varint,6,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	Reason               string   `protobuf:"bytes,7,opt,name=reason,proto3" json:"reason,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DiscoveryCandidate) Reset()         { *m = DiscoveryCandidate{} }
+func (m *DiscoveryCandidate) String() string { return proto.CompactTextString(m) }
+func (*DiscoveryCandidate) ProtoMessage()    {}
+func (*DiscoveryCandidate) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{38}
+}
+
+func (m *DiscoveryCandidate) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DiscoveryCandidate.Unmarshal(m, b)
+}
+func (m *DiscoveryCandidate) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DiscoveryCandidate.Marshal(b, m, deterministic)
+}
+func (m *DiscoveryCandidate) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DiscoveryCandidate.Merge(m, src)
+}
+func (m *DiscoveryCandidate) XXX_Size() int {
+	return xxx_messageInfo_DiscoveryCandidate.Size(m)
+}
+func (m *DiscoveryCandidate) XXX_DiscardUnknown() {
+	xxx_messageInfo_DiscoveryCandidate.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DiscoveryCandidate proto.InternalMessageInfo
+
+func (m *DiscoveryCandidate) GetPortName() string {
+	if m != nil {
+		return m.PortName
+	}
+	return ""
+}
+
+func (m *DiscoveryCandidate) GetDevicePath() string {
+	if m != nil {
+		return m.DevicePath
+	}
+	return ""
+}
+
+func (m *DiscoveryCandidate) GetByIdPath() string {
+	if m != nil {
+		return m.ByIdPath
+	}
+	return ""
+}
+
+func (m *DiscoveryCandidate) GetVendorId() string {
+	if m != nil {
+		return m.VendorId
+	}
+	return ""
+}
+
+func (m *DiscoveryCandidate) GetProductId() string {
+	if m != nil {
+		return m.ProductId
+	}
+	return ""
+}
+
+func (m *DiscoveryCandidate) GetAccepted() bool {
+	if m != nil {
+		return m.Accepted
+	}
+	return false
+}
+
+func (m *DiscoveryCandidate) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+type ExplainDiscoveryResponse struct {
+	Candidates           []*DiscoveryCandidate `protobuf:"bytes,1,rep,name=candidates,proto3" json:"candidates,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
+}
+
+func (m *ExplainDiscoveryResponse) Reset()         { *m = ExplainDiscoveryResponse{} }
+func (m *ExplainDiscoveryResponse) String() string { return proto.CompactTextString(m) }
+func (*ExplainDiscoveryResponse) ProtoMessage()    {}
+func (*ExplainDiscoveryResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{39}
+}
+
+func (m *ExplainDiscoveryResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExplainDiscoveryResponse.Unmarshal(m, b)
+}
+func (m *ExplainDiscoveryResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExplainDiscoveryResponse.Marshal(b, m, deterministic)
+}
+func (m *ExplainDiscoveryResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExplainDiscoveryResponse.Merge(m, src)
+}
+func (m *ExplainDiscoveryResponse) XXX_Size() int {
+	return xxx_messageInfo_ExplainDiscoveryResponse.Size(m)
+}
+func (m *ExplainDiscoveryResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExplainDiscoveryResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExplainDiscoveryResponse proto.InternalMessageInfo
+
+func (m *ExplainDiscoveryResponse) GetCandidates() []*DiscoveryCandidate {
+	if m != nil {
+		return m.Candidates
+	}
+	return nil
+}
+
+type GetServerInfoRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetServerInfoRequest) Reset()         { *m = GetServerInfoRequest{} }
+func (m *GetServerInfoRequest) String() string { return proto.CompactTextString(m) }
+func (*GetServerInfoRequest) ProtoMessage()    {}
+func (*GetServerInfoRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{40}
+}
+
+func (m *GetServerInfoRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetServerInfoRequest.Unmarshal(m, b)
+}
+func (m *GetServerInfoRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetServerInfoRequest.Marshal(b, m, deterministic)
+}
+func (m *GetServerInfoRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetServerInfoRequest.Merge(m, src)
+}
+func (m *GetServerInfoRequest) XXX_Size() int {
+	return xxx_messageInfo_GetServerInfoRequest.Size(m)
+}
+func (m *GetServerInfoRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetServerInfoRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetServerInfoRequest proto.InternalMessageInfo
+
+type FlasherTool struct {
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// empty if the tool wasn't found
+	Path                 string   `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	Version              string   `protobuf:"bytes,3,opt,name=version,proto3" json:"version,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FlasherTool) Reset()         { *m = FlasherTool{} }
+func (m *FlasherTool) String() string { return proto.CompactTextString(m) }
+func (*FlasherTool) ProtoMessage()    {}
+func (*FlasherTool) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{41}
+}
+
+func (m *FlasherTool) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FlasherTool.Unmarshal(m, b)
+}
+func (m *FlasherTool) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FlasherTool.Marshal(b, m, deterministic)
+}
+func (m *FlasherTool) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FlasherTool.Merge(m, src)
+}
+func (m *FlasherTool) XXX_Size() int {
+	return xxx_messageInfo_FlasherTool.Size(m)
+}
+func (m *FlasherTool) XXX_DiscardUnknown() {
+	xxx_messageInfo_FlasherTool.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FlasherTool proto.InternalMessageInfo
+
+func (m *FlasherTool) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *FlasherTool) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *FlasherTool) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+type GetServerInfoResponse struct {
+	Version              string         `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	GoVersion            string         `protobuf:"bytes,2,opt,name=go_version,json=goVersion,proto3" json:"go_version,omitempty"`
+	VcsRevision          string         `protobuf:"bytes,3,opt,name=vcs_revision,json=vcsRevision,proto3" json:"vcs_revision,omitempty"`
+	VcsTime              string         `protobuf:"bytes,4,opt,name=vcs_time,json=vcsTime,proto3" json:"vcs_time,omitempty"`
+	VcsModified          bool           `protobuf:"varint,5,opt,name=vcs_modified,json=vcsModified,proto3" json:"vcs_modified,omitempty"`
+	UptimeSeconds        int64          `protobuf:"varint,6,opt,name=uptime_seconds,json=uptimeSeconds,proto3" json:"uptime_seconds,omitempty"`
+	Hostname             string         `protobuf:"bytes,7,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	Os                   string         `protobuf:"bytes,8,opt,name=os,proto3" json:"os,omitempty"`
+	Arch                 string         `protobuf:"bytes,9,opt,name=arch,proto3" json:"arch,omitempty"`
+	FlasherTools         []*FlasherTool `protobuf:"bytes,10,rep,name=flasher_tools,json=flasherTools,proto3" json:"flasher_tools,omitempty"`
+	DiscoveryBackend     string         `protobuf:"bytes,11,opt,name=discovery_backend,json=discoveryBackend,proto3" json:"discovery_backend,omitempty"`
+	MhistConnected       bool           `protobuf:"varint,12,opt,name=mhist_connected,json=mhistConnected,proto3" json:"mhist_connected,omitempty"`
+	Features             []string       `protobuf:"bytes,13,rep,name=features,proto3" json:"features,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *GetServerInfoResponse) Reset()         { *m = GetServerInfoResponse{} }
+func (m *GetServerInfoResponse) String() string { return proto.CompactTextString(m) }
+func (*GetServerInfoResponse) ProtoMessage()    {}
+func (*GetServerInfoResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{42}
+}
+
+func (m *GetServerInfoResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetServerInfoResponse.Unmarshal(m, b)
+}
+func (m *GetServerInfoResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetServerInfoResponse.Marshal(b, m, deterministic)
+}
+func (m *GetServerInfoResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetServerInfoResponse.Merge(m, src)
+}
+func (m *GetServerInfoResponse) XXX_Size() int {
+	return xxx_messageInfo_GetServerInfoResponse.Size(m)
+}
+func (m *GetServerInfoResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetServerInfoResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetServerInfoResponse proto.InternalMessageInfo
+
+func (m *GetServerInfoResponse) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+func (m *GetServerInfoResponse) GetGoVersion() string {
+	if m != nil {
+		return m.GoVersion
+	}
+	return ""
+}
+
+func (m *GetServerInfoResponse) GetVcsRevision() string {
+	if m != nil {
+		return m.VcsRevision
+	}
+	return ""
+}
+
+func (m *GetServerInfoResponse) GetVcsTime() string {
+	if m != nil {
+		return m.VcsTime
+	}
+	return ""
+}
+
+func (m *GetServerInfoResponse) GetVcsModified() bool {
+	if m != nil {
+		return m.VcsModified
+	}
+	return false
+}
+
+func (m *GetServerInfoResponse) GetUptimeSeconds() int64 {
+	if m != nil {
+		return m.UptimeSeconds
+	}
+	return 0
+}
+
+func (m *GetServerInfoResponse) GetHostname() string {
+	if m != nil {
+		return m.Hostname
+	}
+	return ""
+}
+
+func (m *GetServerInfoResponse) GetOs() string {
+	if m != nil {
+		return m.Os
+	}
+	return ""
+}
+
+func (m *GetServerInfoResponse) GetArch() string {
+	if m != nil {
+		return m.Arch
+	}
+	return ""
+}
+
+func (m *GetServerInfoResponse) GetFlasherTools() []*FlasherTool {
+	if m != nil {
+		return m.FlasherTools
+	}
+	return nil
+}
+
+func (m *GetServerInfoResponse) GetDiscoveryBackend() string {
+	if m != nil {
+		return m.DiscoveryBackend
+	}
+	return ""
+}
+
+func (m *GetServerInfoResponse) GetMhistConnected() bool {
+	if m != nil {
+		return m.MhistConnected
+	}
+	return false
+}
+
+func (m *GetServerInfoResponse) GetFeatures() []string {
+	if m != nil {
+		return m.Features
+	}
+	return nil
+}
+
+type SessionRequest struct {
+	// opens the session in the first request, ignored afterwards
+	ControllerPortName string `protobuf:"bytes,1,opt,name=controller_port_name,json=controllerPortName,proto3" json:"controller_port_name,omitempty"`
+	// written to the controller as is, may be empty in the first request
+	Message []byte `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	// in the first request: send every written message back as ECHO event
+	Echo                 bool     `protobuf:"varint,3,opt,name=echo,proto3" json:"echo,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SessionRequest) Reset()         { *m = SessionRequest{} }
+func (m *SessionRequest) String() string { return proto.CompactTextString(m) }
+func (*SessionRequest) ProtoMessage()    {}
+func (*SessionRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{43}
+}
+
+func (m *SessionRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SessionRequest.Unmarshal(m, b)
+}
+func (m *SessionRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SessionRequest.Marshal(b, m, deterministic)
+}
+func (m *SessionRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SessionRequest.Merge(m, src)
+}
+func (m *SessionRequest) XXX_Size() int {
+	return xxx_messageInfo_SessionRequest.Size(m)
+}
+func (m *SessionRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SessionRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SessionRequest proto.InternalMessageInfo
+
+func (m *SessionRequest) GetControllerPortName() string {
+	if m != nil {
+		return m.ControllerPortName
+	}
+	return ""
+}
+
+func (m *SessionRequest) GetMessage() []byte {
+	if m != nil {
+		return m.Message
+	}
+	return nil
+}
+
+func (m *SessionRequest) GetEcho() bool {
+	if m != nil {
+		return m.Echo
+	}
+	return false
+}
+
+type SessionEvent struct {
+	Type               SessionEvent_Type `protobuf:"varint,1,opt,name=type,proto3,enum=proto.SessionEvent_Type" json:"type,omitempty"`
+	ControllerPortName string            `protobuf:"bytes,2,opt,name=controller_port_name,json=controllerPortName,proto3" json:"controller_port_name,omitempty"`
+	// the line for OUTPUT, the written message for ECHO
+	Data []byte `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	// when the line was read or the message written
+	TimeUnixNano int64 `protobuf:"varint,4,opt,name=time_unix_nano,json=timeUnixNano,proto3" json:"time_unix_nano,omitempty"`
+	// why writing failed for ERROR, why the session ended for CLOSED
+	Reason               string   `protobuf:"bytes,5,opt,name=reason,proto3" json:"reason,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SessionEvent) Reset()         { *m = SessionEvent{} }
+func (m *SessionEvent) String() string { return proto.CompactTextString(m) }
+func (*SessionEvent) ProtoMessage()    {}
+func (*SessionEvent) Descriptor() ([]byte, []int) {
+	return fileDescriptor_dc3f62d3478b1775, []int{44}
+}
+
+func (m *SessionEvent) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SessionEvent.Unmarshal(m, b)
+}
+func (m *SessionEvent) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SessionEvent.Marshal(b, m, deterministic)
+}
+func (m *SessionEvent) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SessionEvent.Merge(m, src)
+}
+func (m *SessionEvent) XXX_Size() int {
+	return xxx_messageInfo_SessionEvent.Size(m)
+}
+func (m *SessionEvent) XXX_DiscardUnknown() {
+	xxx_messageInfo_SessionEvent.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SessionEvent proto.InternalMessageInfo
+
+func (m *SessionEvent) GetType() SessionEvent_Type {
+	if m != nil {
+		return m.Type
+	}
+	return SessionEvent_OUTPUT
+}
+
+func (m *SessionEvent) GetControllerPortName() string {
+	if m != nil {
+		return m.ControllerPortName
+	}
+	return ""
 }
-func (m *WriteToControllerResponse) XXX_Size() int {
-	return xxx_messageInfo_WriteToControllerResponse.Size(m)
+
+func (m *SessionEvent) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
 }
-func (m *WriteToControllerResponse) XXX_DiscardUnknown() {
-	xxx_messageInfo_WriteToControllerResponse.DiscardUnknown(m)
+
+func (m *SessionEvent) GetTimeUnixNano() int64 {
+	if m != nil {
+		return m.TimeUnixNano
+	}
+	return 0
 }
 
-var xxx_messageInfo_WriteToControllerResponse proto.InternalMessageInfo
+func (m *SessionEvent) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
 
 func init() {
+	proto.RegisterEnum("proto.FirmwareFormat", FirmwareFormat_name, FirmwareFormat_value)
+	proto.RegisterEnum("proto.FlashReport_Failure", FlashReport_Failure_name, FlashReport_Failure_value)
+	proto.RegisterEnum("proto.FlashProgress_Phase", FlashProgress_Phase_name, FlashProgress_Phase_value)
+	proto.RegisterEnum("proto.SessionEvent_Type", SessionEvent_Type_name, SessionEvent_Type_value)
 	proto.RegisterType((*ControllerInfo)(nil), "proto.ControllerInfo")
+	proto.RegisterMapType((map[string]string)(nil), "proto.ControllerInfo.LabelsEntry")
+	proto.RegisterType((*SetControllerLabelsRequest)(nil), "proto.SetControllerLabelsRequest")
+	proto.RegisterMapType((map[string]string)(nil), "proto.SetControllerLabelsRequest.LabelsEntry")
+	proto.RegisterType((*ControllerSelector)(nil), "proto.ControllerSelector")
+	proto.RegisterMapType((map[string]string)(nil), "proto.ControllerSelector.LabelsEntry")
+	proto.RegisterType((*OutputFilter)(nil), "proto.OutputFilter")
+	proto.RegisterType((*ReadMultiplexedOutputRequest)(nil), "proto.ReadMultiplexedOutputRequest")
+	proto.RegisterType((*MultiplexedOutputLine)(nil), "proto.MultiplexedOutputLine")
 	proto.RegisterType((*ControllerListRequest)(nil), "proto.ControllerListRequest")
 	proto.RegisterType((*ControllerListResponse)(nil), "proto.ControllerListResponse")
 	proto.RegisterType((*ReadControllerOutputRequest)(nil), "proto.ReadControllerOutputRequest")
 	proto.RegisterType((*ReadControllerOutputResponse)(nil), "proto.ReadControllerOutputResponse")
 	proto.RegisterType((*FlashControllerRequest)(nil), "proto.FlashControllerRequest")
 	proto.RegisterType((*FlashControllerResponse)(nil), "proto.FlashControllerResponse")
+	proto.RegisterType((*FlashReport)(nil), "proto.FlashReport")
+	proto.RegisterMapType((map[string]string)(nil), "proto.FlashReport.FusesEntry")
+	proto.RegisterType((*FirmwareChunk)(nil), "proto.FirmwareChunk")
+	proto.RegisterType((*UploadFirmwareResponse)(nil), "proto.UploadFirmwareResponse")
+	proto.RegisterType((*FlashFirmwareRequest)(nil), "proto.FlashFirmwareRequest")
+	proto.RegisterType((*Artifact)(nil), "proto.Artifact")
+	proto.RegisterType((*ArtifactChunk)(nil), "proto.ArtifactChunk")
+	proto.RegisterType((*ListArtifactsRequest)(nil), "proto.ListArtifactsRequest")
+	proto.RegisterType((*ListArtifactsResponse)(nil), "proto.ListArtifactsResponse")
+	proto.RegisterType((*TagArtifactRequest)(nil), "proto.TagArtifactRequest")
+	proto.RegisterType((*DeleteArtifactRequest)(nil), "proto.DeleteArtifactRequest")
+	proto.RegisterType((*DeleteArtifactResponse)(nil), "proto.DeleteArtifactResponse")
+	proto.RegisterType((*FlashControllersRequest)(nil), "proto.FlashControllersRequest")
+	proto.RegisterType((*FlashControllersResponse)(nil), "proto.FlashControllersResponse")
+	proto.RegisterType((*FlashControllerResult)(nil), "proto.FlashControllerResult")
+	proto.RegisterType((*FlashRecord)(nil), "proto.FlashRecord")
+	proto.RegisterType((*FlashHistoryRequest)(nil), "proto.FlashHistoryRequest")
+	proto.RegisterType((*FlashHistoryResponse)(nil), "proto.FlashHistoryResponse")
+	proto.RegisterType((*RollbackControllerRequest)(nil), "proto.RollbackControllerRequest")
+	proto.RegisterType((*FlashProgress)(nil), "proto.FlashProgress")
 	proto.RegisterType((*ResetUsbRequest)(nil), "proto.ResetUsbRequest")
 	proto.RegisterType((*ResetUsbResponse)(nil), "proto.ResetUsbResponse")
 	proto.RegisterType((*WriteToControllerRequest)(nil), "proto.WriteToControllerRequest")
 	proto.RegisterType((*WriteToControllerResponse)(nil), "proto.WriteToControllerResponse")
+	proto.RegisterType((*WriteToControllersRequest)(nil), "proto.WriteToControllersRequest")
+	proto.RegisterType((*WriteToControllersAck)(nil), "proto.WriteToControllersAck")
+	proto.RegisterType((*ExplainDiscoveryRequest)(nil), "proto.ExplainDiscoveryRequest")
+	proto.RegisterType((*DiscoveryCandidate)(nil), "proto.DiscoveryCandidate")
+	proto.RegisterType((*ExplainDiscoveryResponse)(nil), "proto.ExplainDiscoveryResponse")
+	proto.RegisterType((*GetServerInfoRequest)(nil), "proto.GetServerInfoRequest")
+	proto.RegisterType((*FlasherTool)(nil), "proto.FlasherTool")
+	proto.RegisterType((*GetServerInfoResponse)(nil), "proto.GetServerInfoResponse")
+	proto.RegisterType((*SessionRequest)(nil), "proto.SessionRequest")
+	proto.RegisterType((*SessionEvent)(nil), "proto.SessionEvent")
+}
+
+func init() { proto.RegisterFile("proto/protocol.proto", fileDescriptor_dc3f62d3478b1775) }
+
+var fileDescriptor_dc3f62d3478b1775 = []byte{
+	// 2757 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xcc, 0x19, 0x5b, 0x73, 0xdb, 0x58,
+	0xb9, 0xf2, 0x25, 0xb6, 0x3f, 0x27, 0x8e, 0x72, 0x9a, 0xa4, 0xae, 0x93, 0xd2, 0x56, 0xcb, 0x0e,
+	0xa1, 0xbb, 0x9b, 0x2d, 0x5d, 0x16, 0xb6, 0x30, 0x0c, 0x9b, 0x4d, 0x9c, 0xae, 0xa1, 0xb5, 0x33,
+	0xc7, 0x4e, 0xbb, 0xcb, 0x2c, 0xa3, 0x51, 0xa4, 0xe3, 0x44, 0x53, 0x59, 0x32, 0x3a, 0xb2, 0x37,
+	0xe6, 0x0d, 0x18, 0xfe, 0x08, 0xcf, 0xf0, 0xc8, 0x03, 0xaf, 0xc0, 0xf0, 0xcc, 0x65, 0x78, 0x87,
+	0x47, 0xfe, 0x05, 0x73, 0x6e, 0x92, 0x6c, 0x4b, 0x0d, 0xbd, 0xec, 0x0c, 0x2f, 0x89, 0xbe, 0xcb,
+	0xb9, 0x7c, 0x97, 0xf3, 0xdd, 0x0c, 0x9b, 0xe3, 0x30, 0x88, 0x82, 0xf7, 0xf9, 0x5f, 0x3b, 0xf0,
+	0xf6, 0xf9, 0x07, 0x2a, 0xf3, 0x7f, 0xc6, 0x7f, 0x34, 0x68, 0x1c, 0x06, 0x7e, 0x14, 0x06, 0x9e,
+	0x47, 0xc2, 0x8e, 0x3f, 0x0c, 0x50, 0x0b, 0xaa, 0xe3, 0x20, 0x8c, 0xba, 0xd6, 0x88, 0x34, 0xb5,
+	0x3b, 0xda, 0x5e, 0x0d, 0xc7, 0x30, 0x42, 0x50, 0xf2, 0x19, 0xbe, 0xc0, 0xf1, 0xfc, 0x1b, 0x6d,
+	0x42, 0x99, 0x46, 0x56, 0x44, 0x9a, 0x45, 0x8e, 0x14, 0x00, 0x7a, 0x08, 0x2b, 0x9e, 0x75, 0x46,
+	0x3c, 0xda, 0x2c, 0xdd, 0x29, 0xee, 0xd5, 0x1f, 0xdc, 0x15, 0xe7, 0xee, 0xcf, 0x1f, 0xb6, 0xff,
+	0x98, 0xf3, 0xb4, 0xfd, 0x28, 0x9c, 0x61, 0xb9, 0x00, 0xed, 0x40, 0x8d, 0x46, 0xd6, 0x99, 0x47,
+	0x4c, 0xd7, 0x69, 0x96, 0xc5, 0x0d, 0x04, 0xa2, 0xe3, 0xb4, 0x1e, 0x42, 0x3d, 0xb5, 0x06, 0xe9,
+	0x50, 0x7c, 0x4e, 0x66, 0xf2, 0x9e, 0xec, 0x93, 0x5d, 0x67, 0x6a, 0x79, 0x13, 0x75, 0x47, 0x01,
+	0x7c, 0xaf, 0xf0, 0x91, 0x66, 0xfc, 0x55, 0x83, 0x56, 0x9f, 0x44, 0xc9, 0x0d, 0xc4, 0x46, 0x98,
+	0xfc, 0x6c, 0x42, 0x68, 0x84, 0xee, 0xc3, 0xa6, 0x1d, 0x93, 0x4c, 0x26, 0xb2, 0xe9, 0x27, 0x3a,
+	0x40, 0x09, 0xed, 0x44, 0x69, 0xa3, 0x1d, 0xcb, 0x58, 0xe0, 0x32, 0xbe, 0x27, 0x65, 0xcc, 0x3f,
+	0x24, 0x4b, 0xde, 0xd7, 0x11, 0xe9, 0x9f, 0x1a, 0xa0, 0xe4, 0xa8, 0x3e, 0xf1, 0x88, 0x1d, 0x05,
+	0x21, 0xdb, 0xc2, 0xf2, 0x3c, 0xbe, 0x45, 0x15, 0xb3, 0x4f, 0x74, 0x0b, 0x20, 0x96, 0x48, 0x5c,
+	0xb7, 0x86, 0x6b, 0xca, 0xac, 0x94, 0x9d, 0x20, 0x28, 0x45, 0x4e, 0x11, 0x00, 0xfa, 0xc1, 0x82,
+	0x0d, 0xdf, 0x5e, 0xb2, 0xa1, 0x3a, 0xf1, 0x4d, 0xcb, 0xf5, 0x4b, 0x0d, 0x56, 0x7b, 0x93, 0x68,
+	0x3c, 0x89, 0x8e, 0x5d, 0x2f, 0x22, 0x21, 0x67, 0x25, 0xe1, 0x19, 0x6d, 0x6a, 0xe2, 0x82, 0x1c,
+	0x40, 0x4d, 0xa8, 0xb8, 0xbe, 0xed, 0x4d, 0x1c, 0x22, 0x45, 0x52, 0x20, 0xa3, 0x90, 0x4b, 0x41,
+	0x11, 0x22, 0x29, 0x10, 0xed, 0x81, 0x4e, 0xad, 0xd1, 0xd8, 0x23, 0x26, 0x99, 0x92, 0x70, 0x66,
+	0xfa, 0xd1, 0x45, 0xb3, 0x74, 0x47, 0xdb, 0x5b, 0xc3, 0x0d, 0x81, 0x6f, 0x33, 0x74, 0x37, 0xba,
+	0x60, 0x97, 0xd8, 0xc5, 0xc4, 0x72, 0x9e, 0x4c, 0xbc, 0xc8, 0x1d, 0x7b, 0xe4, 0x92, 0x38, 0xe2,
+	0x4e, 0xca, 0x63, 0x3e, 0x84, 0x2a, 0x95, 0x0a, 0xe0, 0x62, 0xd5, 0x1f, 0xdc, 0xcc, 0xd5, 0x10,
+	0x8e, 0x59, 0xd1, 0x3b, 0xb0, 0x32, 0xe4, 0x52, 0x71, 0xb9, 0xeb, 0x0f, 0xae, 0xcb, 0x45, 0x69,
+	0x81, 0xb1, 0x64, 0x31, 0x7e, 0xab, 0xc1, 0xd6, 0xd2, 0x05, 0x1e, 0xbb, 0x3e, 0x79, 0x05, 0x7f,
+	0xfd, 0x06, 0xac, 0xa7, 0x56, 0xa4, 0x1e, 0x72, 0x23, 0x41, 0xab, 0x67, 0xee, 0xb9, 0xbe, 0x7a,
+	0xd1, 0xfc, 0x1b, 0x7d, 0x1d, 0x1a, 0x91, 0x3b, 0x22, 0xe6, 0xc4, 0x77, 0x2f, 0x4d, 0xdf, 0xf2,
+	0x03, 0xae, 0xb5, 0x22, 0x5e, 0x65, 0xd8, 0x53, 0xdf, 0xbd, 0xec, 0x5a, 0x7e, 0x60, 0xdc, 0x80,
+	0xad, 0x94, 0xeb, 0xbb, 0x54, 0xe9, 0xca, 0xf8, 0x09, 0x6c, 0x2f, 0x12, 0xe8, 0x38, 0xf0, 0x29,
+	0x41, 0x1f, 0x83, 0x9e, 0xba, 0x95, 0xeb, 0x0f, 0x03, 0x61, 0xe5, 0xfa, 0x83, 0xad, 0xcc, 0x98,
+	0x81, 0xd7, 0xed, 0x39, 0x98, 0x1a, 0x7f, 0xd0, 0x60, 0x87, 0x19, 0x2a, 0xe1, 0x9b, 0xb7, 0xd3,
+	0xcb, 0x6b, 0x6a, 0x1b, 0x56, 0x42, 0x42, 0x27, 0x52, 0x41, 0x55, 0x2c, 0x21, 0xf4, 0x16, 0xac,
+	0x0d, 0xc3, 0x60, 0x64, 0x52, 0xb6, 0xb3, 0x6f, 0x0b, 0x0d, 0x95, 0xf0, 0x2a, 0x43, 0xf6, 0x25,
+	0x2e, 0x65, 0xdf, 0xd2, 0xd5, 0xf6, 0xfd, 0x8d, 0x74, 0xb2, 0xe5, 0xbb, 0x4b, 0xf5, 0x6c, 0xc3,
+	0x4a, 0xc0, 0x31, 0xf2, 0xba, 0x12, 0x62, 0x61, 0x3a, 0xbe, 0x45, 0x81, 0xdf, 0x22, 0x86, 0x33,
+	0x6c, 0x55, 0x5c, 0xb6, 0x15, 0x7b, 0x90, 0xe7, 0xd6, 0x98, 0x5f, 0xb2, 0x8a, 0xd9, 0x27, 0x3b,
+	0x6b, 0xe4, 0x52, 0x4a, 0x44, 0xd8, 0x2d, 0x61, 0x09, 0x19, 0x7f, 0xd1, 0x60, 0xfb, 0xd8, 0xb3,
+	0xe8, 0x45, 0x72, 0xcb, 0x57, 0xd7, 0xed, 0x1e, 0xe8, 0x17, 0xe4, 0xd2, 0x1c, 0xba, 0x1e, 0x31,
+	0x19, 0x99, 0xf8, 0x11, 0x17, 0x60, 0x15, 0x37, 0x2e, 0xc8, 0xe5, 0xb1, 0xeb, 0x91, 0x43, 0x81,
+	0x45, 0xef, 0xc1, 0xca, 0x30, 0x08, 0x47, 0x56, 0xc4, 0xaf, 0xdf, 0x88, 0xfd, 0xe1, 0xd8, 0x0d,
+	0x47, 0x5f, 0x5a, 0x21, 0x39, 0xe6, 0x44, 0x2c, 0x99, 0xd0, 0x5d, 0x58, 0x3d, 0xb3, 0x28, 0x31,
+	0x2d, 0xc7, 0x09, 0x09, 0xa5, 0xf2, 0x55, 0xd7, 0x19, 0xee, 0x40, 0xa0, 0x8c, 0x9f, 0xc2, 0x8d,
+	0x25, 0x39, 0xae, 0xd0, 0xf3, 0x3d, 0xe6, 0x0a, 0x4c, 0x2e, 0xf9, 0x5a, 0x91, 0xba, 0x04, 0xdb,
+	0x07, 0x73, 0x0a, 0x96, 0x1c, 0xc6, 0xaf, 0x4b, 0x50, 0x4f, 0xe1, 0xd1, 0x37, 0x41, 0x77, 0xc8,
+	0xd4, 0xb5, 0x89, 0x49, 0xdd, 0x73, 0xdf, 0x8a, 0x26, 0xa1, 0x52, 0xcc, 0xba, 0xc0, 0xf7, 0x15,
+	0x9a, 0x1d, 0x2f, 0x50, 0xf2, 0x49, 0x4a, 0x88, 0x79, 0xdc, 0xd9, 0x2c, 0x22, 0xd4, 0xfc, 0x32,
+	0x74, 0xa3, 0x88, 0xf8, 0xca, 0xe3, 0x38, 0xf2, 0x99, 0xc0, 0xa1, 0xb7, 0xa1, 0x21, 0x98, 0xa6,
+	0x24, 0x74, 0x87, 0x2e, 0x71, 0xb8, 0xec, 0x25, 0x2c, 0x96, 0x3e, 0x95, 0x48, 0xe6, 0x32, 0x31,
+	0x43, 0x99, 0x5b, 0x3d, 0x86, 0xd1, 0x07, 0x50, 0x1e, 0x4e, 0x28, 0xa1, 0xcd, 0x15, 0xfe, 0xf4,
+	0x6e, 0x2d, 0x4b, 0xb9, 0x7f, 0xcc, 0xe8, 0x22, 0xc4, 0x0b, 0x5e, 0xa6, 0x71, 0xe2, 0x59, 0x63,
+	0x4a, 0x1c, 0xe1, 0x65, 0x15, 0xee, 0x65, 0x75, 0x89, 0xe3, 0x4e, 0xf6, 0x6d, 0xa8, 0x0c, 0x2d,
+	0xd7, 0x63, 0x92, 0x57, 0xb9, 0x11, 0x5b, 0x59, 0x3b, 0x0b, 0x0e, 0xac, 0x58, 0x5b, 0x1f, 0x01,
+	0x24, 0xa7, 0xbd, 0x6c, 0xe6, 0xa8, 0xc8, 0xed, 0x50, 0x15, 0x4a, 0xdd, 0x5e, 0xb7, 0xad, 0x5f,
+	0x43, 0x75, 0xa8, 0x9c, 0x76, 0x7f, 0xdc, 0xed, 0x3d, 0xeb, 0xea, 0x1a, 0x5a, 0x87, 0x7a, 0xb7,
+	0x37, 0x30, 0x3b, 0x5d, 0xb3, 0xff, 0x79, 0xf7, 0x50, 0x2f, 0xa0, 0xeb, 0xb0, 0xfe, 0x0c, 0xf7,
+	0xba, 0x8f, 0xcc, 0x7e, 0xe7, 0x51, 0xf7, 0x60, 0x70, 0x8a, 0xdb, 0x7a, 0x11, 0x6d, 0xc1, 0xc6,
+	0x49, 0x1b, 0x3f, 0xe9, 0xf4, 0xfb, 0x9d, 0x5e, 0xd7, 0x3c, 0x6a, 0x77, 0x3b, 0xed, 0x23, 0xbd,
+	0xc4, 0x76, 0x1a, 0x74, 0x9e, 0xb4, 0x7b, 0xa7, 0x03, 0xbd, 0x8c, 0xb6, 0x01, 0x3d, 0x6d, 0xe3,
+	0xce, 0x71, 0xe7, 0xf0, 0x60, 0xc0, 0xb8, 0xda, 0x18, 0xf7, 0xb0, 0xbe, 0x62, 0xbc, 0x05, 0x6b,
+	0xca, 0x47, 0x0f, 0x2f, 0x26, 0xfe, 0x73, 0x16, 0x50, 0x1d, 0x2b, 0xb2, 0xb8, 0x08, 0xab, 0x98,
+	0x7f, 0x1b, 0x1d, 0xd8, 0x3e, 0x1d, 0x7b, 0x81, 0xe5, 0x28, 0xd6, 0xd8, 0x15, 0x77, 0xa0, 0x36,
+	0xe1, 0x14, 0x56, 0x00, 0xc9, 0x12, 0x4c, 0x20, 0x3a, 0x0e, 0xdb, 0x8a, 0xba, 0x3f, 0x57, 0x6f,
+	0x9e, 0x7f, 0x1b, 0xff, 0xd0, 0x60, 0x93, 0xeb, 0x33, 0xd9, 0xea, 0x55, 0x5f, 0xe7, 0xdc, 0xd9,
+	0x85, 0x85, 0xb3, 0xdf, 0xf8, 0x83, 0x64, 0x2e, 0x69, 0x85, 0x91, 0x3b, 0xb4, 0xec, 0x48, 0x95,
+	0x7a, 0x0a, 0x36, 0xfe, 0x58, 0x80, 0xea, 0x81, 0x04, 0x50, 0x03, 0x0a, 0xb1, 0x32, 0x0a, 0xae,
+	0x93, 0x59, 0x89, 0x36, 0xa1, 0x32, 0x25, 0x21, 0x75, 0x03, 0x5f, 0x66, 0x2e, 0x05, 0x32, 0x7f,
+	0x39, 0x0b, 0xac, 0x50, 0xbc, 0x8b, 0x1a, 0x16, 0x00, 0xc3, 0xfa, 0x41, 0x44, 0xa8, 0x3c, 0x59,
+	0x00, 0x29, 0x21, 0x57, 0x5e, 0x45, 0xc8, 0xca, 0xb2, 0x90, 0xca, 0x64, 0xd5, 0xc4, 0x64, 0x0c,
+	0x17, 0x59, 0xe7, 0xb4, 0x59, 0xe3, 0xd5, 0x09, 0xff, 0x46, 0xdf, 0x82, 0x2d, 0xa1, 0x6a, 0xe2,
+	0x98, 0x56, 0x94, 0x8a, 0xde, 0xc0, 0xdf, 0x15, 0x52, 0xc4, 0x83, 0x28, 0x8e, 0xe1, 0xb7, 0xa1,
+	0x1e, 0x2f, 0x39, 0x9b, 0x35, 0xeb, 0x5c, 0x10, 0x50, 0xa8, 0x4f, 0x66, 0xc6, 0x09, 0xac, 0x29,
+	0x1d, 0x0a, 0x57, 0x7c, 0x07, 0xaa, 0x23, 0x12, 0x59, 0xb1, 0x3b, 0xd6, 0x1f, 0xac, 0x4b, 0x01,
+	0x15, 0x1f, 0x8e, 0x19, 0x62, 0xbf, 0x2d, 0xa4, 0xfc, 0xf6, 0x1e, 0x6c, 0xb2, 0xfc, 0xad, 0xb8,
+	0xe3, 0xfa, 0x59, 0x59, 0x44, 0x4b, 0x2c, 0x62, 0x1c, 0xc3, 0xd6, 0x02, 0xaf, 0x74, 0xf1, 0xf7,
+	0xa0, 0xa6, 0xec, 0xac, 0xb2, 0xfd, 0xd2, 0x35, 0x12, 0x0e, 0xe3, 0x0b, 0x40, 0x03, 0xeb, 0x3c,
+	0xa6, 0xc8, 0x13, 0x77, 0xa1, 0x16, 0x92, 0x21, 0x09, 0x79, 0x0e, 0x14, 0xc7, 0x26, 0x08, 0x16,
+	0x35, 0x22, 0xeb, 0x5c, 0x3a, 0x08, 0xfb, 0x14, 0x59, 0x7d, 0x14, 0x4c, 0x45, 0xda, 0xae, 0x62,
+	0x09, 0x19, 0x1f, 0xc2, 0xd6, 0x11, 0xf1, 0x48, 0x44, 0x5e, 0xea, 0x00, 0xa3, 0x09, 0xdb, 0x8b,
+	0xcb, 0x84, 0x74, 0xc6, 0xef, 0x0a, 0x4b, 0x79, 0x86, 0xbe, 0x66, 0xd1, 0xf8, 0xff, 0xf3, 0x2e,
+	0xd1, 0x1d, 0xa8, 0x8f, 0xad, 0xd0, 0xf2, 0x3c, 0xe2, 0xb9, 0x74, 0xc4, 0x5f, 0x49, 0x19, 0xa7,
+	0x51, 0x68, 0x1f, 0xae, 0xb3, 0xd0, 0xe2, 0xfa, 0x13, 0x62, 0x06, 0xbe, 0xa9, 0x12, 0x40, 0x85,
+	0x6b, 0x7d, 0x43, 0x91, 0x7a, 0xbe, 0x0c, 0xd4, 0x06, 0x86, 0xe6, 0xb2, 0xba, 0xa4, 0xa7, 0x7c,
+	0x07, 0x2a, 0xac, 0xf8, 0xf2, 0x62, 0x3f, 0xd9, 0x4d, 0x27, 0x90, 0xb9, 0x44, 0x3e, 0xf1, 0x22,
+	0xac, 0x98, 0x8d, 0xbf, 0x15, 0x60, 0x2b, 0x93, 0xe5, 0xab, 0x2c, 0x9c, 0xe7, 0xb7, 0x4e, 0xba,
+	0xd8, 0xe2, 0xe2, 0xd6, 0x7d, 0xd9, 0xcf, 0xb2, 0x57, 0x63, 0x07, 0x0e, 0xe1, 0x36, 0x28, 0x63,
+	0xfe, 0xcd, 0xe2, 0x12, 0x09, 0xc3, 0x20, 0x54, 0x71, 0x89, 0x03, 0xa9, 0x02, 0x65, 0x65, 0xae,
+	0x40, 0x69, 0x42, 0x85, 0x3e, 0x77, 0xc7, 0x63, 0xe2, 0x48, 0x05, 0x2b, 0x90, 0xd5, 0x0e, 0xce,
+	0x24, 0xb4, 0x22, 0x37, 0xf0, 0x45, 0x1c, 0xa9, 0x8a, 0x2a, 0x50, 0x21, 0x79, 0x04, 0x49, 0xea,
+	0x9b, 0xda, 0x95, 0xf5, 0xcd, 0xef, 0x0b, 0x71, 0x7d, 0x63, 0x07, 0xa1, 0x83, 0x6e, 0x42, 0xd5,
+	0x1d, 0x59, 0xe7, 0x24, 0xc9, 0x53, 0x15, 0x0e, 0x77, 0x1c, 0x16, 0x98, 0x94, 0xc3, 0x24, 0x1e,
+	0x0b, 0x0a, 0xd5, 0x71, 0x98, 0x38, 0x34, 0x98, 0x84, 0xb6, 0xea, 0x32, 0x24, 0x94, 0x6b, 0x9d,
+	0xd2, 0x8b, 0x8a, 0x75, 0xdb, 0x73, 0x59, 0x19, 0x29, 0xf4, 0x25, 0x21, 0xf4, 0x3e, 0x6c, 0xd2,
+	0xc8, 0x0a, 0xa3, 0xc5, 0x68, 0xba, 0xc2, 0xb5, 0xb0, 0x21, 0x69, 0xa9, 0x60, 0xba, 0xa4, 0xaf,
+	0x4a, 0x86, 0xbe, 0x76, 0xa1, 0x46, 0x27, 0xb6, 0x4d, 0x88, 0x43, 0x1c, 0xae, 0xd0, 0x2a, 0x4e,
+	0x10, 0x89, 0xe9, 0x6a, 0x29, 0xd3, 0x19, 0x33, 0xb8, 0xce, 0xd5, 0xf6, 0xa9, 0x4b, 0xa3, 0x20,
+	0x9c, 0xbd, 0x7a, 0x76, 0xce, 0xf3, 0xaf, 0x42, 0x9e, 0x7f, 0x19, 0x53, 0xd8, 0x9c, 0x3f, 0x5a,
+	0x3e, 0xab, 0xbc, 0x9d, 0xb4, 0x5c, 0x4f, 0x7d, 0x97, 0x3d, 0x44, 0x66, 0x76, 0x35, 0xee, 0x58,
+	0xf0, 0x14, 0x46, 0xc2, 0x8a, 0xc5, 0x78, 0x02, 0x37, 0x71, 0xe0, 0x79, 0x67, 0x96, 0xfd, 0xfc,
+	0x0d, 0x34, 0x0d, 0xc6, 0xbf, 0x35, 0x58, 0xe3, 0xe7, 0x9c, 0x84, 0xc1, 0x39, 0x8f, 0x50, 0xf7,
+	0xa1, 0x3c, 0xbe, 0xb0, 0xa8, 0x58, 0xb4, 0x50, 0x56, 0x2a, 0xa6, 0xfd, 0x13, 0xc6, 0x81, 0x05,
+	0x23, 0x7b, 0x28, 0x63, 0x12, 0xda, 0xaa, 0xdf, 0x28, 0x63, 0x05, 0xa6, 0x9e, 0x56, 0x31, 0xa7,
+	0xf6, 0x2f, 0x5d, 0xf9, 0x36, 0x3e, 0x86, 0x32, 0x3f, 0x2d, 0x5d, 0x6b, 0x5e, 0x43, 0x35, 0x28,
+	0xb7, 0xf1, 0x41, 0xbf, 0xad, 0x6b, 0xec, 0xf3, 0x19, 0xee, 0x0c, 0xda, 0x7a, 0x01, 0x01, 0xac,
+	0xf0, 0xba, 0xf1, 0x73, 0xbd, 0xc8, 0x8a, 0xd4, 0x23, 0x56, 0xa4, 0x96, 0x8c, 0x0d, 0x58, 0xc7,
+	0x84, 0x92, 0xe8, 0x94, 0x9e, 0xa9, 0xae, 0xf9, 0x1e, 0xe8, 0x09, 0xea, 0xc5, 0x8d, 0x8a, 0x31,
+	0x84, 0x26, 0xeb, 0x07, 0xc8, 0x20, 0x78, 0x13, 0x5d, 0x5a, 0x13, 0x2a, 0x23, 0x42, 0xa9, 0x75,
+	0x4e, 0x64, 0xf2, 0x57, 0xa0, 0xb1, 0x03, 0x37, 0x33, 0xce, 0x91, 0x99, 0xef, 0xef, 0x5a, 0x06,
+	0x35, 0xce, 0x7d, 0x49, 0x11, 0x57, 0xe2, 0x45, 0x5c, 0xde, 0xb5, 0x0a, 0x2f, 0xfd, 0x00, 0xf2,
+	0x03, 0x6c, 0x46, 0xec, 0x2e, 0x65, 0xc6, 0xee, 0x94, 0xc4, 0xe5, 0x79, 0x89, 0x7f, 0xa5, 0xc1,
+	0xd6, 0xb2, 0x50, 0x07, 0xf6, 0xf3, 0x37, 0x20, 0x90, 0x8a, 0xff, 0xc5, 0xac, 0xf8, 0x5f, 0x4a,
+	0x07, 0x91, 0x9b, 0x70, 0xa3, 0x7d, 0x39, 0xf6, 0x2c, 0xd7, 0x3f, 0x72, 0xa9, 0x1d, 0xb0, 0x29,
+	0x95, 0x72, 0x93, 0x7f, 0x69, 0x80, 0x62, 0xe4, 0xa1, 0xe5, 0x3b, 0xae, 0x63, 0x45, 0xbc, 0x96,
+	0x5f, 0x34, 0x75, 0x32, 0xca, 0xbd, 0x0d, 0x75, 0xd9, 0x9b, 0x8e, 0xad, 0xe8, 0x42, 0x05, 0x68,
+	0x81, 0x3a, 0xb1, 0xa2, 0x0b, 0xb4, 0x0b, 0x70, 0x36, 0x33, 0x5d, 0x47, 0xd0, 0x85, 0x82, 0xab,
+	0x67, 0xb3, 0x8e, 0xc3, 0xa9, 0x3b, 0x50, 0x9b, 0x12, 0xdf, 0x09, 0x42, 0xa6, 0x7d, 0x71, 0xcf,
+	0xaa, 0x40, 0x74, 0x1c, 0x3e, 0x6d, 0x0c, 0x03, 0x67, 0x22, 0x62, 0xbf, 0x88, 0xca, 0x35, 0x89,
+	0xe9, 0xf0, 0x3e, 0xd4, 0xb2, 0x6d, 0x32, 0x8e, 0x88, 0xc3, 0x83, 0x71, 0x15, 0xc7, 0xb0, 0xa8,
+	0xd1, 0x2c, 0x1a, 0xf8, 0x3c, 0xf8, 0xd6, 0xb0, 0x84, 0x8c, 0x53, 0x68, 0x2e, 0x4b, 0x2f, 0x5f,
+	0xc4, 0x43, 0x00, 0x5b, 0x09, 0xad, 0xaa, 0x04, 0x55, 0x54, 0x2d, 0xab, 0x05, 0xa7, 0x98, 0x8d,
+	0x6d, 0xd8, 0x7c, 0x44, 0xa2, 0x3e, 0x09, 0xa7, 0x72, 0xb8, 0x24, 0x35, 0xda, 0x93, 0x89, 0x8e,
+	0x84, 0x83, 0x20, 0xf0, 0xb2, 0x6a, 0x5b, 0x86, 0x4b, 0x69, 0x8e, 0x7f, 0xe7, 0x77, 0x20, 0xc6,
+	0x9f, 0x8a, 0xb0, 0xb5, 0x70, 0x92, 0xbc, 0x7d, 0x6a, 0x8d, 0x36, 0xb7, 0x86, 0xa9, 0xf1, 0x3c,
+	0x30, 0x15, 0x51, 0x9c, 0x53, 0x3b, 0x0f, 0x9e, 0x4a, 0xf2, 0x5d, 0x58, 0x9d, 0xda, 0xd4, 0x0c,
+	0xc9, 0xd4, 0x4d, 0x9d, 0x58, 0x9f, 0xda, 0x14, 0x4b, 0x14, 0x4b, 0xd0, 0x8c, 0x85, 0x8d, 0x7d,
+	0xa4, 0x91, 0x2a, 0x53, 0x9b, 0x0e, 0xdc, 0x11, 0x51, 0xab, 0x47, 0x81, 0x93, 0x1e, 0x08, 0xb0,
+	0xd5, 0x4f, 0x24, 0x8a, 0x8d, 0x15, 0x26, 0x63, 0xb6, 0xd6, 0xa4, 0xc4, 0x0e, 0x7c, 0x87, 0xca,
+	0xd4, 0xb9, 0x26, 0xb0, 0x7d, 0x81, 0x64, 0xe6, 0xbc, 0x08, 0x68, 0xc4, 0x15, 0x24, 0x8c, 0x16,
+	0xc3, 0xec, 0x81, 0x04, 0x94, 0xa7, 0xc9, 0x1a, 0x2e, 0x04, 0xbc, 0x15, 0xb2, 0x42, 0xfb, 0x42,
+	0xa6, 0x47, 0xfe, 0x8d, 0xbe, 0x0b, 0x6b, 0x43, 0xa1, 0x6b, 0x33, 0x0a, 0x02, 0x8f, 0x36, 0x61,
+	0x39, 0xbd, 0x08, 0x3b, 0xe0, 0xd5, 0x61, 0x02, 0x50, 0xf4, 0x0e, 0x6c, 0x38, 0xca, 0xbc, 0x26,
+	0xcb, 0x34, 0xc4, 0x77, 0x64, 0x0b, 0xa4, 0xc7, 0x84, 0x4f, 0x04, 0x9e, 0xc5, 0x81, 0xd1, 0x85,
+	0x4b, 0x23, 0x36, 0x73, 0xf2, 0x89, 0xcd, 0x7c, 0x6f, 0x95, 0x8b, 0xdc, 0xe0, 0xe8, 0x43, 0x85,
+	0x65, 0xe2, 0x0c, 0x09, 0x1f, 0xca, 0xd0, 0xe6, 0x1a, 0xef, 0xce, 0x62, 0xd8, 0x18, 0x43, 0xa3,
+	0x4f, 0x28, 0x53, 0xed, 0x57, 0x10, 0x59, 0x99, 0x72, 0x88, 0x7d, 0x11, 0xc8, 0xee, 0x84, 0x7f,
+	0x1b, 0xbf, 0x28, 0xc0, 0xaa, 0x3c, 0xb2, 0x3d, 0x65, 0xb9, 0xea, 0x5d, 0x28, 0x45, 0xb3, 0xb1,
+	0x4a, 0x7b, 0xcd, 0xf8, 0x27, 0x87, 0x84, 0x65, 0x7f, 0x30, 0x1b, 0x13, 0xcc, 0xb9, 0x5e, 0x2d,
+	0x20, 0xf1, 0x96, 0xaf, 0x98, 0xb4, 0x7c, 0xff, 0xdb, 0xec, 0x37, 0xf5, 0x74, 0xcb, 0x73, 0x4f,
+	0xf7, 0x87, 0x50, 0x62, 0x37, 0x62, 0x59, 0xaf, 0x77, 0x3a, 0x38, 0x39, 0x1d, 0xe8, 0xd7, 0x58,
+	0xd6, 0x6b, 0x1f, 0x7e, 0xda, 0xd3, 0x35, 0x8e, 0x3d, 0x69, 0x77, 0xdb, 0x47, 0x7a, 0x41, 0x64,
+	0x4b, 0x36, 0x42, 0x29, 0x32, 0xf4, 0xe1, 0xe3, 0x5e, 0x9f, 0xcd, 0x5c, 0xee, 0x7d, 0x08, 0x8d,
+	0xf9, 0x4e, 0x86, 0x2d, 0x3f, 0x38, 0x1d, 0xf4, 0xf4, 0x6b, 0xa8, 0x02, 0xc5, 0x4f, 0xdb, 0x9f,
+	0xe9, 0x1a, 0xfb, 0x68, 0x3f, 0x3e, 0xd6, 0x0b, 0xec, 0xe3, 0x93, 0x4e, 0x57, 0x2f, 0x3e, 0xf8,
+	0x73, 0x03, 0x56, 0xbb, 0x24, 0x9c, 0x06, 0xec, 0xd1, 0xb1, 0x59, 0x5a, 0x17, 0xd6, 0x1f, 0x0b,
+	0x53, 0xab, 0x18, 0x8e, 0x76, 0x97, 0x7a, 0xaf, 0xd4, 0xd0, 0xba, 0x75, 0x2b, 0x87, 0x2a, 0x5f,
+	0xae, 0x09, 0x9b, 0x59, 0xa3, 0x5b, 0x64, 0xc8, 0x65, 0x2f, 0x98, 0x49, 0xb7, 0xde, 0x7a, 0x21,
+	0x8f, 0x3c, 0xe0, 0x04, 0xd6, 0x17, 0x5a, 0x18, 0x74, 0x2b, 0xaf, 0xfb, 0x11, 0xdb, 0x7e, 0x2d,
+	0x8f, 0x2c, 0x77, 0x1c, 0xc1, 0x9d, 0xac, 0x13, 0x0f, 0x45, 0x4b, 0x16, 0x4c, 0xa8, 0x37, 0x7b,
+	0x63, 0xd7, 0xbf, 0xaf, 0xa1, 0x0e, 0x6c, 0xcc, 0xfd, 0x18, 0xc6, 0x3d, 0x2c, 0x7b, 0xac, 0x7f,
+	0x95, 0xb2, 0xbf, 0x0f, 0x55, 0x55, 0x0a, 0xa1, 0xed, 0xf8, 0xf4, 0xb9, 0x72, 0xa9, 0x75, 0x63,
+	0x09, 0x2f, 0x17, 0x3f, 0x85, 0x8d, 0xa5, 0x04, 0x8e, 0x6e, 0x4b, 0xee, 0xbc, 0xaa, 0xa9, 0x75,
+	0x27, 0x9f, 0x41, 0xee, 0xeb, 0xc0, 0xad, 0x25, 0xe2, 0x9c, 0x2e, 0x5f, 0xff, 0x8c, 0x3d, 0x0d,
+	0xf5, 0x41, 0x5f, 0xcc, 0x7d, 0x48, 0x19, 0x3a, 0xa7, 0x24, 0x68, 0xdd, 0xce, 0xa5, 0xcb, 0xab,
+	0xff, 0x08, 0xd6, 0xe6, 0xf2, 0x11, 0xda, 0x91, 0x2b, 0xb2, 0xf2, 0x61, 0x6b, 0x37, 0x9b, 0x18,
+	0x27, 0xe0, 0x8a, 0x0c, 0x40, 0xb1, 0x71, 0xe7, 0xc3, 0x64, 0xeb, 0x7a, 0x46, 0x9c, 0xda, 0xd3,
+	0xee, 0x6b, 0xe8, 0x19, 0x5c, 0xcf, 0xf8, 0xb9, 0x14, 0xdd, 0xbd, 0xf2, 0xa7, 0xd4, 0xab, 0xfc,
+	0xe5, 0x0b, 0xd8, 0xca, 0xfc, 0xf1, 0x0e, 0xa5, 0x5d, 0x37, 0xef, 0xa7, 0xbd, 0x58, 0xde, 0xcc,
+	0x9f, 0xde, 0xee, 0x6b, 0xe8, 0x33, 0x40, 0xcb, 0x15, 0x21, 0xca, 0x35, 0x26, 0x5d, 0xdc, 0x37,
+	0xb3, 0x9c, 0xe4, 0x0a, 0x79, 0x04, 0x8d, 0xf9, 0xb1, 0x30, 0xda, 0x5c, 0x98, 0xe6, 0xf0, 0x39,
+	0x5e, 0x2c, 0x7e, 0xf6, 0x0c, 0x79, 0x4f, 0x43, 0x47, 0xb2, 0x63, 0x8a, 0xf7, 0xd9, 0x49, 0xc7,
+	0x86, 0x85, 0x49, 0x71, 0x6b, 0x33, 0xab, 0x7f, 0xba, 0xaf, 0xa1, 0x87, 0xea, 0x3a, 0xf1, 0x24,
+	0x76, 0x73, 0x61, 0x4e, 0x27, 0xae, 0xb3, 0x38, 0xbd, 0xdb, 0xd3, 0x98, 0x87, 0xcd, 0x0d, 0xff,
+	0xe2, 0x0b, 0x64, 0x8d, 0x0f, 0x5b, 0xbb, 0xd9, 0xc4, 0xd8, 0xc3, 0xea, 0xa9, 0x01, 0x20, 0x52,
+	0xd5, 0xdd, 0xf2, 0x50, 0x70, 0xe9, 0x22, 0xe8, 0x09, 0x34, 0xe6, 0xc7, 0x74, 0x71, 0xd0, 0xcf,
+	0x1c, 0xfa, 0xb5, 0x6e, 0xe5, 0x50, 0xe3, 0x77, 0xb3, 0xfe, 0x88, 0x44, 0xe9, 0x9e, 0x1a, 0xcd,
+	0xf5, 0x9e, 0xf3, 0x3d, 0x7e, 0x6b, 0x27, 0x93, 0x26, 0xf7, 0xea, 0x02, 0x5a, 0x6e, 0x92, 0x63,
+	0x2f, 0xca, 0xed, 0x9f, 0x73, 0x8d, 0xd5, 0x07, 0x7d, 0x71, 0x8e, 0x86, 0x72, 0x32, 0x02, 0x5d,
+	0x0c, 0x14, 0x79, 0x03, 0xb8, 0xb3, 0x15, 0x4e, 0xff, 0xe0, 0xbf, 0x03, 0x00, 0xf4, 0xeb, 0xe7,
+	0xb6, 0x48, 0x22, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -475,6 +3090,21 @@ type NervoServiceClient interface {
 	ResetUsb(ctx context.Context, in *ResetUsbRequest, opts ...grpc.CallOption) (*ResetUsbResponse, error)
 	WriteToController(ctx context.Context, in *WriteToControllerRequest, opts ...grpc.CallOption) (*WriteToControllerResponse, error)
 	WriteToControllerContinuously(ctx context.Context, opts ...grpc.CallOption) (NervoService_WriteToControllerContinuouslyClient, error)
+	ExplainDiscovery(ctx context.Context, in *ExplainDiscoveryRequest, opts ...grpc.CallOption) (*ExplainDiscoveryResponse, error)
+	GetServerInfo(ctx context.Context, in *GetServerInfoRequest, opts ...grpc.CallOption) (*GetServerInfoResponse, error)
+	Session(ctx context.Context, opts ...grpc.CallOption) (NervoService_SessionClient, error)
+	SetControllerLabels(ctx context.Context, in *SetControllerLabelsRequest, opts ...grpc.CallOption) (*ControllerListResponse, error)
+	ReadMultiplexedOutput(ctx context.Context, in *ReadMultiplexedOutputRequest, opts ...grpc.CallOption) (NervoService_ReadMultiplexedOutputClient, error)
+	WriteToControllers(ctx context.Context, opts ...grpc.CallOption) (NervoService_WriteToControllersClient, error)
+	UploadFirmware(ctx context.Context, opts ...grpc.CallOption) (NervoService_UploadFirmwareClient, error)
+	FlashFirmware(ctx context.Context, in *FlashFirmwareRequest, opts ...grpc.CallOption) (NervoService_FlashFirmwareClient, error)
+	UploadArtifact(ctx context.Context, opts ...grpc.CallOption) (NervoService_UploadArtifactClient, error)
+	ListArtifacts(ctx context.Context, in *ListArtifactsRequest, opts ...grpc.CallOption) (*ListArtifactsResponse, error)
+	TagArtifact(ctx context.Context, in *TagArtifactRequest, opts ...grpc.CallOption) (*Artifact, error)
+	DeleteArtifact(ctx context.Context, in *DeleteArtifactRequest, opts ...grpc.CallOption) (*DeleteArtifactResponse, error)
+	GetFlashHistory(ctx context.Context, in *FlashHistoryRequest, opts ...grpc.CallOption) (*FlashHistoryResponse, error)
+	RollbackController(ctx context.Context, in *RollbackControllerRequest, opts ...grpc.CallOption) (NervoService_RollbackControllerClient, error)
+	FlashControllers(ctx context.Context, in *FlashControllersRequest, opts ...grpc.CallOption) (*FlashControllersResponse, error)
 }
 
 type nervoServiceClient struct {
@@ -605,6 +3235,304 @@ func (x *nervoServiceWriteToControllerContinuouslyClient) CloseAndRecv() (*Write
 	return m, nil
 }
 
+func (c *nervoServiceClient) ExplainDiscovery(ctx context.Context, in *ExplainDiscoveryRequest, opts ...grpc.CallOption) (*ExplainDiscoveryResponse, error) {
+	out := new(ExplainDiscoveryResponse)
+	err := c.cc.Invoke(ctx, "/proto.NervoService/ExplainDiscovery", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nervoServiceClient) GetServerInfo(ctx context.Context, in *GetServerInfoRequest, opts ...grpc.CallOption) (*GetServerInfoResponse, error) {
+	out := new(GetServerInfoResponse)
+	err := c.cc.Invoke(ctx, "/proto.NervoService/GetServerInfo", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nervoServiceClient) Session(ctx context.Context, opts ...grpc.CallOption) (NervoService_SessionClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_NervoService_serviceDesc.Streams[2], "/proto.NervoService/Session", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &nervoServiceSessionClient{stream}
+	return x, nil
+}
+
+type NervoService_SessionClient interface {
+	Send(*SessionRequest) error
+	Recv() (*SessionEvent, error)
+	grpc.ClientStream
+}
+
+type nervoServiceSessionClient struct {
+	grpc.ClientStream
+}
+
+func (x *nervoServiceSessionClient) Send(m *SessionRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *nervoServiceSessionClient) Recv() (*SessionEvent, error) {
+	m := new(SessionEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *nervoServiceClient) SetControllerLabels(ctx context.Context, in *SetControllerLabelsRequest, opts ...grpc.CallOption) (*ControllerListResponse, error) {
+	out := new(ControllerListResponse)
+	err := c.cc.Invoke(ctx, "/proto.NervoService/SetControllerLabels", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nervoServiceClient) ReadMultiplexedOutput(ctx context.Context, in *ReadMultiplexedOutputRequest, opts ...grpc.CallOption) (NervoService_ReadMultiplexedOutputClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_NervoService_serviceDesc.Streams[3], "/proto.NervoService/ReadMultiplexedOutput", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &nervoServiceReadMultiplexedOutputClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type NervoService_ReadMultiplexedOutputClient interface {
+	Recv() (*MultiplexedOutputLine, error)
+	grpc.ClientStream
+}
+
+type nervoServiceReadMultiplexedOutputClient struct {
+	grpc.ClientStream
+}
+
+func (x *nervoServiceReadMultiplexedOutputClient) Recv() (*MultiplexedOutputLine, error) {
+	m := new(MultiplexedOutputLine)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *nervoServiceClient) WriteToControllers(ctx context.Context, opts ...grpc.CallOption) (NervoService_WriteToControllersClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_NervoService_serviceDesc.Streams[4], "/proto.NervoService/WriteToControllers", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &nervoServiceWriteToControllersClient{stream}
+	return x, nil
+}
+
+type NervoService_WriteToControllersClient interface {
+	Send(*WriteToControllersRequest) error
+	Recv() (*WriteToControllersAck, error)
+	grpc.ClientStream
+}
+
+type nervoServiceWriteToControllersClient struct {
+	grpc.ClientStream
+}
+
+func (x *nervoServiceWriteToControllersClient) Send(m *WriteToControllersRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *nervoServiceWriteToControllersClient) Recv() (*WriteToControllersAck, error) {
+	m := new(WriteToControllersAck)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *nervoServiceClient) UploadFirmware(ctx context.Context, opts ...grpc.CallOption) (NervoService_UploadFirmwareClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_NervoService_serviceDesc.Streams[5], "/proto.NervoService/UploadFirmware", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &nervoServiceUploadFirmwareClient{stream}
+	return x, nil
+}
+
+type NervoService_UploadFirmwareClient interface {
+	Send(*FirmwareChunk) error
+	CloseAndRecv() (*UploadFirmwareResponse, error)
+	grpc.ClientStream
+}
+
+type nervoServiceUploadFirmwareClient struct {
+	grpc.ClientStream
+}
+
+func (x *nervoServiceUploadFirmwareClient) Send(m *FirmwareChunk) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *nervoServiceUploadFirmwareClient) CloseAndRecv() (*UploadFirmwareResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(UploadFirmwareResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *nervoServiceClient) FlashFirmware(ctx context.Context, in *FlashFirmwareRequest, opts ...grpc.CallOption) (NervoService_FlashFirmwareClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_NervoService_serviceDesc.Streams[6], "/proto.NervoService/FlashFirmware", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &nervoServiceFlashFirmwareClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type NervoService_FlashFirmwareClient interface {
+	Recv() (*FlashProgress, error)
+	grpc.ClientStream
+}
+
+type nervoServiceFlashFirmwareClient struct {
+	grpc.ClientStream
+}
+
+func (x *nervoServiceFlashFirmwareClient) Recv() (*FlashProgress, error) {
+	m := new(FlashProgress)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *nervoServiceClient) UploadArtifact(ctx context.Context, opts ...grpc.CallOption) (NervoService_UploadArtifactClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_NervoService_serviceDesc.Streams[7], "/proto.NervoService/UploadArtifact", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &nervoServiceUploadArtifactClient{stream}
+	return x, nil
+}
+
+type NervoService_UploadArtifactClient interface {
+	Send(*ArtifactChunk) error
+	CloseAndRecv() (*Artifact, error)
+	grpc.ClientStream
+}
+
+type nervoServiceUploadArtifactClient struct {
+	grpc.ClientStream
+}
+
+func (x *nervoServiceUploadArtifactClient) Send(m *ArtifactChunk) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *nervoServiceUploadArtifactClient) CloseAndRecv() (*Artifact, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(Artifact)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *nervoServiceClient) ListArtifacts(ctx context.Context, in *ListArtifactsRequest, opts ...grpc.CallOption) (*ListArtifactsResponse, error) {
+	out := new(ListArtifactsResponse)
+	err := c.cc.Invoke(ctx, "/proto.NervoService/ListArtifacts", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nervoServiceClient) TagArtifact(ctx context.Context, in *TagArtifactRequest, opts ...grpc.CallOption) (*Artifact, error) {
+	out := new(Artifact)
+	err := c.cc.Invoke(ctx, "/proto.NervoService/TagArtifact", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nervoServiceClient) DeleteArtifact(ctx context.Context, in *DeleteArtifactRequest, opts ...grpc.CallOption) (*DeleteArtifactResponse, error) {
+	out := new(DeleteArtifactResponse)
+	err := c.cc.Invoke(ctx, "/proto.NervoService/DeleteArtifact", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nervoServiceClient) GetFlashHistory(ctx context.Context, in *FlashHistoryRequest, opts ...grpc.CallOption) (*FlashHistoryResponse, error) {
+	out := new(FlashHistoryResponse)
+	err := c.cc.Invoke(ctx, "/proto.NervoService/GetFlashHistory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nervoServiceClient) RollbackController(ctx context.Context, in *RollbackControllerRequest, opts ...grpc.CallOption) (NervoService_RollbackControllerClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_NervoService_serviceDesc.Streams[8], "/proto.NervoService/RollbackController", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &nervoServiceRollbackControllerClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type NervoService_RollbackControllerClient interface {
+	Recv() (*FlashProgress, error)
+	grpc.ClientStream
+}
+
+type nervoServiceRollbackControllerClient struct {
+	grpc.ClientStream
+}
+
+func (x *nervoServiceRollbackControllerClient) Recv() (*FlashProgress, error) {
+	m := new(FlashProgress)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *nervoServiceClient) FlashControllers(ctx context.Context, in *FlashControllersRequest, opts ...grpc.CallOption) (*FlashControllersResponse, error) {
+	out := new(FlashControllersResponse)
+	err := c.cc.Invoke(ctx, "/proto.NervoService/FlashControllers", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // NervoServiceServer is the server API for NervoService service.
 type NervoServiceServer interface {
 	ListControllers(context.Context, *ControllerListRequest) (*ControllerListResponse, error)
@@ -615,6 +3543,95 @@ type NervoServiceServer interface {
 	ResetUsb(context.Context, *ResetUsbRequest) (*ResetUsbResponse, error)
 	WriteToController(context.Context, *WriteToControllerRequest) (*WriteToControllerResponse, error)
 	WriteToControllerContinuously(NervoService_WriteToControllerContinuouslyServer) error
+	ExplainDiscovery(context.Context, *ExplainDiscoveryRequest) (*ExplainDiscoveryResponse, error)
+	GetServerInfo(context.Context, *GetServerInfoRequest) (*GetServerInfoResponse, error)
+	Session(NervoService_SessionServer) error
+	SetControllerLabels(context.Context, *SetControllerLabelsRequest) (*ControllerListResponse, error)
+	ReadMultiplexedOutput(*ReadMultiplexedOutputRequest, NervoService_ReadMultiplexedOutputServer) error
+	WriteToControllers(NervoService_WriteToControllersServer) error
+	UploadFirmware(NervoService_UploadFirmwareServer) error
+	FlashFirmware(*FlashFirmwareRequest, NervoService_FlashFirmwareServer) error
+	UploadArtifact(NervoService_UploadArtifactServer) error
+	ListArtifacts(context.Context, *ListArtifactsRequest) (*ListArtifactsResponse, error)
+	TagArtifact(context.Context, *TagArtifactRequest) (*Artifact, error)
+	DeleteArtifact(context.Context, *DeleteArtifactRequest) (*DeleteArtifactResponse, error)
+	GetFlashHistory(context.Context, *FlashHistoryRequest) (*FlashHistoryResponse, error)
+	RollbackController(*RollbackControllerRequest, NervoService_RollbackControllerServer) error
+	FlashControllers(context.Context, *FlashControllersRequest) (*FlashControllersResponse, error)
+}
+
+// UnimplementedNervoServiceServer can be embedded to have forward compatible implementations.
+type UnimplementedNervoServiceServer struct {
+}
+
+func (*UnimplementedNervoServiceServer) ListControllers(ctx context.Context, req *ControllerListRequest) (*ControllerListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListControllers not implemented")
+}
+func (*UnimplementedNervoServiceServer) ReadControllerOutput(ctx context.Context, req *ReadControllerOutputRequest) (*ReadControllerOutputResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReadControllerOutput not implemented")
+}
+func (*UnimplementedNervoServiceServer) FlashController(ctx context.Context, req *FlashControllerRequest) (*FlashControllerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FlashController not implemented")
+}
+func (*UnimplementedNervoServiceServer) ReadControllerOutputContinuously(req *ReadControllerOutputRequest, srv NervoService_ReadControllerOutputContinuouslyServer) error {
+	return status.Errorf(codes.Unimplemented, "method ReadControllerOutputContinuously not implemented")
+}
+func (*UnimplementedNervoServiceServer) SetControllerName(ctx context.Context, req *ControllerInfo) (*ControllerListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetControllerName not implemented")
+}
+func (*UnimplementedNervoServiceServer) ResetUsb(ctx context.Context, req *ResetUsbRequest) (*ResetUsbResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResetUsb not implemented")
+}
+func (*UnimplementedNervoServiceServer) WriteToController(ctx context.Context, req *WriteToControllerRequest) (*WriteToControllerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method WriteToController not implemented")
+}
+func (*UnimplementedNervoServiceServer) WriteToControllerContinuously(srv NervoService_WriteToControllerContinuouslyServer) error {
+	return status.Errorf(codes.Unimplemented, "method WriteToControllerContinuously not implemented")
+}
+func (*UnimplementedNervoServiceServer) ExplainDiscovery(ctx context.Context, req *ExplainDiscoveryRequest) (*ExplainDiscoveryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExplainDiscovery not implemented")
+}
+func (*UnimplementedNervoServiceServer) GetServerInfo(ctx context.Context, req *GetServerInfoRequest) (*GetServerInfoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetServerInfo not implemented")
+}
+func (*UnimplementedNervoServiceServer) Session(srv NervoService_SessionServer) error {
+	return status.Errorf(codes.Unimplemented, "method Session not implemented")
+}
+func (*UnimplementedNervoServiceServer) SetControllerLabels(ctx context.Context, req *SetControllerLabelsRequest) (*ControllerListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetControllerLabels not implemented")
+}
+func (*UnimplementedNervoServiceServer) ReadMultiplexedOutput(req *ReadMultiplexedOutputRequest, srv NervoService_ReadMultiplexedOutputServer) error {
+	return status.Errorf(codes.Unimplemented, "method ReadMultiplexedOutput not implemented")
+}
+func (*UnimplementedNervoServiceServer) WriteToControllers(srv NervoService_WriteToControllersServer) error {
+	return status.Errorf(codes.Unimplemented, "method WriteToControllers not implemented")
+}
+func (*UnimplementedNervoServiceServer) UploadFirmware(srv NervoService_UploadFirmwareServer) error {
+	return status.Errorf(codes.Unimplemented, "method UploadFirmware not implemented")
+}
+func (*UnimplementedNervoServiceServer) FlashFirmware(req *FlashFirmwareRequest, srv NervoService_FlashFirmwareServer) error {
+	return status.Errorf(codes.Unimplemented, "method FlashFirmware not implemented")
+}
+func (*UnimplementedNervoServiceServer) UploadArtifact(srv NervoService_UploadArtifactServer) error {
+	return status.Errorf(codes.Unimplemented, "method UploadArtifact not implemented")
+}
+func (*UnimplementedNervoServiceServer) ListArtifacts(ctx context.Context, req *ListArtifactsRequest) (*ListArtifactsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListArtifacts not implemented")
+}
+func (*UnimplementedNervoServiceServer) TagArtifact(ctx context.Context, req *TagArtifactRequest) (*Artifact, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TagArtifact not implemented")
+}
+func (*UnimplementedNervoServiceServer) DeleteArtifact(ctx context.Context, req *DeleteArtifactRequest) (*DeleteArtifactResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteArtifact not implemented")
+}
+func (*UnimplementedNervoServiceServer) GetFlashHistory(ctx context.Context, req *FlashHistoryRequest) (*FlashHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFlashHistory not implemented")
+}
+func (*UnimplementedNervoServiceServer) RollbackController(req *RollbackControllerRequest, srv NervoService_RollbackControllerServer) error {
+	return status.Errorf(codes.Unimplemented, "method RollbackController not implemented")
+}
+func (*UnimplementedNervoServiceServer) FlashControllers(ctx context.Context, req *FlashControllersRequest) (*FlashControllersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FlashControllers not implemented")
 }
 
 func RegisterNervoServiceServer(s *grpc.Server, srv NervoServiceServer) {
@@ -776,6 +3793,317 @@ func (x *nervoServiceWriteToControllerContinuouslyServer) Recv() (*WriteToContro
 	return m, nil
 }
 
+func _NervoService_ExplainDiscovery_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExplainDiscoveryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NervoServiceServer).ExplainDiscovery(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.NervoService/ExplainDiscovery",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NervoServiceServer).ExplainDiscovery(ctx, req.(*ExplainDiscoveryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NervoService_GetServerInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetServerInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NervoServiceServer).GetServerInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.NervoService/GetServerInfo",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NervoServiceServer).GetServerInfo(ctx, req.(*GetServerInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NervoService_Session_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(NervoServiceServer).Session(&nervoServiceSessionServer{stream})
+}
+
+type NervoService_SessionServer interface {
+	Send(*SessionEvent) error
+	Recv() (*SessionRequest, error)
+	grpc.ServerStream
+}
+
+type nervoServiceSessionServer struct {
+	grpc.ServerStream
+}
+
+func (x *nervoServiceSessionServer) Send(m *SessionEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *nervoServiceSessionServer) Recv() (*SessionRequest, error) {
+	m := new(SessionRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _NervoService_SetControllerLabels_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetControllerLabelsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NervoServiceServer).SetControllerLabels(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.NervoService/SetControllerLabels",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NervoServiceServer).SetControllerLabels(ctx, req.(*SetControllerLabelsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NervoService_ReadMultiplexedOutput_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ReadMultiplexedOutputRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(NervoServiceServer).ReadMultiplexedOutput(m, &nervoServiceReadMultiplexedOutputServer{stream})
+}
+
+type NervoService_ReadMultiplexedOutputServer interface {
+	Send(*MultiplexedOutputLine) error
+	grpc.ServerStream
+}
+
+type nervoServiceReadMultiplexedOutputServer struct {
+	grpc.ServerStream
+}
+
+func (x *nervoServiceReadMultiplexedOutputServer) Send(m *MultiplexedOutputLine) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _NervoService_WriteToControllers_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(NervoServiceServer).WriteToControllers(&nervoServiceWriteToControllersServer{stream})
+}
+
+type NervoService_WriteToControllersServer interface {
+	Send(*WriteToControllersAck) error
+	Recv() (*WriteToControllersRequest, error)
+	grpc.ServerStream
+}
+
+type nervoServiceWriteToControllersServer struct {
+	grpc.ServerStream
+}
+
+func (x *nervoServiceWriteToControllersServer) Send(m *WriteToControllersAck) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *nervoServiceWriteToControllersServer) Recv() (*WriteToControllersRequest, error) {
+	m := new(WriteToControllersRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _NervoService_UploadFirmware_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(NervoServiceServer).UploadFirmware(&nervoServiceUploadFirmwareServer{stream})
+}
+
+type NervoService_UploadFirmwareServer interface {
+	SendAndClose(*UploadFirmwareResponse) error
+	Recv() (*FirmwareChunk, error)
+	grpc.ServerStream
+}
+
+type nervoServiceUploadFirmwareServer struct {
+	grpc.ServerStream
+}
+
+func (x *nervoServiceUploadFirmwareServer) SendAndClose(m *UploadFirmwareResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *nervoServiceUploadFirmwareServer) Recv() (*FirmwareChunk, error) {
+	m := new(FirmwareChunk)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _NervoService_FlashFirmware_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(FlashFirmwareRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(NervoServiceServer).FlashFirmware(m, &nervoServiceFlashFirmwareServer{stream})
+}
+
+type NervoService_FlashFirmwareServer interface {
+	Send(*FlashProgress) error
+	grpc.ServerStream
+}
+
+type nervoServiceFlashFirmwareServer struct {
+	grpc.ServerStream
+}
+
+func (x *nervoServiceFlashFirmwareServer) Send(m *FlashProgress) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _NervoService_UploadArtifact_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(NervoServiceServer).UploadArtifact(&nervoServiceUploadArtifactServer{stream})
+}
+
+type NervoService_UploadArtifactServer interface {
+	SendAndClose(*Artifact) error
+	Recv() (*ArtifactChunk, error)
+	grpc.ServerStream
+}
+
+type nervoServiceUploadArtifactServer struct {
+	grpc.ServerStream
+}
+
+func (x *nervoServiceUploadArtifactServer) SendAndClose(m *Artifact) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *nervoServiceUploadArtifactServer) Recv() (*ArtifactChunk, error) {
+	m := new(ArtifactChunk)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _NervoService_ListArtifacts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListArtifactsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NervoServiceServer).ListArtifacts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.NervoService/ListArtifacts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NervoServiceServer).ListArtifacts(ctx, req.(*ListArtifactsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NervoService_TagArtifact_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TagArtifactRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NervoServiceServer).TagArtifact(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.NervoService/TagArtifact",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NervoServiceServer).TagArtifact(ctx, req.(*TagArtifactRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NervoService_DeleteArtifact_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteArtifactRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NervoServiceServer).DeleteArtifact(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.NervoService/DeleteArtifact",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NervoServiceServer).DeleteArtifact(ctx, req.(*DeleteArtifactRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NervoService_GetFlashHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FlashHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NervoServiceServer).GetFlashHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.NervoService/GetFlashHistory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NervoServiceServer).GetFlashHistory(ctx, req.(*FlashHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NervoService_RollbackController_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RollbackControllerRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(NervoServiceServer).RollbackController(m, &nervoServiceRollbackControllerServer{stream})
+}
+
+type NervoService_RollbackControllerServer interface {
+	Send(*FlashProgress) error
+	grpc.ServerStream
+}
+
+type nervoServiceRollbackControllerServer struct {
+	grpc.ServerStream
+}
+
+func (x *nervoServiceRollbackControllerServer) Send(m *FlashProgress) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _NervoService_FlashControllers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FlashControllersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NervoServiceServer).FlashControllers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.NervoService/FlashControllers",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NervoServiceServer).FlashControllers(ctx, req.(*FlashControllersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _NervoService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "proto.NervoService",
 	HandlerType: (*NervoServiceServer)(nil),
@@ -804,6 +4132,38 @@ var _NervoService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "WriteToController",
 			Handler:    _NervoService_WriteToController_Handler,
 		},
+		{
+			MethodName: "ExplainDiscovery",
+			Handler:    _NervoService_ExplainDiscovery_Handler,
+		},
+		{
+			MethodName: "GetServerInfo",
+			Handler:    _NervoService_GetServerInfo_Handler,
+		},
+		{
+			MethodName: "SetControllerLabels",
+			Handler:    _NervoService_SetControllerLabels_Handler,
+		},
+		{
+			MethodName: "ListArtifacts",
+			Handler:    _NervoService_ListArtifacts_Handler,
+		},
+		{
+			MethodName: "TagArtifact",
+			Handler:    _NervoService_TagArtifact_Handler,
+		},
+		{
+			MethodName: "DeleteArtifact",
+			Handler:    _NervoService_DeleteArtifact_Handler,
+		},
+		{
+			MethodName: "GetFlashHistory",
+			Handler:    _NervoService_GetFlashHistory_Handler,
+		},
+		{
+			MethodName: "FlashControllers",
+			Handler:    _NervoService_FlashControllers_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -816,42 +4176,43 @@ var _NervoService_serviceDesc = grpc.ServiceDesc{
 			Handler:       _NervoService_WriteToControllerContinuously_Handler,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "Session",
+			Handler:       _NervoService_Session_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ReadMultiplexedOutput",
+			Handler:       _NervoService_ReadMultiplexedOutput_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WriteToControllers",
+			Handler:       _NervoService_WriteToControllers_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "UploadFirmware",
+			Handler:       _NervoService_UploadFirmware_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "FlashFirmware",
+			Handler:       _NervoService_FlashFirmware_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "UploadArtifact",
+			Handler:       _NervoService_UploadArtifact_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "RollbackController",
+			Handler:       _NervoService_RollbackController_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "proto/protocol.proto",
 }
-
-func init() { proto.RegisterFile("proto/protocol.proto", fileDescriptor_protocol_3860ec6f7903b783) }
-
-var fileDescriptor_protocol_3860ec6f7903b783 = []byte{
-	// 466 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x53, 0x4d, 0x4f, 0xdb, 0x40,
-	0x10, 0x95, 0xdb, 0x42, 0xe9, 0x14, 0xe1, 0x64, 0x15, 0x1c, 0xd7, 0x40, 0x6b, 0x6d, 0x2f, 0x56,
-	0x0f, 0x94, 0x52, 0xa9, 0x97, 0x5e, 0x90, 0x90, 0x90, 0x90, 0xaa, 0x80, 0x4c, 0x3f, 0xa4, 0x5e,
-	0x2c, 0x63, 0xc6, 0x8d, 0x25, 0x67, 0xd7, 0xf5, 0xae, 0x11, 0xfd, 0x83, 0xfd, 0x5d, 0x95, 0x37,
-	0xeb, 0x38, 0xc6, 0x36, 0x91, 0x4a, 0x2e, 0xc9, 0xce, 0xbc, 0xd9, 0x79, 0x6f, 0x66, 0x9f, 0x61,
-	0x94, 0xe5, 0x5c, 0xf2, 0xf7, 0xea, 0x37, 0xe2, 0xe9, 0xa1, 0x3a, 0x90, 0x0d, 0xf5, 0x47, 0x4f,
-	0x60, 0xe7, 0x94, 0x33, 0x99, 0xf3, 0x34, 0xc5, 0xfc, 0x9c, 0xc5, 0x9c, 0x38, 0xb0, 0x95, 0xf1,
-	0x5c, 0x4e, 0xc2, 0x19, 0xda, 0x86, 0x6b, 0x78, 0x2f, 0xfc, 0x45, 0x4c, 0x08, 0x3c, 0x63, 0x65,
-	0xfe, 0x89, 0xca, 0xab, 0x33, 0x1d, 0xc3, 0x6e, 0xdd, 0xe1, 0x4b, 0x22, 0xa4, 0x8f, 0xbf, 0x0b,
-	0x14, 0x92, 0xfe, 0x04, 0xeb, 0x3e, 0x20, 0x32, 0xce, 0x04, 0x92, 0x13, 0x18, 0x44, 0x0b, 0x24,
-	0x48, 0x58, 0xcc, 0x85, 0x6d, 0xb8, 0x4f, 0xbd, 0x97, 0xc7, 0xbb, 0x73, 0x75, 0x87, 0x4d, 0x4d,
-	0xbe, 0x19, 0x35, 0x62, 0x41, 0x2f, 0x60, 0xcf, 0xc7, 0xf0, 0xa6, 0x2e, 0xbb, 0x28, 0x64, 0x56,
-	0x54, 0xd4, 0xe4, 0x08, 0x46, 0x4b, 0x04, 0xa5, 0xfc, 0x80, 0xd5, 0xf3, 0x90, 0x1a, 0xbb, 0xd4,
-	0x93, 0xd1, 0x4f, 0xb0, 0xdf, 0xdd, 0x50, 0x4b, 0xb6, 0x60, 0x93, 0xab, 0x8c, 0xee, 0xa1, 0x23,
-	0x2a, 0xc1, 0x3a, 0x4b, 0x43, 0x31, 0xad, 0x2f, 0xfe, 0xb7, 0x06, 0xe2, 0xc1, 0x60, 0x8a, 0x77,
-	0x41, 0x9c, 0xa4, 0x18, 0x94, 0x30, 0x32, 0xa9, 0x36, 0xbd, 0xed, 0xef, 0x4c, 0xf1, 0xee, 0x2c,
-	0x49, 0xf1, 0x74, 0x9e, 0xa5, 0x1f, 0x60, 0xdc, 0x62, 0x5d, 0x21, 0x74, 0x08, 0xa6, 0x8f, 0x02,
-	0xe5, 0x37, 0x71, 0x5d, 0x3d, 0xd0, 0x3b, 0x18, 0xd4, 0xa9, 0x15, 0xd7, 0x63, 0xb0, 0x7f, 0xe4,
-	0x89, 0xc4, 0xaf, 0x7c, 0x1d, 0x93, 0xda, 0xf0, 0x7c, 0x86, 0x42, 0x84, 0xbf, 0x50, 0x0f, 0x58,
-	0x85, 0x74, 0x0f, 0x5e, 0x75, 0xf0, 0xcc, 0xc5, 0x1d, 0xff, 0xdd, 0x80, 0xed, 0x09, 0xe6, 0xb7,
-	0xfc, 0x0a, 0xf3, 0xdb, 0x24, 0x42, 0x32, 0x01, 0xb3, 0x34, 0x56, 0x5d, 0x2a, 0xc8, 0x7e, 0xcb,
-	0x41, 0x4b, 0x9e, 0x74, 0x0e, 0x7a, 0x50, 0x3d, 0x7d, 0x00, 0xa3, 0x2e, 0x17, 0x10, 0xaa, 0xaf,
-	0x3d, 0xe0, 0x39, 0xe7, 0xed, 0x83, 0x35, 0x9a, 0xe0, 0x12, 0xcc, 0x7b, 0x0f, 0x47, 0x2a, 0x49,
-	0xdd, 0x36, 0x72, 0x5e, 0xf7, 0xc1, 0xba, 0xe3, 0x0c, 0xdc, 0x2e, 0xc6, 0x32, 0x4e, 0x58, 0xc1,
-	0x0b, 0x91, 0xfe, 0x59, 0x9b, 0xfc, 0x23, 0x83, 0x9c, 0xc3, 0xf0, 0x0a, 0x97, 0x16, 0xae, 0x9e,
-	0xb3, 0xfb, 0xab, 0x5d, 0xb5, 0xec, 0xcf, 0xb0, 0x55, 0xd9, 0x8f, 0x58, 0x0b, 0xf6, 0x86, 0x45,
-	0x9d, 0x71, 0x2b, 0xaf, 0x2f, 0x7f, 0x87, 0x61, 0xcb, 0x27, 0xe4, 0x8d, 0xae, 0xee, 0x73, 0xaa,
-	0xe3, 0xf6, 0x17, 0xe8, 0xbe, 0x37, 0x70, 0xd0, 0x02, 0x1b, 0xbb, 0x7c, 0x3c, 0x87, 0x67, 0x5c,
-	0x6f, 0xaa, 0x92, 0x8f, 0xff, 0x02, 0x00, 0x00, 0xff, 0xff, 0xfd, 0xb1, 0xfd, 0x3d, 0x9b, 0x05,
-	0x00, 0x00,
-}
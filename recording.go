@@ -0,0 +1,226 @@
+package nervo
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Directions of a SessionEvent
+const (
+	SessionEventRead  = "read"
+	SessionEventWrite = "write"
+)
+
+// SessionEvent is a single line of a recorded session file
+type SessionEvent struct {
+	Time      time.Time `json:"time"`
+	Direction string    `json:"direction"`
+	Data      string    `json:"data"`
+}
+
+// ErrReplayFinished is read from a replay that played all of its events and doesn't loop
+var ErrReplayFinished = errors.New("the replay finished")
+
+var unsafeFileNameChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// sessionRecorder appends everything read from and written to a controller to a json lines file
+type sessionRecorder struct {
+	file    *os.File
+	encoder *json.Encoder
+	mutex   *sync.Mutex
+}
+
+func newSessionRecorder(directory, portName string) (*sessionRecorder, error) {
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return nil, err
+	}
+
+	fileName := fmt.Sprintf(
+		"%s_%s.jsonl",
+		unsafeFileNameChars.ReplaceAllString(portName, "_"),
+		time.Now().Format("20060102T150405.000"),
+	)
+	file, err := os.Create(path.Join(directory, fileName))
+	if err != nil {
+		return nil, err
+	}
+
+	return &sessionRecorder{
+		file:    file,
+		encoder: json.NewEncoder(file),
+		mutex:   &sync.Mutex{},
+	}, nil
+}
+
+func (r *sessionRecorder) record(direction string, data []byte) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	err := r.encoder.Encode(SessionEvent{Time: time.Now(), Direction: direction, Data: string(data)})
+	if err != nil {
+		log.Println("recording session to", r.file.Name(), "failed:", err)
+	}
+}
+
+func (r *sessionRecorder) close() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.file.Close()
+}
+
+// replayTransport plays the read events of a recorded session back as if they came from a controller.
+// Writes are accepted and dropped
+type replayTransport struct {
+	sessionPath string
+	events      []SessionEvent
+	speed       float64
+	loop        bool
+	next        int
+	pending     []byte
+	lastEventAt time.Time
+	closed      chan struct{}
+	closeOnce   *sync.Once
+}
+
+// openReplayTransport opens "replay://<session file>?speed=<factor>&loop=<bool>". The session file is taken as it is, relative or absolute.
+// A speed of 0 replays as fast as possible, the default of 1 in real time
+func openReplayTransport(portName string) (*replayTransport, error) {
+	sessionPath, rawQuery := strings.TrimPrefix(portName, replayPortPrefix), ""
+	if i := strings.LastIndex(sessionPath, "?"); i >= 0 {
+		sessionPath, rawQuery = sessionPath[:i], sessionPath[i+1:]
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	speed := 1.0
+	if s := query.Get("speed"); s != "" {
+		speed, err = strconv.ParseFloat(s, 64)
+		if err != nil || speed < 0 {
+			return nil, fmt.Errorf("invalid replay speed %q", s)
+		}
+	}
+
+	events, err := readSessionEvents(sessionPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &replayTransport{
+		sessionPath: sessionPath,
+		events:      events,
+		speed:       speed,
+		loop:        query.Get("loop") == "true",
+		closed:      make(chan struct{}),
+		closeOnce:   &sync.Once{},
+	}, nil
+}
+
+func (t *replayTransport) Read(p []byte) (int, error) {
+	for len(t.pending) == 0 {
+		event, err := t.nextReadEvent()
+		if err != nil {
+			return 0, err
+		}
+
+		if !t.lastEventAt.IsZero() && t.speed > 0 {
+			wait := time.Duration(float64(event.Time.Sub(t.lastEventAt)) / t.speed)
+			select {
+			case <-time.After(wait):
+			case <-t.closed:
+				return 0, io.EOF
+			}
+		}
+		t.lastEventAt = event.Time
+		t.pending = []byte(event.Data)
+	}
+
+	n := copy(p, t.pending)
+	t.pending = t.pending[n:]
+	return n, nil
+}
+
+func (t *replayTransport) nextReadEvent() (SessionEvent, error) {
+	for {
+		select {
+		case <-t.closed:
+			return SessionEvent{}, io.EOF
+		default:
+		}
+
+		if t.next >= len(t.events) {
+			if !t.loop {
+				return SessionEvent{}, ErrReplayFinished
+			}
+			t.next = 0
+			t.lastEventAt = time.Time{}
+		}
+
+		event := t.events[t.next]
+		t.next++
+		if event.Direction == SessionEventRead {
+			return event, nil
+		}
+	}
+}
+
+func (t *replayTransport) Write(p []byte) (int, error) {
+	select {
+	case <-t.closed:
+		return 0, io.ErrClosedPipe
+	default:
+		return len(p), nil
+	}
+}
+
+func (t *replayTransport) Close() error {
+	t.closeOnce.Do(func() {
+		close(t.closed)
+	})
+	return nil
+}
+
+func (t *replayTransport) SetControlLines(dtr, rts bool) error {
+	return &UnsupportedError{PortName: replayPortPrefix + t.sessionPath, Reason: "a replayed session has no control lines"}
+}
+
+func readSessionEvents(sessionPath string) ([]SessionEvent, error) {
+	file, err := os.Open(sessionPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	events := []SessionEvent{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxBufferLength)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		event := SessionEvent{}
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", sessionPath, lineNumber, err)
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(events) == 0 {
+		return nil, errors.New(sessionPath + " contains no events")
+	}
+	return events, nil
+}
@@ -0,0 +1,89 @@
+package nervo
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RecordAndReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nervo_sessions")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	recorder, err := newSessionRecorder(dir, "/dev/ttyACM0")
+	require.NoError(t, err)
+	recorder.record(SessionEventRead, []byte("announce leg1\n"))
+	recorder.record(SessionEventWrite, []byte("step 1\n"))
+	recorder.record(SessionEventRead, []byte("feedback done\n"))
+	recorder.close()
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	sessionPath := path.Join(dir, files[0].Name())
+
+	t.Run("replays the read lines", func(t *testing.T) {
+		transport, err := OpenTransport("replay://" + sessionPath + "?speed=0")
+		require.NoError(t, err)
+		defer transport.Close()
+
+		_, err = transport.Write([]byte("ignored\n"))
+		assert.NoError(t, err)
+
+		r := bufio.NewReader(transport)
+		for _, expected := range []string{"announce leg1\n", "feedback done\n"} {
+			line, err := r.ReadString('\n')
+			require.NoError(t, err)
+			assert.Equal(t, expected, line)
+		}
+		_, err = r.ReadString('\n')
+		assert.Equal(t, ErrReplayFinished, err)
+	})
+
+	t.Run("takes relative paths as they are", func(t *testing.T) {
+		wd, err := os.Getwd()
+		require.NoError(t, err)
+		require.NoError(t, os.Chdir(dir))
+		defer os.Chdir(wd)
+
+		transport, err := OpenTransport("replay://" + files[0].Name() + "?speed=0")
+		require.NoError(t, err)
+		transport.Close()
+	})
+
+	t.Run("a finished replay is not reconnected", func(t *testing.T) {
+		c := newController("replay://"+sessionPath+"?speed=0", defaultByIDDirectory, OpenTransport, newOutputObservers(1))
+		c.startReading()
+		require.Eventually(t, func() bool {
+			return c.state() == controllerStateFinished
+		}, testWaitTimeout, testTick)
+		assert.Nil(t, c.disconnectedBy())
+		assert.Equal(t, "leg1", c.name())
+	})
+
+	t.Run("loops if asked to", func(t *testing.T) {
+		transport, err := OpenTransport("replay://" + sessionPath + "?speed=0&loop=true")
+		require.NoError(t, err)
+		defer transport.Close()
+
+		r := bufio.NewReader(transport)
+		lines := []string{}
+		for i := 0; i < 4; i++ {
+			line, err := r.ReadString('\n')
+			require.NoError(t, err)
+			lines = append(lines, line)
+		}
+		assert.Equal(t, []string{"announce leg1\n", "feedback done\n", "announce leg1\n", "feedback done\n"}, lines)
+	})
+
+	t.Run("rejects invalid speeds", func(t *testing.T) {
+		_, err := OpenTransport("replay://" + sessionPath + "?speed=fast")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,89 @@
+package nervo
+
+import (
+	"errors"
+
+	"github.com/codeuniversity/nervo/proto"
+)
+
+// controllerSelector picks controllers by port name, name or labels
+type controllerSelector struct {
+	all       bool
+	portNames []string
+	names     []string
+	// labels select controllers that have all of them
+	labels map[string]string
+}
+
+func selectorFromProto(selector *proto.ControllerSelector) (controllerSelector, error) {
+	if selector == nil || (!selector.All && len(selector.PortNames) == 0 && len(selector.Names) == 0 && len(selector.Labels) == 0) {
+		return controllerSelector{}, errors.New("the selector matches no controller, set all, port names, names or labels")
+	}
+
+	return controllerSelector{
+		all:       selector.All,
+		portNames: selector.PortNames,
+		names:     selector.Names,
+		labels:    selector.Labels,
+	}, nil
+}
+
+// matches if any of port names, names or labels match
+func (s controllerSelector) matches(portName, name string, labels map[string]string) bool {
+	if s.all {
+		return true
+	}
+	for _, selected := range s.portNames {
+		if selected == portName {
+			return true
+		}
+	}
+	for _, selected := range s.names {
+		if name != "" && selected == name {
+			return true
+		}
+	}
+
+	if len(s.labels) == 0 {
+		return false
+	}
+	for key, value := range s.labels {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// controllerTarget names exactly one controller, by port name, stable id or name. The first one set is used
+type controllerTarget struct {
+	portName string
+	stableID string
+	name     string
+}
+
+func (t controllerTarget) empty() bool {
+	return t.portName == "" && t.stableID == "" && t.name == ""
+}
+
+func (t controllerTarget) matches(info controllerInfo) bool {
+	switch {
+	case t.portName != "":
+		return t.portName == info.portName
+	case t.stableID != "":
+		return t.stableID == info.stableID
+	default:
+		return t.name != "" && t.name == info.name
+	}
+}
+
+func (t controllerTarget) String() string {
+	switch {
+	case t.portName != "":
+		return t.portName
+	case t.stableID != "":
+		return t.stableID
+	default:
+		return t.name
+	}
+}
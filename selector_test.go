@@ -0,0 +1,41 @@
+package nervo
+
+import (
+	"testing"
+
+	"github.com/codeuniversity/nervo/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_controllerSelector_matches(t *testing.T) {
+	labels := map[string]string{"side": "left", "position": "front"}
+
+	tests := []struct {
+		testMessage string
+		selector    *proto.ControllerSelector
+		matches     bool
+	}{
+		{"all", &proto.ControllerSelector{All: true}, true},
+		{"port name", &proto.ControllerSelector{PortNames: []string{"/dev/ttyACM1", "/dev/ttyACM0"}}, true},
+		{"other port name", &proto.ControllerSelector{PortNames: []string{"/dev/ttyACM1"}}, false},
+		{"name", &proto.ControllerSelector{Names: []string{"leg1"}}, true},
+		{"other name", &proto.ControllerSelector{Names: []string{"leg2"}}, false},
+		{"all labels", &proto.ControllerSelector{Labels: map[string]string{"side": "left", "position": "front"}}, true},
+		{"some labels", &proto.ControllerSelector{Labels: map[string]string{"side": "left", "position": "back"}}, false},
+		{"name or labels", &proto.ControllerSelector{Names: []string{"leg2"}, Labels: map[string]string{"side": "left"}}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testMessage, func(t *testing.T) {
+			selector, err := selectorFromProto(test.selector)
+			require.NoError(t, err)
+			assert.Equal(t, test.matches, selector.matches("/dev/ttyACM0", "leg1", labels))
+		})
+	}
+
+	t.Run("an empty selector is rejected", func(t *testing.T) {
+		_, err := selectorFromProto(&proto.ControllerSelector{})
+		assert.Error(t, err)
+	})
+}
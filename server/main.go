@@ -2,8 +2,11 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"log"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/alexmorten/mhist/proto"
 	"github.com/codeuniversity/nervo"
@@ -13,13 +16,118 @@ func main() {
 	var mhistAddress string
 	var mhistNamesFilter string
 	var grpcPort int
+	var httpPort int
+	var discovery string
+	var discoveryConfigPath string
+	var simulatedControllers int
+	var simulatorConfigPath string
+	var recordDirectory string
+	var replaySessions string
+	var tlsCertFile, tlsKeyFile, tlsClientCAFile string
+	var tokensFile string
+	var artifactDirectory string
+	var flashHistoryDirectory string
+	var httpAllowedOrigins string
 	flag.StringVar(&mhistAddress, "mhist_address", "", "the address to mhist. If not given will not subscribe to mhist")
 	flag.StringVar(&mhistNamesFilter, "mhist_names_filter", "", "comma seperated string what channels nervo should subscribe to. Necessary of an address is given")
 	flag.IntVar(&grpcPort, "grpc_port", 4000, "the port the grpc server should listen on")
+	flag.IntVar(&httpPort, "http_port", 0, "the port the http/json gateway should listen on. If not given, only grpc is served")
+	flag.StringVar(&httpAllowedOrigins, "http_allowed_origins", "", "comma seperated origins browsers may open the websocket from, e.g. 'https://dashboard.example.com'. If not given, only the gateway's own pages may")
+	flag.StringVar(&discovery, "discovery", "uevent", "how to discover attached controllers, either 'uevent' (falls back to polling if unavailable) or 'poll'")
+	flag.StringVar(&discoveryConfigPath, "discovery_config", "", "path to a json file configuring which ports are discovered. If not given, the defaults are used")
+	flag.IntVar(&simulatedControllers, "simulate", 0, "how many fake controllers to simulate, for development without hardware")
+	flag.StringVar(&simulatorConfigPath, "simulator_config", "", "path to a json file scripting fake controllers. Implies simulating")
+	flag.StringVar(&recordDirectory, "record_dir", "", "directory to record every session with a controller into. If not given, nothing is recorded")
+	flag.StringVar(&replaySessions, "replay", "", "comma seperated session files to replay as if they were attached controllers, e.g. 'leg1.jsonl?speed=10&loop=true'")
+	flag.StringVar(&tlsCertFile, "tls_cert", "", "certificate file for serving grpc over tls. If not given, the server is unencrypted")
+	flag.StringVar(&tlsKeyFile, "tls_key", "", "private key file belonging to tls_cert")
+	flag.StringVar(&tlsClientCAFile, "tls_client_ca", "", "CA file to verify client certificates with. If given, clients without a valid certificate are rejected")
+	flag.StringVar(&tokensFile, "tokens_file", "", "json file with api tokens and their roles. If given, every request needs a token")
+	flag.StringVar(&artifactDirectory, "artifact_dir", "", "directory to keep uploaded firmware artifacts in. If not given, artifacts are lost when the server stops")
+	flag.StringVar(&flashHistoryDirectory, "flash_history_dir", "", "directory to keep the flash history and the flashed images in. If not given, the history is lost when the server stops")
 	flag.Parse()
 
-	m := nervo.NewManager()
+	if discovery != "uevent" && discovery != "poll" {
+		fmt.Fprintf(flag.CommandLine.Output(), "unknown discovery %q, expected 'uevent' or 'poll'\n", discovery)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	discoveryConfig := nervo.DefaultDiscoveryConfig()
+	if discoveryConfigPath != "" {
+		var err error
+		discoveryConfig, err = nervo.LoadDiscoveryConfig(discoveryConfigPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	config := nervo.ManagerConfig{RecordDirectory: recordDirectory, ByIDDirectory: discoveryConfig.ByIDDirectory}
+	if replaySessions != "" {
+		for _, session := range strings.Split(replaySessions, ",") {
+			discoveryConfig.StaticPorts = append(discoveryConfig.StaticPorts, "replay://"+session)
+		}
+	}
+	if simulatedControllers > 0 || simulatorConfigPath != "" {
+		simulatorConfig := nervo.DefaultSimulatorConfig(simulatedControllers)
+		if simulatorConfigPath != "" {
+			var err error
+			simulatorConfig, err = nervo.LoadSimulatorConfig(simulatorConfigPath)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		simulator, err := nervo.StartSimulator(simulatorConfig)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer simulator.Close()
+		discoveryConfig.StaticPorts = append(discoveryConfig.StaticPorts, simulator.Ports()...)
+		config.Flasher = simulator.Flasher(&nervo.AvrdudeFlasher{})
+	}
+
+	var err error
+	if discovery == "poll" {
+		config.Discoverer, err = nervo.NewPollingDiscoverer(discoveryConfig, time.Second)
+	} else {
+		config.Discoverer, err = nervo.NewDiscoverer(discoveryConfig)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	m := nervo.NewManager(config)
 	s := nervo.NewGrpcServer(m, grpcPort)
+	if tlsCertFile != "" || tlsKeyFile != "" {
+		tlsConfig, err := nervo.LoadServerTLSConfig(tlsCertFile, tlsKeyFile, tlsClientCAFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		s.TLSConfig = tlsConfig
+	} else if tlsClientCAFile != "" {
+		log.Fatal("tls_client_ca requires tls_cert and tls_key")
+	}
+	if tokensFile != "" {
+		authenticator, err := nervo.LoadTokenAuthenticator(tokensFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		s.Authenticator = authenticator
+	}
+	if artifactDirectory != "" {
+		artifacts, err := nervo.NewArtifactStore(artifactDirectory)
+		if err != nil {
+			log.Fatal(err)
+		}
+		s.Artifacts = artifacts
+	}
+	if flashHistoryDirectory != "" {
+		flashHistory, err := nervo.NewFlashHistory(flashHistoryDirectory)
+		if err != nil {
+			log.Fatal(err)
+		}
+		s.FlashHistory = flashHistory
+	}
 
 	if mhistAddress != "" {
 		namesFilter := strings.Split(mhistNamesFilter, ",")
@@ -34,9 +142,17 @@ func main() {
 			panic(err)
 		}
 		m.VerbMessageHandler = connector.WriteMessage
+		s.Mhist = connector
 		log.Println("reading from subscription. Subscribed to", namesFilter)
 		go connector.ReadMessages()
 	}
 
+	if httpPort != 0 {
+		httpServer := nervo.NewHTTPServer(s, httpPort)
+		if httpAllowedOrigins != "" {
+			httpServer.AllowedOrigins = strings.Split(httpAllowedOrigins, ",")
+		}
+		go httpServer.Listen()
+	}
 	s.Listen()
 }
@@ -0,0 +1,87 @@
+package nervo
+
+import (
+	"context"
+	"crypto/tls"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/codeuniversity/nervo/proto"
+)
+
+// Version of nervo, set at build time with -ldflags "-X github.com/codeuniversity/nervo.Version=<version>"
+var Version = "dev"
+
+var startedAt = time.Now()
+
+// GetServerInfo for the grpc NervoService
+func (s *GrpcServer) GetServerInfo(context.Context, *proto.GetServerInfoRequest) (*proto.GetServerInfoResponse, error) {
+	hostname, _ := os.Hostname()
+	info := &proto.GetServerInfoResponse{
+		Version:          Version,
+		GoVersion:        runtime.Version(),
+		UptimeSeconds:    int64(time.Since(startedAt).Seconds()),
+		Hostname:         hostname,
+		Os:               runtime.GOOS,
+		Arch:             runtime.GOARCH,
+		DiscoveryBackend: s.Manager.discoverer.Name(),
+		MhistConnected:   s.Mhist != nil && s.Mhist.Connected(),
+		Features:         s.features(),
+	}
+
+	if buildInfo, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "dev" && buildInfo.Main.Version != "" && buildInfo.Main.Version != "(devel)" {
+			info.Version = buildInfo.Main.Version
+		}
+		for _, setting := range buildInfo.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				info.VcsRevision = setting.Value
+			case "vcs.time":
+				info.VcsTime = setting.Value
+			case "vcs.modified":
+				info.VcsModified = setting.Value == "true"
+			}
+		}
+	}
+
+	if detector, ok := s.Manager.flasher.(toolDetector); ok {
+		for _, tool := range detector.detectTools() {
+			info.FlasherTools = append(info.FlasherTools, &proto.FlasherTool{
+				Name:    tool.Name,
+				Path:    tool.Path,
+				Version: tool.Version,
+			})
+		}
+	}
+	return info, nil
+}
+
+// features lists the optional parts of nervo this server runs with
+func (s *GrpcServer) features() []string {
+	features := []string{}
+	if s.TLSConfig != nil {
+		features = append(features, "tls")
+		if s.TLSConfig.ClientAuth == tls.RequireAndVerifyClientCert {
+			features = append(features, "mutual_tls")
+		}
+	}
+	if s.Authenticator != nil {
+		features = append(features, "api_tokens")
+	}
+	if s.httpGateway {
+		features = append(features, "http_gateway")
+	}
+	if s.Manager.recordDirectory != "" {
+		features = append(features, "recording")
+	}
+	if _, ok := s.Manager.flasher.(*simulatorFlasher); ok {
+		features = append(features, "simulator")
+	}
+	if s.Mhist != nil {
+		features = append(features, "mhist")
+	}
+	return features
+}
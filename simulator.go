@@ -0,0 +1,256 @@
+package nervo
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SimulatorConfig describes the fake controllers the Simulator creates
+type SimulatorConfig struct {
+	Controllers []SimulatedControllerConfig `json:"controllers"`
+}
+
+// SimulatedControllerConfig scripts the behaviour of a single fake controller
+type SimulatedControllerConfig struct {
+	// Name is announced whenever the controller (re)boots
+	Name string `json:"name"`
+	// Lines are emitted one after another in a loop
+	Lines []SimulatedLine `json:"lines"`
+	// Echo writes every received line back, unless there is a response for it
+	Echo bool `json:"echo"`
+	// Responses maps received lines to the line that is written back
+	Responses map[string]string `json:"responses"`
+}
+
+// SimulatedLine is a line emitted by a fake controller after waiting DelayMs
+type SimulatedLine struct {
+	Line    string `json:"line"`
+	DelayMs int    `json:"delay_ms"`
+}
+
+// DefaultSimulatorConfig returns count legs that report sensor data and feedback and echo what they receive
+func DefaultSimulatorConfig(count int) *SimulatorConfig {
+	config := &SimulatorConfig{}
+	for i := 1; i <= count; i++ {
+		config.Controllers = append(config.Controllers, SimulatedControllerConfig{
+			Name: fmt.Sprintf("leg%d", i),
+			Lines: []SimulatedLine{
+				{Line: fmt.Sprintf("sensor_data {\"leg\": %d, \"angle\": 42}", i), DelayMs: 500},
+				{Line: "feedback step_done", DelayMs: 500},
+			},
+			Echo: true,
+		})
+	}
+	return config
+}
+
+// LoadSimulatorConfig reads a json encoded SimulatorConfig from path
+func LoadSimulatorConfig(configPath string) (*SimulatorConfig, error) {
+	content, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &SimulatorConfig{}
+	if err := json.Unmarshal(content, config); err != nil {
+		return nil, fmt.Errorf("parsing %s: %v", configPath, err)
+	}
+	return config, nil
+}
+
+// Simulator runs pty backed fake controllers, so nervo can be used without any hardware attached
+type Simulator struct {
+	devices []*simulatedDevice
+}
+
+type simulatedDevice struct {
+	config    SimulatedControllerConfig
+	portName  string
+	master    *os.File
+	slave     *os.File
+	bootChan  chan struct{}
+	flashes   int
+	flashLock *sync.Mutex
+}
+
+// StartSimulator creates a pty for every configured controller and starts emitting the scripted lines
+func StartSimulator(config *SimulatorConfig) (*Simulator, error) {
+	s := &Simulator{}
+	for _, controllerConfig := range config.Controllers {
+		master, slave, err := openPty()
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+
+		device := &simulatedDevice{
+			config:    controllerConfig,
+			portName:  slave.Name(),
+			master:    master,
+			slave:     slave,
+			bootChan:  make(chan struct{}, 1),
+			flashLock: &sync.Mutex{},
+		}
+		s.devices = append(s.devices, device)
+		log.Println("simulating", controllerConfig.Name, "at", device.portName)
+
+		go device.emit()
+		go device.respond()
+		device.bootChan <- struct{}{}
+	}
+	return s, nil
+}
+
+// Ports of all fake controllers
+func (s *Simulator) Ports() []string {
+	ports := []string{}
+	for _, device := range s.devices {
+		ports = append(ports, device.portName)
+	}
+	return ports
+}
+
+// Flasher returns a Flasher that pretends to flash fake controllers and hands real ones to fallback
+func (s *Simulator) Flasher(fallback Flasher) Flasher {
+	return &simulatorFlasher{simulator: s, fallback: fallback}
+}
+
+// Close removes all ptys
+func (s *Simulator) Close() {
+	for _, device := range s.devices {
+		device.master.Close()
+		device.slave.Close()
+	}
+}
+
+func (s *Simulator) deviceForPort(portName string) *simulatedDevice {
+	for _, device := range s.devices {
+		if device.portName == portName {
+			return device
+		}
+	}
+	return nil
+}
+
+type simulatorFlasher struct {
+	simulator *Simulator
+	fallback  Flasher
+}
+
+func (f *simulatorFlasher) Flash(portName string, hexFileContent []byte) (output string, err error) {
+	return f.FlashWithProgress(portName, hexFileContent, func(FlashProgress) {})
+}
+
+// FlashWithProgress for the ProgressFlasher interface
+func (f *simulatorFlasher) FlashWithProgress(portName string, hexFileContent []byte, progress func(FlashProgress)) (output string, err error) {
+	device := f.simulator.deviceForPort(portName)
+	if device == nil {
+		if fallback, ok := f.fallback.(ProgressFlasher); ok {
+			return fallback.FlashWithProgress(portName, hexFileContent, progress)
+		}
+		return f.fallback.Flash(portName, hexFileContent)
+	}
+
+	return device.flash(hexFileContent, progress)
+}
+
+// flashSize pretends simulated controllers are arduino unos
+func (f *simulatorFlasher) flashSize(portName string) int {
+	if f.simulator.deviceForPort(portName) != nil {
+		return atmega328pFlashSize
+	}
+	if sizer, ok := f.fallback.(flashSizer); ok {
+		return sizer.flashSize(portName)
+	}
+	return 0
+}
+
+func (f *simulatorFlasher) detectTools() []FlasherTool {
+	tools := []FlasherTool{{Name: "simulator", Path: "builtin"}}
+	if detector, ok := f.fallback.(toolDetector); ok {
+		tools = append(tools, detector.detectTools()...)
+	}
+	return tools
+}
+
+// emit writes the announce message on every boot and the scripted lines in between
+func (d *simulatedDevice) emit() {
+	<-d.bootChan
+	for {
+		d.writeLine("announce " + d.config.Name)
+		d.emitScriptUntilReboot()
+	}
+}
+
+func (d *simulatedDevice) emitScriptUntilReboot() {
+	if len(d.config.Lines) == 0 {
+		<-d.bootChan
+		return
+	}
+
+	for {
+		for _, line := range d.config.Lines {
+			select {
+			case <-d.bootChan:
+				return
+			case <-time.After(time.Duration(line.DelayMs) * time.Millisecond):
+				d.writeLine(line.Line)
+			}
+		}
+	}
+}
+
+func (d *simulatedDevice) respond() {
+	r := bufio.NewReader(d.master)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			log.Println("simulated controller", d.config.Name, "stopped responding:", err)
+			return
+		}
+
+		line = removeNewLineChars(line)
+		if response, ok := d.config.Responses[line]; ok {
+			d.writeLine(response)
+		} else if d.config.Echo {
+			d.writeLine(line)
+		}
+	}
+}
+
+func (d *simulatedDevice) flash(hexFileContent []byte, progress func(FlashProgress)) (output string, err error) {
+	d.flashLock.Lock()
+	defer d.flashLock.Unlock()
+
+	if len(hexFileContent) == 0 || !strings.HasPrefix(string(hexFileContent), ":") {
+		return "simulated flash of " + d.config.Name + " failed: not an intel hex file\n", errors.New("not an intel hex file")
+	}
+
+	progress(FlashProgress{Phase: FlashPhaseErase})
+	for percent := 0; percent <= 100; percent += 25 {
+		time.Sleep(time.Millisecond * 100)
+		progress(FlashProgress{Phase: FlashPhaseWrite, Percent: percent})
+	}
+	progress(FlashProgress{Phase: FlashPhaseVerify, Percent: 100})
+	d.flashes++
+	if err := flushPtyInput(d.slave); err != nil {
+		log.Println("flushing pty of", d.config.Name, "failed:", err)
+	}
+	d.bootChan <- struct{}{}
+
+	return fmt.Sprintf("simulated flash #%d of %s: %d bytes of hex file written\n", d.flashes, d.config.Name, len(hexFileContent)), nil
+}
+
+func (d *simulatedDevice) writeLine(line string) {
+	if _, err := d.master.Write([]byte(line + "\n")); err != nil {
+		log.Println("simulated controller", d.config.Name, "could not write:", err)
+	}
+}
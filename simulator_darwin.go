@@ -0,0 +1,48 @@
+package nervo
+
+import (
+	"bytes"
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	ioctlGetTermios = unix.TIOCGETA
+	ioctlSetTermios = unix.TIOCSETA
+)
+
+// openPty creates a pseudo terminal the way posix_openpt, grantpt, unlockpt and ptsname do.
+// The slave is kept open by the simulator, so the master doesn't see a hangup while no controller has the port opened
+func openPty() (master, slave *os.File, err error) {
+	master, err = os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := ioctl(master.Fd(), unix.TIOCPTYGRANT, 0); err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+	if err := ioctl(master.Fd(), unix.TIOCPTYUNLK, 0); err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+	name := make([]byte, 128)
+	if err := ioctl(master.Fd(), unix.TIOCPTYGNAME, uintptr(unsafe.Pointer(&name[0]))); err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+	if end := bytes.IndexByte(name, 0); end >= 0 {
+		name = name[:end]
+	}
+
+	return openPtySlave(master, string(name))
+}
+
+// flushPtyInput drops everything the fake controller wrote that hasn't been read yet, TCIFLUSH is FREAD on darwin
+func flushPtyInput(slave *os.File) error {
+	queue := int32(unix.TCIFLUSH)
+	return ioctl(slave.Fd(), unix.TIOCFLUSH, uintptr(unsafe.Pointer(&queue)))
+}
@@ -0,0 +1,41 @@
+package nervo
+
+import (
+	"fmt"
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	ioctlGetTermios = unix.TCGETS
+	ioctlSetTermios = unix.TCSETS
+)
+
+// openPty creates a pseudo terminal. The slave is kept open by the simulator,
+// so the master doesn't see a hangup while no controller has the port opened
+func openPty() (master, slave *os.File, err error) {
+	master, err = os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var unlock int32
+	if err := ioctl(master.Fd(), unix.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock))); err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+	var ptyNumber uint32
+	if err := ioctl(master.Fd(), unix.TIOCGPTN, uintptr(unsafe.Pointer(&ptyNumber))); err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+
+	return openPtySlave(master, fmt.Sprintf("/dev/pts/%d", ptyNumber))
+}
+
+// flushPtyInput drops everything the fake controller wrote that hasn't been read yet
+func flushPtyInput(slave *os.File) error {
+	return ioctl(slave.Fd(), unix.TCFLSH, unix.TCIFLUSH)
+}
@@ -0,0 +1,17 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package nervo
+
+import (
+	"errors"
+	"os"
+)
+
+func openPty() (master, slave *os.File, err error) {
+	return nil, nil, errors.New("the simulator is only supported on linux and macOS")
+}
+
+func flushPtyInput(slave *os.File) error {
+	return nil
+}
@@ -0,0 +1,42 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package nervo
+
+import (
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// openPtySlave opens the slave of master at slavePath in raw mode, master is closed if that fails
+func openPtySlave(master *os.File, slavePath string) (*os.File, *os.File, error) {
+	slave, err := os.OpenFile(slavePath, os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+
+	if err := makeRaw(slave); err != nil {
+		master.Close()
+		slave.Close()
+		return nil, nil, err
+	}
+	return master, slave, nil
+}
+
+// makeRaw disables echoing and line editing, otherwise the fake controller would read its own output
+func makeRaw(f *os.File) error {
+	var termios unix.Termios
+	if err := ioctl(f.Fd(), ioctlGetTermios, uintptr(unsafe.Pointer(&termios))); err != nil {
+		return err
+	}
+
+	termios.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	termios.Oflag &^= unix.OPOST
+	termios.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	termios.Cflag &^= unix.CSIZE | unix.PARENB
+	termios.Cflag |= unix.CS8
+	return ioctl(f.Fd(), ioctlSetTermios, uintptr(unsafe.Pointer(&termios)))
+}
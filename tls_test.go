@@ -0,0 +1,108 @@
+package nervo
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/codeuniversity/nervo/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func Test_GrpcServer_MutualTLS(t *testing.T) {
+	ca, caKey := newTestCertificate(t, "nervo test ca", nil, nil)
+	serverCertificate, serverKey := newTestCertificate(t, "nervo", ca, caKey)
+	clientCertificate, clientKey := newTestCertificate(t, "alice", ca, caKey)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+
+	s := NewGrpcServer(NewManager(ManagerConfig{Discoverer: &fakeDiscoverer{portsChan: make(chan []string)}}), 0)
+	s.TLSConfig = &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{serverCertificate.Raw}, PrivateKey: serverKey}},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+
+	identityChan := make(chan string, 1)
+	grpcServer := grpc.NewServer(
+		grpc.Creds(credentials.NewTLS(s.TLSConfig)),
+		grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			identity, _ := clientCertificateIdentity(ctx)
+			identityChan <- identity
+			return handler(ctx, req)
+		}),
+	)
+	proto.RegisterNervoServiceServer(grpcServer, s)
+	listener := bufconn.Listen(1 << 20)
+	go grpcServer.Serve(listener)
+	defer grpcServer.Stop()
+
+	dial := func(certificates []tls.Certificate) proto.NervoServiceClient {
+		conn, err := grpc.Dial(
+			"bufconn",
+			grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+				RootCAs:      pool,
+				ServerName:   "nervo",
+				Certificates: certificates,
+			})),
+			grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+				return listener.Dial()
+			}),
+		)
+		require.NoError(t, err)
+		return proto.NewNervoServiceClient(conn)
+	}
+
+	t.Run("with a client certificate", func(t *testing.T) {
+		client := dial([]tls.Certificate{{Certificate: [][]byte{clientCertificate.Raw}, PrivateKey: clientKey}})
+		_, err := client.ListControllers(context.Background(), &proto.ControllerListRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, "alice", <-identityChan)
+	})
+
+	t.Run("without a client certificate", func(t *testing.T) {
+		client := dial(nil)
+		_, err := client.ListControllers(context.Background(), &proto.ControllerListRequest{})
+		assert.Error(t, err)
+	})
+}
+
+// newTestCertificate creates a certificate signed by parent or a self signed CA if parent is nil
+func newTestCertificate(t *testing.T, commonName string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	certificate, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return certificate, key
+}
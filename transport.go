@@ -0,0 +1,82 @@
+package nervo
+
+import (
+	"io"
+	"net"
+	"strings"
+
+	"github.com/tarm/serial"
+)
+
+const defaultBaudRate = 9600
+
+const (
+	tcpPortPrefix     = "tcp://"
+	rfc2217PortPrefix = "rfc2217://"
+	replayPortPrefix  = "replay://"
+)
+
+// Transport is a byte stream to a controller, no matter whether it is attached locally or over the network
+type Transport interface {
+	io.ReadWriteCloser
+	// SetControlLines sets the DTR and RTS modem control lines. Transports without them fail with UnsupportedError
+	SetControlLines(dtr, rts bool) error
+}
+
+// TransportOpener opens the Transport for a port name
+type TransportOpener func(portName string) (Transport, error)
+
+// OpenTransport picks the transport by the prefix of the port name.
+// "tcp://host:port" is a raw socket (e.g. ser2net in raw mode), "rfc2217://host:port" a telnet com port server,
+// "replay://<session file>" a recorded session and everything else a local serial port
+func OpenTransport(portName string) (Transport, error) {
+	switch {
+	case strings.HasPrefix(portName, tcpPortPrefix):
+		return openTCPTransport(strings.TrimPrefix(portName, tcpPortPrefix))
+	case strings.HasPrefix(portName, rfc2217PortPrefix):
+		return openRFC2217Transport(strings.TrimPrefix(portName, rfc2217PortPrefix), defaultBaudRate)
+	case strings.HasPrefix(portName, replayPortPrefix):
+		return openReplayTransport(portName)
+	default:
+		return openSerialTransport(portName, defaultBaudRate)
+	}
+}
+
+type serialTransport struct {
+	portName string
+	*serial.Port
+}
+
+func openSerialTransport(portName string, baudRate int) (*serialTransport, error) {
+	port, err := serial.OpenPort(&serial.Config{Name: portName, Baud: baudRate})
+	if err != nil {
+		return nil, err
+	}
+
+	return &serialTransport{portName: portName, Port: port}, nil
+}
+
+func (t *serialTransport) SetControlLines(dtr, rts bool) error {
+	return setModemControlLines(t.portName, dtr, rts)
+}
+
+type tcpTransport struct {
+	address string
+	net.Conn
+}
+
+func openTCPTransport(address string) (*tcpTransport, error) {
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tcpTransport{address: address, Conn: conn}, nil
+}
+
+func (t *tcpTransport) SetControlLines(dtr, rts bool) error {
+	return &UnsupportedError{
+		PortName: tcpPortPrefix + t.address,
+		Reason:   "a raw tcp connection carries no control lines, connect with rfc2217:// instead",
+	}
+}
@@ -0,0 +1,216 @@
+package nervo
+
+import (
+	"bufio"
+	"encoding/binary"
+	"net"
+	"sync"
+)
+
+// telnet and RFC 2217 (com port control option) constants
+const (
+	telnetIAC  = 255
+	telnetDont = 254
+	telnetDo   = 253
+	telnetWont = 252
+	telnetWill = 251
+	telnetSB   = 250
+	telnetSE   = 240
+
+	telnetOptionBinary          = 0
+	telnetOptionSuppressGoAhead = 3
+	telnetOptionComPort         = 44
+
+	comPortSetBaudRate = 1
+	comPortSetDataSize = 2
+	comPortSetParity   = 3
+	comPortSetStopSize = 4
+	comPortSetControl  = 5
+
+	comPortParityNone = 1
+	comPortStopSize1  = 1
+	comPortDTROn      = 8
+	comPortDTROff     = 9
+	comPortRTSOn      = 11
+	comPortRTSOff     = 12
+)
+
+// rfc2217Transport talks to a telnet com port server like ser2net or esp-link.
+// Telnet commands are stripped from the read data, 0xff bytes in written data are escaped
+type rfc2217Transport struct {
+	conn       net.Conn
+	reader     *bufio.Reader
+	writeMutex *sync.Mutex
+}
+
+func openRFC2217Transport(address string, baudRate int) (*rfc2217Transport, error) {
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &rfc2217Transport{
+		conn:       conn,
+		reader:     bufio.NewReader(conn),
+		writeMutex: &sync.Mutex{},
+	}
+
+	baud := make([]byte, 4)
+	binary.BigEndian.PutUint32(baud, uint32(baudRate))
+	err = t.writeRaw(
+		[]byte{telnetIAC, telnetWill, telnetOptionComPort},
+		[]byte{telnetIAC, telnetWill, telnetOptionBinary},
+		[]byte{telnetIAC, telnetDo, telnetOptionBinary},
+		[]byte{telnetIAC, telnetDo, telnetOptionSuppressGoAhead},
+		comPortCommand(comPortSetBaudRate, baud...),
+		comPortCommand(comPortSetDataSize, 8),
+		comPortCommand(comPortSetParity, comPortParityNone),
+		comPortCommand(comPortSetStopSize, comPortStopSize1),
+	)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (t *rfc2217Transport) Read(p []byte) (n int, err error) {
+	for n < len(p) {
+		if n > 0 && t.reader.Buffered() == 0 {
+			break
+		}
+
+		b, err := t.reader.ReadByte()
+		if err != nil {
+			return n, err
+		}
+		if b != telnetIAC {
+			p[n] = b
+			n++
+			continue
+		}
+
+		isData, err := t.handleCommand()
+		if err != nil {
+			return n, err
+		}
+		if isData {
+			p[n] = telnetIAC
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (t *rfc2217Transport) Write(p []byte) (int, error) {
+	escaped := make([]byte, 0, len(p))
+	for _, b := range p {
+		escaped = append(escaped, b)
+		if b == telnetIAC {
+			escaped = append(escaped, telnetIAC)
+		}
+	}
+
+	if err := t.writeRaw(escaped); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (t *rfc2217Transport) Close() error {
+	return t.conn.Close()
+}
+
+// SetControlLines asks the com port server to set DTR and RTS with COM-PORT-OPTION SET-CONTROL
+func (t *rfc2217Transport) SetControlLines(dtr, rts bool) error {
+	dtrValue, rtsValue := byte(comPortDTROff), byte(comPortRTSOff)
+	if dtr {
+		dtrValue = comPortDTROn
+	}
+	if rts {
+		rtsValue = comPortRTSOn
+	}
+
+	return t.writeRaw(
+		comPortCommand(comPortSetControl, dtrValue),
+		comPortCommand(comPortSetControl, rtsValue),
+	)
+}
+
+// handleCommand consumes a telnet command after an IAC. isData is true for an escaped 0xff data byte
+func (t *rfc2217Transport) handleCommand() (isData bool, err error) {
+	command, err := t.reader.ReadByte()
+	if err != nil {
+		return false, err
+	}
+
+	switch command {
+	case telnetIAC:
+		return true, nil
+	case telnetDo, telnetDont, telnetWill, telnetWont:
+		option, err := t.reader.ReadByte()
+		if err != nil {
+			return false, err
+		}
+		return false, t.negotiate(command, option)
+	case telnetSB:
+		// the server only acknowledges our com port settings, nothing we need to act on
+		for {
+			b, err := t.reader.ReadByte()
+			if err != nil {
+				return false, err
+			}
+			if b != telnetIAC {
+				continue
+			}
+			next, err := t.reader.ReadByte()
+			if err != nil {
+				return false, err
+			}
+			if next == telnetSE {
+				return false, nil
+			}
+		}
+	default:
+		return false, nil
+	}
+}
+
+func (t *rfc2217Transport) negotiate(command, option byte) error {
+	supported := option == telnetOptionBinary || option == telnetOptionSuppressGoAhead || option == telnetOptionComPort
+	switch command {
+	case telnetDo:
+		if !supported {
+			return t.writeRaw([]byte{telnetIAC, telnetWont, option})
+		}
+	case telnetWill:
+		if !supported {
+			return t.writeRaw([]byte{telnetIAC, telnetDont, option})
+		}
+	}
+	return nil
+}
+
+func (t *rfc2217Transport) writeRaw(chunks ...[]byte) error {
+	t.writeMutex.Lock()
+	defer t.writeMutex.Unlock()
+
+	for _, chunk := range chunks {
+		if _, err := t.conn.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func comPortCommand(command byte, values ...byte) []byte {
+	message := []byte{telnetIAC, telnetSB, telnetOptionComPort, command}
+	for _, value := range values {
+		message = append(message, value)
+		if value == telnetIAC {
+			message = append(message, telnetIAC)
+		}
+	}
+	return append(message, telnetIAC, telnetSE)
+}
@@ -0,0 +1,100 @@
+package nervo
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_rfc2217Transport(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	transport := &rfc2217Transport{
+		conn:       client,
+		reader:     bufio.NewReader(client),
+		writeMutex: &sync.Mutex{},
+	}
+
+	t.Run("strips telnet commands and unescapes data", func(t *testing.T) {
+		go func() {
+			server.Write([]byte("sensor_data 1"))
+			server.Write([]byte{telnetIAC, telnetSB, telnetOptionComPort, comPortSetBaudRate + 100, 0, 0, 0x25, 0x80, telnetIAC, telnetSE})
+			server.Write([]byte{telnetIAC, telnetIAC, '\n'})
+		}()
+
+		line, err := bufio.NewReader(transport).ReadString('\n')
+		require.NoError(t, err)
+		assert.Equal(t, "sensor_data 1\xff\n", line)
+	})
+
+	t.Run("refuses unsupported options", func(t *testing.T) {
+		go server.Write([]byte{telnetIAC, telnetDo, 24, 'x'})
+
+		answer := make([]byte, 3)
+		readDone := make(chan error)
+		go func() {
+			_, err := io.ReadFull(server, answer)
+			readDone <- err
+		}()
+
+		b := make([]byte, 1)
+		_, err := transport.Read(b)
+		require.NoError(t, err)
+		require.NoError(t, <-readDone)
+		assert.Equal(t, []byte{telnetIAC, telnetWont, 24}, answer)
+		assert.Equal(t, []byte("x"), b)
+	})
+
+	t.Run("escapes written data", func(t *testing.T) {
+		written := make([]byte, 4)
+		readDone := make(chan error)
+		go func() {
+			_, err := io.ReadFull(server, written)
+			readDone <- err
+		}()
+
+		n, err := transport.Write([]byte{'a', telnetIAC, 'b'})
+		require.NoError(t, err)
+		require.NoError(t, <-readDone)
+		assert.Equal(t, 3, n)
+		assert.Equal(t, []byte{'a', telnetIAC, telnetIAC, 'b'}, written)
+	})
+
+	t.Run("sets the control lines", func(t *testing.T) {
+		written := make([]byte, 14)
+		readDone := make(chan error)
+		go func() {
+			_, err := io.ReadFull(server, written)
+			readDone <- err
+		}()
+
+		require.NoError(t, transport.SetControlLines(false, true))
+		require.NoError(t, <-readDone)
+		assert.Equal(t, []byte{
+			telnetIAC, telnetSB, telnetOptionComPort, comPortSetControl, comPortDTROff, telnetIAC, telnetSE,
+			telnetIAC, telnetSB, telnetOptionComPort, comPortSetControl, comPortRTSOn, telnetIAC, telnetSE,
+		}, written)
+	})
+}
+
+func Test_TransportControlLines(t *testing.T) {
+	tests := []struct {
+		testMessage string
+		transport   Transport
+	}{
+		{testMessage: "raw tcp has none", transport: &tcpTransport{address: "esp:23"}},
+		{testMessage: "replays have none", transport: &replayTransport{sessionPath: "leg1.jsonl"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.testMessage, func(t *testing.T) {
+			assert.IsType(t, &UnsupportedError{}, tt.transport.SetControlLines(true, true))
+		})
+	}
+}
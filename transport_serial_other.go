@@ -0,0 +1,8 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package nervo
+
+func setModemControlLines(portName string, dtr, rts bool) error {
+	return &UnsupportedError{PortName: portName, Reason: "control lines of serial ports are only supported on linux and macOS"}
+}
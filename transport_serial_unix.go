@@ -0,0 +1,39 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package nervo
+
+import (
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// setModemControlLines opens the device a second time, the lines belong to the device, not to the file descriptor
+func setModemControlLines(portName string, dtr, rts bool) error {
+	f, err := os.OpenFile(portName, unix.O_RDWR|unix.O_NOCTTY|unix.O_NONBLOCK, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	lines := []struct {
+		bit int32
+		set bool
+	}{
+		{unix.TIOCM_DTR, dtr},
+		{unix.TIOCM_RTS, rts},
+	}
+	for _, line := range lines {
+		request := uintptr(unix.TIOCMBIC)
+		if line.set {
+			request = unix.TIOCMBIS
+		}
+		bit := line.bit
+		if err := ioctl(f.Fd(), request, uintptr(unsafe.Pointer(&bit))); err != nil {
+			return err
+		}
+	}
+	return nil
+}